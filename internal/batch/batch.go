@@ -0,0 +1,391 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+)
+
+// Job describes a batch of files converted to a single target format.
+type Job struct {
+	// Files are the source paths to convert.
+	Files []string
+	// TargetExt is the target extension. Empty means compress in the source format.
+	TargetExt string
+	// Quality is the quality label passed to converters (High, Balanced, Compact).
+	Quality string
+	// OutDir places outputs in this directory instead of next to the source.
+	OutDir string
+	// Jobs is the maximum number of concurrent conversions of any cost.
+	// Zero leaves it to Limits.
+	Jobs int
+	// Limits caps concurrent conversions per cost class (see
+	// converter.CostOf) and decides how many threads each gets.
+	Limits Limits
+	// Options are extra converter options such as pandocArgs or ebookArgs.
+	Options converter.Options
+	// OnProgress, when not nil, receives the completed fraction of each file
+	// as converters report it, starting with 0 when the file begins. It is
+	// called concurrently from the worker goroutines.
+	OnProgress func(path string, fraction float64)
+	// Conflict is applied when an output already exists, or is claimed by
+	// another file in the batch. Empty means ConflictOverwrite.
+	Conflict ConflictPolicy
+	// Ask resolves ConflictAsk for one output path and returns ConflictSkip,
+	// ConflictOverwrite or ConflictRename. Calls are serialized. A nil Ask
+	// skips the file.
+	Ask func(outputPath string) ConflictPolicy
+	// Cache, when not nil, skips files converted before from the same
+	// content with the same converter, target and options, as long as that
+	// output is unchanged. Finished conversions are recorded in it; callers
+	// persist them with Cache.Save.
+	Cache *cache.Cache
+	// Force converts every file even when Cache has a match, and records
+	// the fresh outputs.
+	Force bool
+	// Verify reads every output back before it replaces anything (see
+	// converter.Verify). Outputs that fail fail their file with an error
+	// wrapping converter.ErrVerification.
+	Verify bool
+	// Journal, when not nil, records each file as it starts and finishes,
+	// so an interrupted batch can be resumed (see ResumeJob). The caller
+	// creates it from Spec and removes it once the batch has finished.
+	Journal *journal.Journal
+}
+
+// Result holds the outcome of converting a single file.
+type Result struct {
+	Path       string
+	OutputPath string
+	Converter  string
+	Err        error
+	// Warning notes a problem that did not stop the conversion, such as an
+	// extension that does not match the file content.
+	Warning string
+	// Skipped is set when the output already existed and the conflict
+	// policy left it alone.
+	Skipped bool
+	// Cached is set when the output of an identical earlier conversion was
+	// reused instead of converting again.
+	Cached     bool
+	Duration   time.Duration
+	InputSize  int64
+	OutputSize int64
+}
+
+// Report holds the outcome of a whole batch.
+type Report struct {
+	Results  []Result
+	Duration time.Duration
+	// Cancelled is set when the batch was stopped before every file ran.
+	// Files that never started have no Result.
+	Cancelled bool
+}
+
+// Failed returns the number of results that carry an error.
+func (r Report) Failed() int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Unverified returns the number of files whose output failed verification.
+// They are also counted by Failed.
+func (r Report) Unverified() int {
+	count := 0
+	for _, res := range r.Results {
+		if errors.Is(res.Err, converter.ErrVerification) {
+			count++
+		}
+	}
+	return count
+}
+
+// Skipped returns the number of files skipped because their output existed.
+func (r Report) Skipped() int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// Cached returns the number of files whose earlier output was reused.
+func (r Report) Cached() int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Cached {
+			count++
+		}
+	}
+	return count
+}
+
+// OutputPath returns where the converted file for path is written.
+// Compression (empty targetExt) adds a "_compressed" suffix, and a conversion
+// that would overwrite its own source adds "_converted".
+func OutputPath(path, targetExt, outDir string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	var outputPath string
+	if targetExt == "" {
+		// Compression mode
+		outputPath = base + "_compressed" + ext
+	} else {
+		// Conversion mode
+		outputPath = base + targetExt
+		if outputPath == path {
+			outputPath = base + "_converted" + targetExt
+		}
+	}
+
+	if outDir != "" {
+		outputPath = filepath.Join(outDir, filepath.Base(outputPath))
+	}
+	return outputPath
+}
+
+// Run converts every file in the job using converters resolved through mgr.
+// onResult, when not nil, is called after each file finishes; calls are serialized.
+// Cancelling ctx stops running conversions and skips files not yet started.
+//
+// Conversions are scheduled by cost: light in-process conversions run
+// alongside each other, while heavy ones such as ffmpeg are limited so the
+// threads they are given add up to about the number of CPUs.
+func Run(ctx context.Context, mgr *converter.Manager, job Job, onResult func(Result)) Report {
+	startTime := time.Now()
+	results := make([]Result, 0, len(job.Files))
+	var mu sync.Mutex
+	claims := newOutputClaims()
+	sched := newScheduler(job.Jobs, job.Limits)
+
+	opts := converter.Options{}
+	for k, v := range job.Options {
+		opts[k] = v
+	}
+	opts["quality"] = job.Quality
+
+	// Preparing a file reads, and with a cache hashes, its source, so that
+	// is bounded too
+	preparing := job.Jobs
+	if preparing <= 0 {
+		preparing = runtime.NumCPU()
+	}
+	semaphore := make(chan struct{}, preparing)
+
+	var wg sync.WaitGroup
+	for _, path := range job.Files {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				<-semaphore
+				return
+			}
+			t, res, finished := prepare(mgr, job, claims, path, opts)
+			<-semaphore
+
+			if !finished {
+				threads, err := sched.acquire(ctx, t.cost)
+				if err != nil {
+					// Cancelled before it started
+					return
+				}
+				fileCtx := converter.WithThreads(ctx, threads)
+				if job.Verify {
+					fileCtx = converter.WithVerification(fileCtx)
+				}
+				if job.OnProgress != nil {
+					job.OnProgress(path, 0)
+					fileCtx = converter.WithProgress(fileCtx, func(fraction float64) {
+						job.OnProgress(path, fraction)
+					})
+				}
+				res = t.convert(fileCtx, job, opts)
+				sched.release(t.cost)
+			}
+			journalResult(job.Journal, res)
+
+			mu.Lock()
+			results = append(results, res)
+			if onResult != nil {
+				onResult(res)
+			}
+			mu.Unlock()
+		}(path)
+	}
+
+	wg.Wait()
+	return Report{
+		Results:   results,
+		Duration:  time.Since(startTime),
+		Cancelled: ctx.Err() != nil,
+	}
+}
+
+// task is a file ready to convert: its converter is found and its output
+// path claimed.
+type task struct {
+	path       string
+	srcExt     string
+	conv       converter.Converter
+	cost       converter.Cost
+	outputPath string
+	// key records the conversion in the job's cache; empty means not to.
+	key     string
+	warning string
+	// prepared is how long preparing took; time spent waiting for the
+	// scheduler is not counted in the file's Duration.
+	prepared time.Duration
+}
+
+// prepare detects path's format, finds its converter and claims its output.
+// Files that need no conversion, because they cannot be converted, their
+// output is skipped or an earlier output is reused, come back finished with
+// their Result.
+func prepare(mgr *converter.Manager, job Job, claims *outputClaims, path string, opts converter.Options) (task, Result, bool) {
+	fileStart := time.Now()
+
+	src, conv, err := locate(mgr, job, path)
+	if err != nil {
+		return task{}, Result{
+			Path:      path,
+			Err:       err,
+			Warning:   src.warning,
+			Duration:  time.Since(fileStart),
+			InputSize: fileSize(path),
+		}, true
+	}
+
+	key := cacheKey(job, path, conv.Name(), src.targetExt, opts)
+	outputPath, reused := reuseCached(job, claims, key, src.output)
+	if reused {
+		return task{}, Result{
+			Path:       path,
+			OutputPath: outputPath,
+			Converter:  conv.Name(),
+			Warning:    src.warning,
+			Cached:     true,
+			Duration:   time.Since(fileStart),
+			InputSize:  fileSize(path),
+			OutputSize: fileSize(outputPath),
+		}, true
+	}
+	if outputPath == "" {
+		return task{}, Result{
+			Path:       path,
+			OutputPath: src.output,
+			Converter:  conv.Name(),
+			Warning:    src.warning,
+			Skipped:    true,
+			Duration:   time.Since(fileStart),
+			InputSize:  fileSize(path),
+		}, true
+	}
+	return task{
+		path:       path,
+		srcExt:     src.ext,
+		conv:       conv,
+		cost:       converter.CostOf(conv, src.ext, src.targetExt),
+		outputPath: outputPath,
+		key:        key,
+		warning:    src.warning,
+		prepared:   time.Since(fileStart),
+	}, Result{}, false
+}
+
+// source is a file of a job with its format detected.
+type source struct {
+	// ext is the detected format.
+	ext string
+	// targetExt is the format it converts to, its own when compressing.
+	targetExt string
+	// output is where its output goes before conflicts are resolved.
+	output  string
+	warning string
+}
+
+// locate detects path's format and finds the converter for it. The source
+// is filled in as far as it got when locating fails.
+func locate(mgr *converter.Manager, job Job, path string) (source, converter.Converter, error) {
+	det, err := converter.DetectFile(path)
+	if err != nil {
+		return source{}, nil, err
+	}
+	src := source{ext: det.Format(), warning: det.Warning()}
+
+	// Name outputs of extension-less files after their detected format
+	name := path
+	if filepath.Ext(path) == "" {
+		name = path + src.ext
+	}
+	src.targetExt = job.TargetExt
+	if src.targetExt == "" {
+		src.targetExt = filepath.Ext(name)
+	}
+	src.output = OutputPath(name, job.TargetExt, job.OutDir)
+
+	conv, err := mgr.FindConverter(src.ext, src.targetExt)
+	if err != nil {
+		return src, nil, err
+	}
+	return src, conv, nil
+}
+
+// convert runs the conversion t prepared.
+func (t task) convert(ctx context.Context, job Job, opts converter.Options) Result {
+	start := time.Now()
+	journalStart(job.Journal, t.path, t.outputPath)
+	src, cleanup, err := converter.StageSource(t.path, t.srcExt)
+	if err == nil {
+		err = converter.ConvertContext(ctx, t.conv, src, t.outputPath, opts)
+		cleanup()
+	}
+
+	res := Result{
+		Path:       t.path,
+		OutputPath: t.outputPath,
+		Converter:  t.conv.Name(),
+		Err:        err,
+		Warning:    t.warning,
+		Duration:   t.prepared + time.Since(start),
+		InputSize:  fileSize(t.path),
+	}
+	if err == nil {
+		res.OutputSize = fileSize(t.outputPath)
+		if t.key != "" {
+			job.Cache.Store(t.key, t.outputPath, t.conv.Name())
+		}
+	}
+	return res
+}
+
+// fileSize returns the size of path, or 0 when it cannot be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
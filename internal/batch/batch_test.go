@@ -0,0 +1,272 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// fakeConverter copies the source to the target, or fails for sources containing "bad".
+type fakeConverter struct{}
+
+func (c *fakeConverter) Name() string { return "Fake" }
+
+func (c *fakeConverter) CanConvert(srcExt, targetExt string) bool {
+	return srcExt == ".txt" && (targetExt == ".txt" || targetExt == ".out")
+}
+
+func (c *fakeConverter) SupportedSourceExtensions() []string { return []string{".txt"} }
+
+func (c *fakeConverter) SupportedTargetFormats(srcExt string) []string {
+	if srcExt != ".txt" {
+		return nil
+	}
+	return []string{".out"}
+}
+
+func (c *fakeConverter) Convert(src, target string, opts converter.Options) error {
+	if strings.Contains(src, "bad") {
+		return errors.New("boom")
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		targetExt string
+		outDir    string
+		want      string
+	}{
+		{"Conversion", filepath.Join("dir", "a.png"), ".webp", "", filepath.Join("dir", "a.webp")},
+		{"Compression", filepath.Join("dir", "a.png"), "", "", filepath.Join("dir", "a_compressed.png")},
+		{"Same extension", filepath.Join("dir", "a.pdf"), ".pdf", "", filepath.Join("dir", "a_converted.pdf")},
+		{"Out dir", filepath.Join("dir", "a.png"), ".webp", "out", filepath.Join("out", "a.webp")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OutputPath(tt.path, tt.targetExt, tt.outDir); got != tt.want {
+				t.Errorf("OutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.txt")
+	bad := filepath.Join(tmpDir, "bad.txt")
+	unknown := filepath.Join(tmpDir, "photo.bmp")
+	for _, p := range []string{good, bad, unknown} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", p, err)
+		}
+	}
+
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+
+	calls := 0
+	report := Run(context.Background(), mgr, Job{Files: []string{good, bad, unknown}, TargetExt: ".out", Jobs: 2}, func(Result) {
+		calls++
+	})
+
+	if len(report.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(report.Results))
+	}
+	if calls != 3 {
+		t.Errorf("expected onResult to be called 3 times, got %d", calls)
+	}
+	if report.Failed() != 2 {
+		t.Errorf("expected 2 failures, got %d", report.Failed())
+	}
+
+	for _, res := range report.Results {
+		if res.Path == good {
+			if res.Err != nil {
+				t.Errorf("unexpected error for %s: %v", good, res.Err)
+			}
+			if res.Converter != "Fake" {
+				t.Errorf("expected converter name Fake, got %q", res.Converter)
+			}
+			if _, err := os.Stat(res.OutputPath); err != nil {
+				t.Errorf("output not written: %v", err)
+			}
+		}
+	}
+}
+
+// blockingConverter writes partial output and then waits for cancellation.
+type blockingConverter struct {
+	fakeConverter
+	started chan struct{}
+}
+
+func (c *blockingConverter) ConvertContext(ctx context.Context, src, target string, opts converter.Options) error {
+	if err := os.WriteFile(target, []byte("partial"), 0644); err != nil {
+		return err
+	}
+	c.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, p)
+	}
+
+	conv := &blockingConverter{started: make(chan struct{}, 1)}
+	mgr := converter.NewManager()
+	mgr.Register(conv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-conv.started
+		cancel()
+	}()
+	report := Run(ctx, mgr, Job{Files: files, TargetExt: ".out", Jobs: 1}, nil)
+
+	if !report.Cancelled {
+		t.Error("expected report to be marked cancelled")
+	}
+	if len(report.Results) != 1 {
+		t.Fatalf("expected only the started file in the report, got %d", len(report.Results))
+	}
+	res := report.Results[0]
+	if ErrorClass(res.Err) != ErrorClassCancelled {
+		t.Errorf("expected cancelled error class, got %q (%v)", ErrorClass(res.Err), res.Err)
+	}
+	if _, err := os.Stat(res.OutputPath); !os.IsNotExist(err) {
+		t.Errorf("expected partial output to be removed, stat err = %v", err)
+	}
+}
+
+// halfwayConverter reports 50% progress before copying the source.
+type halfwayConverter struct {
+	fakeConverter
+}
+
+func (c *halfwayConverter) ConvertContext(ctx context.Context, src, target string, opts converter.Options) error {
+	converter.ReportProgress(ctx, 0.5)
+	return c.Convert(src, target, opts)
+}
+
+func TestRun_OnProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, p)
+	}
+
+	mgr := converter.NewManager()
+	mgr.Register(&halfwayConverter{})
+
+	var mu sync.Mutex
+	got := make(map[string][]float64)
+	job := Job{Files: files, TargetExt: ".out", Jobs: 2, OnProgress: func(path string, fraction float64) {
+		mu.Lock()
+		got[path] = append(got[path], fraction)
+		mu.Unlock()
+	}}
+	if report := Run(context.Background(), mgr, job, nil); report.Failed() != 0 {
+		t.Fatalf("unexpected failures: %+v", report.Results)
+	}
+
+	for _, f := range files {
+		if want := []float64{0, 0.5}; len(got[f]) != 2 || got[f][0] != want[0] || got[f][1] != want[1] {
+			t.Errorf("progress for %s = %v, want %v", filepath.Base(f), got[f], want)
+		}
+	}
+}
+
+func TestRun_DetectsFormatFromContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	noExt := filepath.Join(tmpDir, "download")
+	wrongExt := filepath.Join(tmpDir, "photo.webp")
+	for _, p := range []string{noExt, wrongExt} {
+		if err := os.WriteFile(p, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := Run(context.Background(), converter.NewDefaultManager(), Job{Files: []string{noExt, wrongExt}, TargetExt: ".jpg"}, nil)
+	if report.Failed() != 0 {
+		t.Fatalf("unexpected failures: %+v", report.Results)
+	}
+
+	for _, res := range report.Results {
+		switch res.Path {
+		case noExt:
+			if res.OutputPath != filepath.Join(tmpDir, "download.jpg") {
+				t.Errorf("unexpected output path %s", res.OutputPath)
+			}
+			if res.Warning != "" {
+				t.Errorf("expected no warning for a file without extension, got %q", res.Warning)
+			}
+		case wrongExt:
+			if !strings.Contains(res.Warning, ".png") {
+				t.Errorf("expected a mismatch warning naming .png, got %q", res.Warning)
+			}
+		}
+	}
+}
+
+func TestRun_Verify(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.txt")
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(good, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+
+	report := Run(context.Background(), mgr, Job{Files: []string{good, empty}, TargetExt: ".out", Verify: true}, nil)
+	if report.Failed() != 1 || report.Unverified() != 1 {
+		t.Fatalf("expected the empty output to fail verification, got %+v", report.Results)
+	}
+	for _, res := range report.Results {
+		if res.Path == empty && ErrorClass(res.Err) != ErrorClassVerification {
+			t.Errorf("unexpected error class for %v", res.Err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "empty.out")); !os.IsNotExist(err) {
+		t.Error("an output that failed verification should not be kept")
+	}
+	if s := report.Summarize(); s.Unverified != 1 || s.Succeeded != 1 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+}
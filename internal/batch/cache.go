@@ -0,0 +1,67 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// cacheKey returns the job cache key for converting path, or "" when the job
+// has no cache or path cannot be read.
+func cacheKey(job Job, path, converterName, targetExt string, opts converter.Options) string {
+	if job.Cache == nil {
+		return ""
+	}
+	hash, err := cache.HashFile(path)
+	if err != nil {
+		return ""
+	}
+	return cache.Key(hash, converterName, targetExt, opts)
+}
+
+// reuseCached resolves where a file's output goes, reusing an earlier
+// identical conversion when the cache has one. It returns the output path
+// and whether the earlier output was reused, either in place at wanted or
+// linked to the path the conflict policy picks. When nothing was reused the
+// path is where to convert to, or "" when the file is skipped.
+func reuseCached(job Job, claims *outputClaims, key, wanted string) (string, bool) {
+	if key == "" || job.Force {
+		return claims.resolve(job, wanted), false
+	}
+	entry, ok := job.Cache.Lookup(key)
+	if !ok {
+		return claims.resolve(job, wanted), false
+	}
+	if entry.Output == wanted && claims.claim(wanted) {
+		return wanted, true
+	}
+
+	outputPath := claims.resolve(job, wanted)
+	if outputPath == "" || linkFile(entry.Output, outputPath) != nil {
+		return outputPath, false
+	}
+	job.Cache.Store(key, outputPath, entry.Converter)
+	return outputPath, true
+}
+
+// linkFile places a hard link to src at dst, replacing dst atomically, and
+// copies src when it cannot be linked, e.g. across filesystems.
+func linkFile(src, dst string) error {
+	tmp, err := converter.TempTarget(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.Link(src, tmp); err != nil {
+		if err := converter.CopyFile(src, tmp); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to move output into place: %w", err)
+	}
+	return nil
+}
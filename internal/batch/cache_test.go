@@ -0,0 +1,71 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func TestRun_Cache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cache.OpenFile(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+	job := Job{Files: []string{src}, TargetExt: ".out", Cache: c, Conflict: ConflictRename}
+	run := func(job Job) Result {
+		t.Helper()
+		report := Run(context.Background(), mgr, job, nil)
+		if len(report.Results) != 1 || report.Results[0].Err != nil {
+			t.Fatalf("unexpected results: %+v", report.Results)
+		}
+		return report.Results[0]
+	}
+
+	if res := run(job); res.Cached {
+		t.Fatal("the first run has nothing cached")
+	}
+	// The unchanged output is reused rather than renamed around
+	res := run(job)
+	if !res.Cached || res.OutputPath != filepath.Join(dir, "a.out") {
+		t.Errorf("expected the output to be reused in place, got %+v", res)
+	}
+
+	// Force converts again
+	forced := job
+	forced.Force = true
+	forced.Conflict = ConflictOverwrite
+	if res := run(forced); res.Cached {
+		t.Error("expected Force to convert")
+	}
+
+	// A new output location gets a link to the earlier output
+	linked := job
+	linked.OutDir = filepath.Join(dir, "out")
+	if err := os.Mkdir(linked.OutDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	res = run(linked)
+	if !res.Cached || readString(t, filepath.Join(linked.OutDir, "a.out")) != "data" {
+		t.Errorf("expected the earlier output to be linked, got %+v", res)
+	}
+
+	// Changed content is converted
+	if err := os.WriteFile(src, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	job.Conflict = ConflictOverwrite
+	if res := run(job); res.Cached || readString(t, res.OutputPath) != "changed" {
+		t.Errorf("expected changed content to be converted, got %+v", res)
+	}
+}
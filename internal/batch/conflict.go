@@ -0,0 +1,133 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConflictPolicy decides what happens when a file's output already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing output.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip leaves the existing output alone and skips the file.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictRename writes to the first free name with a numeric suffix,
+	// such as photo_1.png.
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictAsk defers the decision to Job.Ask.
+	ConflictAsk ConflictPolicy = "ask"
+)
+
+// ConflictPolicies lists the accepted policies.
+var ConflictPolicies = []ConflictPolicy{ConflictOverwrite, ConflictSkip, ConflictRename, ConflictAsk}
+
+// ParseConflictPolicy parses a policy name, ignoring case.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for _, p := range ConflictPolicies {
+		if strings.EqualFold(s, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want overwrite, skip, rename or ask)", s)
+}
+
+// outputClaims tracks the outputs of a running batch, so two files that map
+// to the same output, or renames racing each other, are treated as conflicts
+// too.
+type outputClaims struct {
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+func newOutputClaims() *outputClaims {
+	return &outputClaims{claimed: make(map[string]bool)}
+}
+
+func (c *outputClaims) taken(path string) bool {
+	if c.claimed[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// resolve applies the job's conflict policy to outputPath and claims the
+// result. It returns the path to write, or "" when the file is skipped.
+// Resolution is serialized, so Job.Ask is never called concurrently.
+func (c *outputClaims) resolve(job Job, outputPath string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.taken(outputPath) {
+		policy := job.Conflict
+		if policy == ConflictAsk {
+			policy = ConflictSkip
+			if job.Ask != nil {
+				policy = job.Ask(outputPath)
+			}
+		}
+		switch policy {
+		case ConflictOverwrite, "":
+		case ConflictRename:
+			outputPath = c.freeName(outputPath)
+		default:
+			return ""
+		}
+	}
+	c.claimed[outputPath] = true
+	return outputPath
+}
+
+// preview is resolve for a plan: it never asks, and also returns the policy
+// applied when outputPath was taken. A conflict left to ConflictAsk keeps
+// outputPath, as the answer is not known yet.
+func (c *outputClaims) preview(job Job, outputPath string) (string, ConflictPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var applied ConflictPolicy
+	if c.taken(outputPath) {
+		applied = job.Conflict
+		if applied == "" {
+			applied = ConflictOverwrite
+		}
+		switch applied {
+		case ConflictOverwrite, ConflictAsk:
+		case ConflictRename:
+			outputPath = c.freeName(outputPath)
+		default:
+			return "", applied
+		}
+	}
+	c.claimed[outputPath] = true
+	return outputPath, applied
+}
+
+// claim claims path for a file whose output is already in place. It fails
+// when another file in the batch claimed path first.
+func (c *outputClaims) claim(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.claimed[path] {
+		return false
+	}
+	c.claimed[path] = true
+	return true
+}
+
+// freeName returns path with the lowest numeric suffix that is not taken.
+func (c *outputClaims) freeName(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if !c.taken(candidate) {
+			return candidate
+		}
+	}
+}
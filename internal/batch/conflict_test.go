@@ -0,0 +1,154 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	for _, p := range ConflictPolicies {
+		got, err := ParseConflictPolicy(string(p))
+		if err != nil || got != p {
+			t.Errorf("ParseConflictPolicy(%q) = %q, %v", p, got, err)
+		}
+	}
+	if got, err := ParseConflictPolicy("Rename"); err != nil || got != ConflictRename {
+		t.Errorf("expected case-insensitive match, got %q, %v", got, err)
+	}
+	if _, err := ParseConflictPolicy("merge"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+// runWithExistingOutput converts a.txt to a.out while a.out already exists.
+func runWithExistingOutput(t *testing.T, job Job) (Report, string) {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.out"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+	job.Files = []string{src}
+	job.TargetExt = ".out"
+	return Run(context.Background(), mgr, job, nil), dir
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRun_ConflictPolicies(t *testing.T) {
+	t.Run("Overwrite", func(t *testing.T) {
+		report, dir := runWithExistingOutput(t, Job{Conflict: ConflictOverwrite})
+		if report.Failed() != 0 || report.Skipped() != 0 {
+			t.Fatalf("unexpected results: %+v", report.Results)
+		}
+		if got := readString(t, filepath.Join(dir, "a.out")); got != "new" {
+			t.Errorf("expected output to be replaced, got %q", got)
+		}
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		report, dir := runWithExistingOutput(t, Job{Conflict: ConflictSkip})
+		if report.Skipped() != 1 || report.Failed() != 0 {
+			t.Fatalf("expected one skipped file, got %+v", report.Results)
+		}
+		if got := readString(t, filepath.Join(dir, "a.out")); got != "old" {
+			t.Errorf("existing output was modified: %q", got)
+		}
+		if s := report.Summarize(); s.Skipped != 1 || s.Succeeded != 0 || s.Results[0].Status != "skipped" {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		report, dir := runWithExistingOutput(t, Job{Conflict: ConflictRename})
+		want := filepath.Join(dir, "a_1.out")
+		if len(report.Results) != 1 || report.Results[0].OutputPath != want {
+			t.Fatalf("expected output at %s, got %+v", want, report.Results)
+		}
+		if got := readString(t, filepath.Join(dir, "a.out")); got != "old" {
+			t.Errorf("existing output was modified: %q", got)
+		}
+		if got := readString(t, want); got != "new" {
+			t.Errorf("expected renamed output, got %q", got)
+		}
+	})
+
+	t.Run("Ask", func(t *testing.T) {
+		var asked string
+		report, dir := runWithExistingOutput(t, Job{
+			Conflict: ConflictAsk,
+			Ask: func(path string) ConflictPolicy {
+				asked = path
+				return ConflictRename
+			},
+		})
+		if asked != filepath.Join(dir, "a.out") {
+			t.Errorf("expected to be asked about a.out, got %q", asked)
+		}
+		if report.Results[0].OutputPath != filepath.Join(dir, "a_1.out") {
+			t.Errorf("expected the answer to be applied, got %+v", report.Results[0])
+		}
+	})
+
+	t.Run("AskWithoutCallbackSkips", func(t *testing.T) {
+		report, _ := runWithExistingOutput(t, Job{Conflict: ConflictAsk})
+		if report.Skipped() != 1 {
+			t.Errorf("expected the file to be skipped, got %+v", report.Results)
+		}
+	})
+}
+
+func TestRun_RenameSeparatesSharedOutputs(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", filepath.Join("sub", "a.txt")} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+	report := Run(context.Background(), mgr, Job{
+		Files:     files,
+		TargetExt: ".out",
+		OutDir:    out,
+		Jobs:      2,
+		Conflict:  ConflictRename,
+	}, nil)
+
+	if report.Failed() != 0 {
+		t.Fatalf("unexpected failures: %+v", report.Results)
+	}
+	outputs := map[string]bool{}
+	for _, res := range report.Results {
+		outputs[res.OutputPath] = true
+	}
+	if !outputs[filepath.Join(out, "a.out")] || !outputs[filepath.Join(out, "a_1.out")] {
+		t.Errorf("expected a.out and a_1.out, got %v", outputs)
+	}
+}
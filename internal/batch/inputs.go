@@ -0,0 +1,49 @@
+package batch
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExpandInputs resolves glob patterns into file paths, keeping plain paths as given.
+func ExpandInputs(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", pattern)
+			}
+			sort.Strings(matches)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	return files, nil
+}
+
+// ParseQuality maps a user-facing quality name onto the labels converters understand.
+func ParseQuality(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "high":
+		return "High", nil
+	case "balanced":
+		return "Balanced", nil
+	case "compact":
+		return "Compact", nil
+	default:
+		return "", fmt.Errorf("invalid quality %q (want high, balanced or compact)", value)
+	}
+}
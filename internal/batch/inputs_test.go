@@ -0,0 +1,43 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuality(t *testing.T) {
+	tests := map[string]string{"high": "High", "Balanced": "Balanced", "COMPACT": "Compact"}
+	for in, want := range tests {
+		got, err := ParseQuality(in)
+		if err != nil || got != want {
+			t.Errorf("ParseQuality(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseQuality("ultra"); err == nil {
+		t.Error("ParseQuality should reject unknown values")
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "c.yaml"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ExpandInputs([]string{filepath.Join(tmpDir, "*.json"), filepath.Join(tmpDir, "a.json")})
+	if err != nil {
+		t.Fatalf("ExpandInputs failed: %v", err)
+	}
+	want := []string{filepath.Join(tmpDir, "a.json"), filepath.Join(tmpDir, "b.json")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandInputs() = %v, want %v", got, want)
+	}
+
+	if _, err := ExpandInputs([]string{filepath.Join(tmpDir, "*.png")}); err == nil {
+		t.Error("ExpandInputs should fail when a glob matches nothing")
+	}
+}
@@ -0,0 +1,84 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+)
+
+// Spec returns what a journal needs to run the job again. Paths are made
+// absolute, so the batch can be resumed from any directory.
+func (j Job) Spec() journal.Spec {
+	files := make([]string, len(j.Files))
+	for i, f := range j.Files {
+		files[i] = absPath(f)
+	}
+	outDir := j.OutDir
+	if outDir != "" {
+		outDir = absPath(outDir)
+	}
+	return journal.Spec{
+		Files:     files,
+		TargetExt: j.TargetExt,
+		Quality:   j.Quality,
+		OutDir:    outDir,
+		Jobs:      j.Jobs,
+		Options:   j.Options,
+		Conflict:  string(j.Conflict),
+		Verify:    j.Verify,
+		Cache:     j.Cache != nil,
+	}
+}
+
+// ResumeJob rebuilds the job recorded in jr for the files it has not
+// finished, recording into jr again. Callers set the cache when the spec
+// asks for one, and anything else that is not part of the spec.
+func ResumeJob(jr *journal.Journal) Job {
+	spec := jr.Spec
+	return Job{
+		Files:     jr.Pending(),
+		TargetExt: spec.TargetExt,
+		Quality:   spec.Quality,
+		OutDir:    spec.OutDir,
+		Jobs:      spec.Jobs,
+		Options:   spec.Options,
+		Conflict:  ConflictPolicy(spec.Conflict),
+		Verify:    spec.Verify,
+		Journal:   jr,
+	}
+}
+
+// journalResult records res in the job's journal. Files stopped by
+// cancellation are left running, so resuming converts them again.
+func journalResult(jr *journal.Journal, res Result) {
+	switch {
+	case jr == nil:
+	case errors.Is(res.Err, converter.ErrCancelled), errors.Is(res.Err, context.Canceled):
+	case res.Err != nil:
+		jr.Fail(absPath(res.Path), absPath(res.OutputPath), res.Err)
+	default:
+		jr.Done(absPath(res.Path), absPath(res.OutputPath))
+	}
+}
+
+// journalStart records that path started converting to outputPath.
+func journalStart(jr *journal.Journal, path, outputPath string) {
+	if jr != nil {
+		jr.Start(absPath(path), absPath(outputPath))
+	}
+}
+
+// absPath makes path absolute so journal entries match Spec's files. An
+// empty path, such as the output of a file that failed early, stays empty.
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,103 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+)
+
+func TestRun_Journal(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.txt")
+	bad := filepath.Join(tmpDir, "bad.txt")
+	for _, p := range []string{good, bad} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+
+	job := Job{Files: []string{good, bad}, TargetExt: ".out", Jobs: 1, Conflict: ConflictRename}
+	jr, err := journal.Create(filepath.Join(tmpDir, "journal"), job.Spec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Journal = jr
+	Run(context.Background(), mgr, job, nil)
+	if err := jr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := journal.Open(jr.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := reopened.Item(good); item.State != journal.StateDone || item.Output != filepath.Join(tmpDir, "good.out") {
+		t.Errorf("unexpected entry for good.txt: %+v", item)
+	}
+	if item := reopened.Item(bad); item.State != journal.StateFailed || item.Error != "boom" {
+		t.Errorf("unexpected entry for bad.txt: %+v", item)
+	}
+
+	resumed := ResumeJob(reopened)
+	if !reflect.DeepEqual(resumed.Files, []string{bad}) {
+		t.Errorf("resumed files = %v, want only bad.txt", resumed.Files)
+	}
+	if resumed.TargetExt != ".out" || resumed.Conflict != ConflictRename || resumed.Journal != reopened {
+		t.Errorf("resumed job does not match the spec: %+v", resumed)
+	}
+}
+
+func TestRun_JournalCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, p)
+	}
+
+	conv := &blockingConverter{started: make(chan struct{}, 1)}
+	mgr := converter.NewManager()
+	mgr.Register(conv)
+
+	job := Job{Files: files, TargetExt: ".out", Jobs: 1}
+	jr, err := journal.Create(filepath.Join(tmpDir, "journal"), job.Spec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Journal = jr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-conv.started
+		cancel()
+	}()
+	Run(ctx, mgr, job, nil)
+	jr.Close()
+
+	reopened, err := journal.Open(jr.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Either file may have started first; the other never did
+	states := map[journal.State]int{}
+	for _, f := range files {
+		states[reopened.Item(f).State]++
+	}
+	if states[journal.StateRunning] != 1 || states[journal.StatePlanned] != 1 {
+		t.Errorf("expected one running and one planned file, got %v", states)
+	}
+	if pending := reopened.Pending(); !reflect.DeepEqual(pending, files) {
+		t.Errorf("Pending = %v, want %v", pending, files)
+	}
+}
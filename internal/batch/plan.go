@@ -0,0 +1,173 @@
+package batch
+
+import (
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// PlanItem is what running a job would do with one file.
+type PlanItem struct {
+	Path string
+	// OutputPath is where the output would be written, after the conflict
+	// policy. It is empty when the file cannot be converted.
+	OutputPath string
+	Converter  string
+	Cost       converter.Cost
+	// Tools are the external programs the conversion runs.
+	Tools []string
+	// Missing are the tools and ffmpeg encoders the conversion needs that
+	// are not installed. The conversion would fail without them.
+	Missing []string
+	// Conflict is the policy applied because the output already exists, or
+	// is claimed by an earlier file of the job. Empty means no conflict.
+	Conflict ConflictPolicy
+	// Skipped is set when the conflict policy would leave the existing
+	// output alone.
+	Skipped bool
+	// Cached is set when the output of an identical earlier conversion
+	// would be reused.
+	Cached bool
+	// Err is why the file cannot be converted, such as an unsupported
+	// format.
+	Err     error
+	Warning string
+	// InputSize is the size of the source file.
+	InputSize int64
+	// EstimatedSize is a rough guess at the size of the output (see
+	// converter.EstimateSize), or 0 when there is none. Cached outputs have
+	// their actual size.
+	EstimatedSize int64
+}
+
+// Converts reports whether running the job would convert the file.
+func (it PlanItem) Converts() bool {
+	return it.Err == nil && !it.Skipped && !it.Cached
+}
+
+// Plan lists what running a job would do with each of its files, in order.
+type Plan struct {
+	Items []PlanItem
+}
+
+// NewPlan works out what Run would do with job, without converting or
+// writing anything: the converter each file gets, where its output goes,
+// the conflicts on the way and what it needs installed. Sources are read to
+// detect their format, and hashed when the job has a cache. Files are
+// planned in order, so of two files with the same output the first one
+// claims it.
+//
+// caps decides which tools count as missing; nil uses what mgr was given
+// with SetCapabilities. Conflicts the job would ask about are reported with
+// ConflictAsk.
+func NewPlan(mgr *converter.Manager, job Job, caps *converter.Capabilities) Plan {
+	if caps == nil {
+		caps = mgr.Capabilities()
+	}
+	opts := converter.Options{}
+	for k, v := range job.Options {
+		opts[k] = v
+	}
+	opts["quality"] = job.Quality
+
+	claims := newOutputClaims()
+	plan := Plan{Items: make([]PlanItem, 0, len(job.Files))}
+	for _, path := range job.Files {
+		plan.Items = append(plan.Items, planFile(mgr, job, caps, claims, path, opts))
+	}
+	return plan
+}
+
+func planFile(mgr *converter.Manager, job Job, caps *converter.Capabilities, claims *outputClaims, path string, opts converter.Options) PlanItem {
+	it := PlanItem{Path: path, InputSize: fileSize(path)}
+	src, conv, err := locate(mgr, job, path)
+	it.Warning = src.warning
+	if err != nil {
+		it.Err = err
+		return it
+	}
+	it.Converter = conv.Name()
+	it.Cost = converter.CostOf(conv, src.ext, src.targetExt)
+	it.Tools = converter.RequiredTools(conv, src.ext, src.targetExt)
+	if caps != nil {
+		it.Missing = caps.Missing(conv, src.ext, src.targetExt)
+	}
+
+	// Mirror reuseCached, without linking anything into place
+	var earlier string
+	if key := cacheKey(job, path, conv.Name(), src.targetExt, opts); key != "" && !job.Force {
+		if entry, ok := job.Cache.Lookup(key); ok {
+			earlier = entry.Output
+		}
+	}
+	if earlier == src.output && claims.claim(src.output) {
+		it.OutputPath = src.output
+		it.Cached = true
+		it.EstimatedSize = fileSize(src.output)
+		return it
+	}
+
+	it.OutputPath, it.Conflict = claims.preview(job, src.output)
+	if it.OutputPath == "" {
+		it.OutputPath = src.output
+		it.Skipped = true
+		return it
+	}
+	if earlier != "" {
+		it.Cached = true
+		it.EstimatedSize = fileSize(earlier)
+		return it
+	}
+	it.EstimatedSize = converter.EstimateSize(conv, src.ext, src.targetExt, it.InputSize, opts)
+	return it
+}
+
+// Converting returns the number of files that would be converted.
+func (p Plan) Converting() int {
+	count := 0
+	for _, it := range p.Items {
+		if it.Converts() {
+			count++
+		}
+	}
+	return count
+}
+
+// Failing returns the number of files that cannot be converted, because
+// they are not supported or need something that is not installed.
+func (p Plan) Failing() int {
+	count := 0
+	for _, it := range p.Items {
+		if it.Err != nil || (len(it.Missing) > 0 && it.Converts()) {
+			count++
+		}
+	}
+	return count
+}
+
+// Conflicts returns the number of files whose output is already taken.
+func (p Plan) Conflicts() int {
+	count := 0
+	for _, it := range p.Items {
+		if it.Conflict != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// Sizes returns the total size of the files that would be converted and
+// the estimated size of their outputs. Outputs without an estimate are
+// counted at their input's size.
+func (p Plan) Sizes() (input, estimated int64) {
+	for _, it := range p.Items {
+		if !it.Converts() {
+			continue
+		}
+		input += it.InputSize
+		if it.EstimatedSize > 0 {
+			estimated += it.EstimatedSize
+		} else {
+			estimated += it.InputSize
+		}
+	}
+	return input, estimated
+}
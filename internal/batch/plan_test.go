@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// toolConverter is a fakeConverter that runs ffmpeg.
+type toolConverter struct {
+	fakeConverter
+}
+
+func (c *toolConverter) RequiredTools(srcExt, targetExt string) []string { return []string{"ffmpeg"} }
+
+func TestNewPlan(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	unknown := filepath.Join(dir, "photo.bmp")
+	for _, p := range []string{a, b, unknown, filepath.Join(dir, "a.out")} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+	job := Job{Files: []string{a, b, unknown}, TargetExt: ".out", Conflict: ConflictRename}
+
+	plan := NewPlan(mgr, job, nil)
+	if len(plan.Items) != 3 {
+		t.Fatalf("expected an item per file, got %+v", plan.Items)
+	}
+	first, second, third := plan.Items[0], plan.Items[1], plan.Items[2]
+	if first.Conflict != ConflictRename || first.OutputPath != filepath.Join(dir, "a_1.out") || first.Converter != "Fake" {
+		t.Errorf("expected a.txt to be renamed around a.out, got %+v", first)
+	}
+	if second.Conflict != "" || second.OutputPath != filepath.Join(dir, "b.out") || second.InputSize != 4 || second.Cost != converter.CostLight {
+		t.Errorf("unexpected plan for b.txt: %+v", second)
+	}
+	if third.Err == nil || third.OutputPath != "" {
+		t.Errorf("expected photo.bmp to be unsupported, got %+v", third)
+	}
+	if plan.Converting() != 2 || plan.Failing() != 1 || plan.Conflicts() != 1 {
+		t.Errorf("unexpected counts: %d converting, %d failing, %d conflicts", plan.Converting(), plan.Failing(), plan.Conflicts())
+	}
+	for _, name := range []string{"a_1.out", "b.out"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("planning must not write %s", name)
+		}
+	}
+
+	job.Conflict = ConflictSkip
+	if it := NewPlan(mgr, job, nil).Items[0]; !it.Skipped || it.Conflict != ConflictSkip || it.Converts() {
+		t.Errorf("expected a.txt to be skipped, got %+v", it)
+	}
+	job.Conflict = ConflictAsk
+	if it := NewPlan(mgr, job, nil).Items[0]; it.Conflict != ConflictAsk || it.OutputPath != filepath.Join(dir, "a.out") {
+		t.Errorf("expected the conflict to be left to the prompt, got %+v", it)
+	}
+}
+
+func TestNewPlan_MissingTools(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&toolConverter{})
+	caps := &converter.Capabilities{Tools: []converter.Tool{{Name: "ffmpeg"}}}
+
+	plan := NewPlan(mgr, Job{Files: []string{src}, TargetExt: ".out"}, caps)
+	it := plan.Items[0]
+	if !slices.Equal(it.Tools, []string{"ffmpeg"}) || !slices.Equal(it.Missing, []string{"ffmpeg"}) || it.Cost != converter.CostHeavy {
+		t.Errorf("expected ffmpeg to be required and missing, got %+v", it)
+	}
+	if plan.Failing() != 1 {
+		t.Errorf("expected the file to be counted as failing, got %d", plan.Failing())
+	}
+}
+
+func TestNewPlan_Cache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cache.OpenFile(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&fakeConverter{})
+	job := Job{Files: []string{src}, TargetExt: ".out", Cache: c}
+	Run(context.Background(), mgr, job, nil)
+
+	plan := NewPlan(mgr, job, nil)
+	if it := plan.Items[0]; !it.Cached || it.Conflict != "" || it.EstimatedSize != 4 {
+		t.Errorf("expected the earlier output to be reused, got %+v", it)
+	}
+	if input, estimated := plan.Sizes(); input != 0 || estimated != 0 {
+		t.Errorf("expected nothing to convert, got %d -> %d bytes", input, estimated)
+	}
+
+	job.Force = true
+	if it := NewPlan(mgr, job, nil).Items[0]; it.Cached || it.Conflict != ConflictOverwrite {
+		t.Errorf("expected Force to convert over the output, got %+v", it)
+	}
+}
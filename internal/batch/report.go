@@ -0,0 +1,184 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// Error classes reported for failed conversions.
+const (
+	ErrorClassUnsupported   = "unsupported"
+	ErrorClassNotFound      = "not_found"
+	ErrorClassConversion    = "conversion_failed"
+	ErrorClassVerification  = "verification_failed"
+	ErrorClassCancelled     = "cancelled"
+	ErrorClassMissingTool   = "missing_dependency"
+	ErrorClassCorruptInput  = "corrupt_input"
+	ErrorClassEncode        = "encode_failed"
+	ErrorClassToolFailed    = "tool_failed"
+	ErrorClassInvalidOption = "invalid_option"
+)
+
+// Entry is the machine-readable form of a Result.
+type Entry struct {
+	Source      string `json:"source"`
+	Output      string `json:"output,omitempty"`
+	Converter   string `json:"converter,omitempty"`
+	Status      string `json:"status"`
+	InputBytes  int64  `json:"input_bytes"`
+	OutputBytes int64  `json:"output_bytes"`
+	DurationMs  int64  `json:"duration_ms"`
+	ErrorClass  string `json:"error_class,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Hint        string `json:"hint,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+}
+
+// Summary is the machine-readable form of a Report.
+type Summary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Unverified counts the failed files whose output failed verification.
+	Unverified int     `json:"unverified,omitempty"`
+	Skipped    int     `json:"skipped,omitempty"`
+	Cached     int     `json:"cached,omitempty"`
+	Cancelled  bool    `json:"cancelled,omitempty"`
+	DurationMs int64   `json:"duration_ms"`
+	Results    []Entry `json:"results"`
+}
+
+// NewEntry converts a Result into its machine-readable form.
+func NewEntry(res Result) Entry {
+	e := Entry{
+		Source:      res.Path,
+		Converter:   res.Converter,
+		Status:      "ok",
+		InputBytes:  res.InputSize,
+		OutputBytes: res.OutputSize,
+		DurationMs:  res.Duration.Milliseconds(),
+		Warning:     res.Warning,
+	}
+	switch {
+	case res.Err != nil:
+		e.Status = "failed"
+		e.ErrorClass = ErrorClass(res.Err)
+		e.Error = res.Err.Error()
+		e.Hint = converter.Hint(res.Err)
+	case res.Skipped:
+		e.Status = "skipped"
+		e.Output = res.OutputPath
+	case res.Cached:
+		e.Status = "cached"
+		e.Output = res.OutputPath
+	default:
+		e.Output = res.OutputPath
+	}
+	return e
+}
+
+// ErrorClass returns a stable, machine-readable category for err.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, converter.ErrCancelled), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassCancelled
+	case errors.Is(err, converter.ErrVerification):
+		return ErrorClassVerification
+	case errors.Is(err, converter.ErrNoConverter):
+		return ErrorClassUnsupported
+	case errors.Is(err, converter.ErrInvalidOption):
+		return ErrorClassInvalidOption
+	case errors.Is(err, converter.ErrMissingTool):
+		return ErrorClassMissingTool
+	case errors.Is(err, converter.ErrCorruptInput):
+		return ErrorClassCorruptInput
+	case errors.Is(err, converter.ErrEncode):
+		return ErrorClassEncode
+	case errors.Is(err, converter.ErrToolFailed):
+		return ErrorClassToolFailed
+	case errors.Is(err, os.ErrNotExist):
+		return ErrorClassNotFound
+	default:
+		return ErrorClassConversion
+	}
+}
+
+// Summarize converts a Report into its machine-readable form.
+func (r Report) Summarize() Summary {
+	s := Summary{
+		Total:      len(r.Results),
+		Failed:     r.Failed(),
+		Unverified: r.Unverified(),
+		Skipped:    r.Skipped(),
+		Cached:     r.Cached(),
+		Cancelled:  r.Cancelled,
+		DurationMs: r.Duration.Milliseconds(),
+		Results:    make([]Entry, 0, len(r.Results)),
+	}
+	s.Succeeded = s.Total - s.Failed - s.Skipped - s.Cached
+	for _, res := range r.Results {
+		s.Results = append(s.Results, NewEntry(res))
+	}
+	return s
+}
+
+// WriteJSON writes the report as a single indented JSON document.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.Summarize()); err != nil {
+		return fmt.Errorf("failed to write json report: %w", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes one JSON line per result.
+func WriteNDJSON(w io.Writer, r Report) error {
+	for _, res := range r.Results {
+		if err := WriteNDJSONLine(w, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteNDJSONLine writes a single result as one JSON line, so results can be
+// streamed while a batch is still running.
+func WriteNDJSONLine(w io.Writer, res Result) error {
+	if err := json.NewEncoder(w).Encode(NewEntry(res)); err != nil {
+		return fmt.Errorf("failed to write ndjson report: %w", err)
+	}
+	return nil
+}
+
+// SaveReport writes the report to path in the given format ("json" or "ndjson").
+func SaveReport(path, format string, r Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer f.Close()
+
+	switch format {
+	case "json":
+		return WriteJSON(f, r)
+	case "ndjson":
+		return WriteNDJSON(f, r)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// DefaultReportName returns a report file name for a batch finished at t.
+func DefaultReportName(t time.Time) string {
+	return "golter-report-" + t.Format("20060102-150405") + ".json"
+}
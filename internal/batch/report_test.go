@@ -0,0 +1,104 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func sampleReport() Report {
+	return Report{
+		Results: []Result{
+			{Path: "a.png", OutputPath: "a.webp", Converter: "Image Converter", Duration: 1500 * time.Millisecond, InputSize: 2048, OutputSize: 512},
+			{Path: "b.txt", Err: fmt.Errorf("%w for .txt to .webp", converter.ErrNoConverter), InputSize: 10},
+		},
+		Duration: 2 * time.Second,
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{fmt.Errorf("wrapped: %w", converter.ErrNoConverter), ErrorClassUnsupported},
+		{fmt.Errorf("open: %w", os.ErrNotExist), ErrorClassNotFound},
+		{errors.New("ffmpeg conversion failed"), ErrorClassConversion},
+		{fmt.Errorf("%w: output is empty", converter.ErrVerification), ErrorClassVerification},
+		{fmt.Errorf("%w: context canceled", converter.ErrCancelled), ErrorClassCancelled},
+		{fmt.Errorf("%w: quality", converter.ErrInvalidOption), ErrorClassInvalidOption},
+		{&converter.MissingToolError{Tool: "ffmpeg", Install: "ffmpeg"}, ErrorClassMissingTool},
+		{fmt.Errorf("decode: %w", converter.ErrCorruptInput), ErrorClassCorruptInput},
+		{fmt.Errorf("encode: %w", converter.ErrEncode), ErrorClassEncode},
+		{converter.NewToolError("ffmpeg", errors.New("exit status 1"), []byte("boom")), ErrorClassToolFailed},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sampleReport()); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if summary.Total != 2 || summary.Succeeded != 1 || summary.Failed != 1 || summary.DurationMs != 2000 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	ok := summary.Results[0]
+	if ok.Status != "ok" || ok.Output != "a.webp" || ok.InputBytes != 2048 || ok.OutputBytes != 512 || ok.DurationMs != 1500 {
+		t.Errorf("unexpected success entry: %+v", ok)
+	}
+	failed := summary.Results[1]
+	if failed.Status != "failed" || failed.ErrorClass != ErrorClassUnsupported || failed.Error == "" {
+		t.Errorf("unexpected failure entry: %+v", failed)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, sampleReport()); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Errorf("invalid ndjson line %q: %v", line, err)
+		}
+	}
+}
+
+func TestSaveReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultReportName(time.Now()))
+	if err := SaveReport(path, "json", sampleReport()); err != nil {
+		t.Fatalf("SaveReport failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("report not written: %v", err)
+	}
+	if err := SaveReport(path, "xml", sampleReport()); err == nil {
+		t.Error("SaveReport should reject unknown formats")
+	}
+}
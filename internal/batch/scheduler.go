@@ -0,0 +1,160 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// heavyCPUs is how many CPUs each heavy conversion gets when Limits.Heavy
+// is not set.
+const heavyCPUs = 4
+
+// memoryReserve is how much available memory a conversion of each cost is
+// assumed to need. One is not started next to others unless that much is
+// available.
+var memoryReserve = map[converter.Cost]uint64{
+	converter.CostLight: 64 << 20,
+	converter.CostHeavy: 512 << 20,
+}
+
+// Limits caps how many conversions of each cost class run at once. Zero
+// fields are sized from the machine: one light conversion per CPU, and one
+// heavy conversion per four CPUs.
+type Limits struct {
+	Light int
+	Heavy int
+}
+
+// scheduler decides when each conversion of a batch may start and how many
+// threads it gets. Heavy conversions share the CPUs: each is given an equal
+// part of them, so ffmpeg is not told to use every core several times over.
+// Light conversions use one thread each. A conversion waits while too few
+// bytes of memory are available, unless nothing else is running.
+type scheduler struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// total caps conversions of any cost; 0 means no cap.
+	total   int
+	limits  map[converter.Cost]int
+	threads map[converter.Cost]int
+	running map[converter.Cost]int
+	// memory returns the available memory, or false when it is unknown.
+	memory func() (uint64, bool)
+}
+
+func newScheduler(jobs int, limits Limits) *scheduler {
+	cpus := runtime.NumCPU()
+	if limits.Light <= 0 {
+		limits.Light = cpus
+	}
+	if limits.Heavy <= 0 {
+		limits.Heavy = max(1, cpus/heavyCPUs)
+	}
+	heavy := limits.Heavy
+	if jobs > 0 && jobs < heavy {
+		heavy = jobs
+	}
+
+	s := &scheduler{
+		total: jobs,
+		limits: map[converter.Cost]int{
+			converter.CostLight: limits.Light,
+			converter.CostHeavy: limits.Heavy,
+		},
+		threads: map[converter.Cost]int{
+			converter.CostLight: 1,
+			converter.CostHeavy: max(1, cpus/heavy),
+		},
+		running: make(map[converter.Cost]int),
+		memory:  availableMemory,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// acquire waits until a conversion of cost may start and returns its thread
+// budget. It fails with ctx's error when ctx is done first. Every successful
+// acquire must be followed by release.
+func (s *scheduler) acquire(ctx context.Context, cost converter.Cost) (int, error) {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.canStart(cost) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		s.cond.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.running[cost]++
+	return s.threads[cost], nil
+}
+
+// release frees the slot of a finished conversion of cost.
+func (s *scheduler) release(cost converter.Cost) {
+	s.mu.Lock()
+	s.running[cost]--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// canStart reports whether a conversion of cost fits now. s.mu must be held.
+func (s *scheduler) canStart(cost converter.Cost) bool {
+	running := 0
+	for _, n := range s.running {
+		running += n
+	}
+	if running == 0 {
+		// Something must run, however busy the machine is
+		return true
+	}
+	if s.total > 0 && running >= s.total {
+		return false
+	}
+	if limit, ok := s.limits[cost]; ok && s.running[cost] >= limit {
+		return false
+	}
+	if avail, ok := s.memory(); ok && avail < memoryReserve[cost] {
+		return false
+	}
+	return true
+}
+
+// availableMemory reads MemAvailable from /proc/meminfo. Elsewhere than on
+// Linux the available memory is unknown.
+func availableMemory() (uint64, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return kb << 10, true
+	}
+	return 0, false
+}
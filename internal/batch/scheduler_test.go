@@ -0,0 +1,186 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// acquireAsync acquires a slot in the background and reports when it has.
+func acquireAsync(ctx context.Context, s *scheduler, cost converter.Cost) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(ctx, cost)
+		done <- err
+	}()
+	return done
+}
+
+func waitAcquired(t *testing.T, done <-chan error, want bool) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if !want {
+			t.Fatalf("expected to wait, acquired with %v", err)
+		}
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		if want {
+			t.Fatal("expected a slot")
+		}
+	}
+}
+
+func unknownMemory() (uint64, bool) { return 0, false }
+
+func TestScheduler_LimitsPerCost(t *testing.T) {
+	s := newScheduler(0, Limits{Heavy: 1, Light: 2})
+	s.memory = unknownMemory
+	ctx := context.Background()
+
+	threads, err := s.acquire(ctx, converter.CostHeavy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threads != runtime.NumCPU() {
+		t.Errorf("the only heavy slot got %d threads, want every CPU (%d)", threads, runtime.NumCPU())
+	}
+
+	heavy := acquireAsync(ctx, s, converter.CostHeavy)
+	waitAcquired(t, heavy, false)
+
+	// Light work is not held up by the heavy queue
+	if threads, err := s.acquire(ctx, converter.CostLight); err != nil || threads != 1 {
+		t.Errorf("light acquire = %d, %v; want 1 thread", threads, err)
+	}
+
+	s.release(converter.CostHeavy)
+	waitAcquired(t, heavy, true)
+}
+
+func TestScheduler_TotalAndThreads(t *testing.T) {
+	s := newScheduler(1, Limits{Heavy: 4})
+	s.memory = unknownMemory
+	ctx := context.Background()
+
+	// Jobs caps the heavy slots too, so the one that runs gets every CPU
+	threads, err := s.acquire(ctx, converter.CostHeavy)
+	if err != nil || threads != runtime.NumCPU() {
+		t.Errorf("acquire = %d, %v; want %d threads", threads, err, runtime.NumCPU())
+	}
+	light := acquireAsync(ctx, s, converter.CostLight)
+	waitAcquired(t, light, false)
+	s.release(converter.CostHeavy)
+	waitAcquired(t, light, true)
+
+	if threads := newScheduler(0, Limits{Heavy: runtime.NumCPU() * 2}).threads[converter.CostHeavy]; threads != 1 {
+		t.Errorf("heavy slots beyond the CPUs got %d threads, want 1", threads)
+	}
+}
+
+func TestScheduler_WaitsForMemory(t *testing.T) {
+	s := newScheduler(0, Limits{Heavy: 4, Light: 4})
+	s.memory = func() (uint64, bool) { return 100 << 20, true }
+	ctx := context.Background()
+
+	// With nothing running, a conversion starts however little memory is left
+	if _, err := s.acquire(ctx, converter.CostHeavy); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.acquire(ctx, converter.CostLight); err != nil {
+		t.Fatalf("light work fits in 100 MiB: %v", err)
+	}
+	heavy := acquireAsync(ctx, s, converter.CostHeavy)
+	waitAcquired(t, heavy, false)
+
+	s.mu.Lock()
+	s.memory = unknownMemory
+	s.mu.Unlock()
+	s.release(converter.CostLight)
+	waitAcquired(t, heavy, true)
+}
+
+func TestScheduler_Cancelled(t *testing.T) {
+	s := newScheduler(0, Limits{Heavy: 1})
+	s.memory = unknownMemory
+	if _, err := s.acquire(context.Background(), converter.CostHeavy); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heavy := acquireAsync(ctx, s, converter.CostHeavy)
+	cancel()
+	select {
+	case err := <-heavy:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected cancellation, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire did not return after cancellation")
+	}
+}
+
+// heavyConverter copies like fakeConverter but costs heavy, recording how
+// many conversions overlap and the threads they were given.
+type heavyConverter struct {
+	fakeConverter
+	mu      sync.Mutex
+	running int
+	peak    int
+	threads []int
+}
+
+func (c *heavyConverter) Cost(srcExt, targetExt string) converter.Cost { return converter.CostHeavy }
+
+func (c *heavyConverter) ConvertContext(ctx context.Context, src, target string, opts converter.Options) error {
+	c.mu.Lock()
+	c.running++
+	c.peak = max(c.peak, c.running)
+	c.threads = append(c.threads, converter.Threads(ctx))
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	return c.Convert(src, target, opts)
+}
+
+func TestRun_SchedulesHeavyConversions(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		p := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, p)
+	}
+
+	conv := &heavyConverter{}
+	mgr := converter.NewManager()
+	mgr.Register(conv)
+
+	report := Run(context.Background(), mgr, Job{Files: files, TargetExt: ".out", Limits: Limits{Heavy: 1}}, nil)
+	if report.Failed() != 0 || len(report.Results) != 4 {
+		t.Fatalf("unexpected results %+v", report.Results)
+	}
+	if conv.peak != 1 {
+		t.Errorf("expected heavy conversions to run one at a time, %d overlapped", conv.peak)
+	}
+	for _, n := range conv.threads {
+		if n != runtime.NumCPU() {
+			t.Errorf("expected each conversion to get every CPU, got %d threads", n)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package cache remembers finished conversions, keyed by the content of the
+// source and everything else that shapes the output, so files that have not
+// changed are not converted again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// EnvPath overrides the location of the cache file.
+const EnvPath = "GOLTER_CACHE"
+
+// keyVersion is mixed into every key so a change to how outputs are produced
+// can invalidate old entries.
+const keyVersion = "golter-cache-1"
+
+// Entry records one finished conversion.
+type Entry struct {
+	// Output is the file the conversion wrote.
+	Output    string `json:"output"`
+	Converter string `json:"converter"`
+	// Size and ModTime identify the output as it was written, so an output
+	// that was edited or replaced since no longer counts.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Stored is when the conversion finished.
+	Stored time.Time `json:"stored"`
+}
+
+// Cache is a set of entries persisted as JSON. It is safe for concurrent use;
+// changes are kept in memory until Save.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// Path returns the cache file location: $GOLTER_CACHE, or golter/conversions.json
+// in the user cache directory ($XDG_CACHE_HOME on Linux).
+func Path() (string, error) {
+	if p := os.Getenv(EnvPath); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "golter", "conversions.json"), nil
+}
+
+// Open loads the cache at Path.
+func Open() (*Cache, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return OpenFile(path)
+}
+
+// OpenFile loads the cache file at path. A missing file is an empty cache.
+func OpenFile(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]Entry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cache %s: %w", path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]Entry)
+	}
+	return c, nil
+}
+
+// File returns where the cache is saved.
+func (c *Cache) File() string {
+	return c.path
+}
+
+// Len returns the number of entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Lookup returns the entry for key when its output still exists unchanged.
+func (c *Cache) Lookup(key string) (Entry, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || !e.current() {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Store records that the conversion identified by key wrote output.
+func (c *Cache) Store(key, output, converterName string) error {
+	info, err := os.Stat(output)
+	if err != nil {
+		return fmt.Errorf("failed to stat output: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = Entry{
+		Output:    output,
+		Converter: converterName,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		Stored:    time.Now(),
+	}
+	return nil
+}
+
+// Prune removes entries whose output is gone or has changed, and entries
+// stored longer than maxAge ago when maxAge is positive. It returns how many
+// were removed.
+func (c *Cache) Prune(maxAge time.Duration) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, e := range c.entries {
+		if !e.current() || (maxAge > 0 && time.Since(e.Stored) > maxAge) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Clear removes every entry.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]Entry)
+}
+
+// Save writes the cache file, creating its directory when needed.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := converter.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// current reports whether the entry's output is still the file it recorded.
+func (e Entry) current() bool {
+	info, err := os.Stat(e.Output)
+	return err == nil && info.Size() == e.Size && info.ModTime().Equal(e.ModTime)
+}
+
+// HashFile returns the hex SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open source: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash source: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Key identifies a conversion by the source content hash, the converter, the
+// target format and the options. Options are ordered by name, so equal maps
+// give equal keys.
+func Key(sourceHash, converterName, targetExt string, opts converter.Options) string {
+	names := make([]string, 0, len(opts))
+	for name, v := range opts {
+		if v != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", keyVersion, sourceHash, converterName, targetExt)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%#v\x00", name, opts[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
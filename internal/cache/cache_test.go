@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func TestKey(t *testing.T) {
+	opts := converter.Options{"quality": "High", "crf": 20}
+	same := converter.Options{"crf": 20, "quality": "High", "unset": nil}
+	base := Key("abc", "Image Converter", ".webp", opts)
+
+	if got := Key("abc", "Image Converter", ".webp", same); got != base {
+		t.Error("expected equal options to give equal keys")
+	}
+	differs := map[string]string{
+		"source":    Key("abd", "Image Converter", ".webp", opts),
+		"converter": Key("abc", "Other", ".webp", opts),
+		"target":    Key("abc", "Image Converter", ".png", opts),
+		"options":   Key("abc", "Image Converter", ".webp", converter.Options{"quality": "Compact", "crf": 20}),
+	}
+	for name, key := range differs {
+		if key == base {
+			t.Errorf("expected a different %s to change the key", name)
+		}
+	}
+}
+
+func TestCache_StoreLookup(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "a.webp")
+	if err := os.WriteFile(output, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := OpenFile(filepath.Join(dir, "cache", "conversions.json"))
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if err := c.Store("k", output, "Image Converter"); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if e, ok := c.Lookup("k"); !ok || e.Output != output || e.Converter != "Image Converter" {
+		t.Errorf("Lookup = %+v, %v", e, ok)
+	}
+
+	// Saved entries survive a reload
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	reloaded, err := OpenFile(c.File())
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if _, ok := reloaded.Lookup("k"); !ok {
+		t.Error("expected the entry after reloading")
+	}
+
+	// An edited output no longer counts
+	if err := os.WriteFile(output, []byte("edited image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup("k"); ok {
+		t.Error("expected a changed output to miss")
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	c, err := OpenFile(filepath.Join(dir, "conversions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"kept", "old", "deleted"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Store(name, path, "Fake"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Remove(filepath.Join(dir, "deleted"))
+	c.entries["old"] = Entry{Output: filepath.Join(dir, "old"), Size: 3, ModTime: c.entries["old"].ModTime, Stored: time.Now().Add(-48 * time.Hour)}
+
+	if removed := c.Prune(0); removed != 1 || c.Len() != 2 {
+		t.Errorf("Prune(0) removed %d, left %d; want 1 and 2", removed, c.Len())
+	}
+	if removed := c.Prune(24 * time.Hour); removed != 1 || c.Len() != 1 {
+		t.Errorf("Prune(24h) removed %d, left %d; want 1 and 1", removed, c.Len())
+	}
+	if _, ok := c.Lookup("kept"); !ok {
+		t.Error("expected the current entry to survive")
+	}
+}
+
+func TestOpenFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversions.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(path); err == nil {
+		t.Error("expected an error for a corrupt cache")
+	}
+}
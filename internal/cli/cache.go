@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/sametcn99/golter/internal/cache"
+)
+
+func runCache(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cache", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	olderThan := fs.Duration("older-than", 0, "with prune, also remove entries stored longer ago than this, e.g. 720h")
+	all := fs.Bool("all", false, "with prune, remove every entry")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter cache              show where the conversion cache is and its size")
+		fmt.Fprintln(stderr, "       golter cache prune [flags] drop entries whose outputs are gone or changed")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	prune := len(positional) == 1 && positional[0] == "prune"
+	if len(positional) > 1 || (len(positional) == 1 && !prune) || (!prune && (*all || *olderThan != 0)) {
+		fs.Usage()
+		return 2
+	}
+
+	c, err := cache.Open()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if !prune {
+		fmt.Fprintf(stdout, "%s: %d entries\n", c.File(), c.Len())
+		return 0
+	}
+
+	before := c.Len()
+	removed := 0
+	if *all {
+		c.Clear()
+		removed = before
+	} else {
+		removed = c.Prune(*olderThan)
+	}
+	if removed > 0 {
+		if err := c.Save(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	fmt.Fprintf(stdout, "Removed %d of %d cache entries\n", removed, before)
+	return 0
+}
+
+// openCache opens the conversion cache when enabled. A cache that cannot be
+// read is reported and left out, so every file is converted.
+func openCache(enabled bool, stderr io.Writer) *cache.Cache {
+	if !enabled {
+		return nil
+	}
+	c, err := cache.Open()
+	if err != nil {
+		fmt.Fprintf(stderr, "warn %v\n", err)
+		return nil
+	}
+	return c
+}
+
+// saveCache persists the conversions recorded in c, if any.
+func saveCache(c *cache.Cache, stderr io.Writer) {
+	if c == nil {
+		return
+	}
+	if err := c.Save(); err != nil {
+		fmt.Fprintf(stderr, "warn %v\n", err)
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/plugin"
+)
+
+// command is a non-interactive golter subcommand.
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands map[string]command
+
+// stdin is where "-" inputs are read from; tests replace it.
+var stdin io.Reader = os.Stdin
+
+func init() {
+	commands = map[string]command{
+		"cache":   {"Show or prune the conversion cache", runCache},
+		"config":  {"Print the effective configuration", runConfig},
+		"convert": {"Convert files without the TUI", runConvert},
+		"doctor":  {"Check external tools and ffmpeg encoders", runDoctor},
+		"formats": {"List every supported conversion", runFormats},
+		"help":    {"Show this help", runHelp},
+		"resume":  {"Resume an interrupted batch", runResume},
+		"run":     {"Run conversions declared in a YAML job file", runRecipe},
+		"serve":   {"Serve conversions over an HTTP API", runServe},
+		"watch":   {"Convert files as they appear in a directory", runWatch},
+	}
+}
+
+// IsCommand reports whether name is a known subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand in args[0] and returns the process exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command: %s\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func runHelp(args []string, stdout, stderr io.Writer) int {
+	printUsage(stdout)
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  golter [directory]          Start the interactive file converter")
+	fmt.Fprintln(w, "  golter <command> [flags]    Run a command")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseArgs parses flags that may appear before, between or after positional
+// arguments and returns the positional arguments in order.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// isFlagSet reports whether the flag called name was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// newManager returns the built-in converters plus the plugins found in the
+// configured plugin directory, with the configured pins applied. Plugins
+// that fail to load and pins that do not apply are reported on stderr and
+// left out.
+func newManager(cfg config.Config, stderr io.Writer) *converter.Manager {
+	mgr := converter.NewDefaultManager()
+	warn := func(err error) {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(stderr, "warn %s\n", line)
+		}
+	}
+	if err := plugin.Register(converter.WithRunner(context.Background(), cfg.Runner()), mgr, cfg.PluginDirectory()); err != nil {
+		warn(err)
+	}
+	// Pins may name plugins, so they are applied last
+	if err := cfg.ApplyPins(mgr); err != nil {
+		warn(err)
+	}
+	return mgr
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the developer's own config file and journals out of the tests
+	dir, err := os.MkdirTemp("", "golter_cli_test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("GOLTER_CONFIG", filepath.Join(dir, "config.toml"))
+	os.Setenv("GOLTER_JOURNAL_DIR", filepath.Join(dir, "journal"))
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("expected unknown command message, got %q", stderr.String())
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	if !IsCommand("convert") {
+		t.Error("convert should be a command")
+	}
+	if IsCommand("/home/user") {
+		t.Error("a path should not be a command")
+	}
+}
+
+func TestParseArgs_Interspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	to := fs.String("to", "", "")
+	got, err := parseArgs(fs, []string{"a.png", "--to", ".webp", "b.png"})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if want := []string{"a.png", "b.png"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("positional = %v, want %v", got, want)
+	}
+	if *to != ".webp" {
+		t.Errorf("to = %q, want .webp", *to)
+	}
+}
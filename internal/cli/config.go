@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sametcn99/golter/internal/config"
+)
+
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pathOnly := fs.Bool("path", false, "print only the config file location")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter config [--path]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *pathOnly {
+		fmt.Fprintln(stdout, path)
+		return 0
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(stdout, "# %s (not found, showing defaults)\n", path)
+	} else {
+		fmt.Fprintf(stdout, "# %s\n", path)
+	}
+	if err := cfg.Write(stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
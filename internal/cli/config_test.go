@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/config"
+)
+
+func TestRunConfig_PrintsDefaults(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"config"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("config exited with %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "not found, showing defaults") || !strings.Contains(out, "workers = 0") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRunConvert_UsesConfigDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "converted")
+	cfgPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(cfgPath, []byte("output_dir = \""+filepath.ToSlash(outDir)+"\"\nworkers = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvPath, cfgPath)
+
+	src := filepath.Join(tmpDir, "data.json")
+	if err := os.WriteFile(src, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "data.yaml")); err != nil {
+		t.Errorf("expected output in configured directory: %v", err)
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(cfgPath, []byte(`quality = "ultra"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvPath, cfgPath)
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", "x.json", "--to", ".yaml"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "ultra") {
+		t.Errorf("expected config error, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	to := fs.String("to", "", "target format extension, e.g. .webp (empty compresses in the source format)")
+	quality := fs.String("quality", cfg.Quality, "output quality: high, balanced or compact")
+	outDir := fs.String("out-dir", cfg.OutputDir, "write outputs to this directory instead of next to the source")
+	jobs := fs.Int("jobs", cfg.Workers, "maximum number of files converted concurrently (0 sizes it from the CPUs)")
+	from := fs.String("from", "", "source format of a single input (default: detected from the content)")
+	output := fs.String("output", "", "write a single conversion to this file, or - for stdout")
+	reportPath := fs.String("report", "", "write a machine-readable results report to this file (- for stdout)")
+	reportFormat := fs.String("report-format", "", "report format: json or ndjson (default from the --report extension)")
+	onConflict := fs.String("on-conflict", string(cfg.ConflictPolicy(false)), "when an output exists: overwrite, skip or rename")
+	useCache := fs.Bool("cache", cfg.Cache, "skip files converted before with the same content and settings")
+	force := fs.Bool("force", false, "convert every file even when the cache has an unchanged output")
+	verify := fs.Bool("verify", cfg.Verify, "read every output back and fail files whose output is unreadable")
+	dryRun := fs.Bool("dry-run", false, "print what would be converted, where to and with what, without converting anything")
+	sets := optionFlags{}
+	fs.Var(sets, "set", "converter option as name=value, e.g. crf=20 (repeatable; see golter formats --json)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter convert [flags] <file|glob>...")
+		fmt.Fprintln(stderr, "       golter convert - [--from .ext] --to .ext [--output file] < input > output")
+		fs.PrintDefaults()
+	}
+
+	inputs, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(inputs) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	q, err := batch.ParseQuality(*quality)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(inputs) == 1 && (inputs[0] == "-" || *output != "") {
+		// Batch flags mean nothing for one stream; refuse rather than ignore them
+		for _, name := range []string{"dry-run", "on-conflict", "cache", "force", "verify", "report", "report-format", "jobs", "out-dir"} {
+			if isFlagSet(fs, name) {
+				fmt.Fprintf(stderr, "--%s cannot be used with --output or stdin\n", name)
+				return 2
+			}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx = converter.WithRunner(ctx, cfg.Runner())
+		return runConvertStream(ctx, newManager(cfg, stderr), inputs[0], *output, converter.NormalizeExt(*from), converter.NormalizeExt(*to), withQuality(sets.merge(cfg.Options()), q), stdout, stderr)
+	}
+	if *output != "" {
+		fmt.Fprintln(stderr, "--output requires exactly one input")
+		return 2
+	}
+
+	if *jobs < 0 {
+		fmt.Fprintln(stderr, "--jobs must not be negative")
+		return 2
+	}
+	conflict, err := parseConflictFlag(*onConflict)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	files, err := batch.ExpandInputs(inputs)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if *dryRun && *reportPath != "" {
+		fmt.Fprintln(stderr, "--report cannot be used with --dry-run")
+		return 2
+	}
+
+	mgr := newManager(cfg, stderr)
+	c := openCache(*useCache, stderr)
+	job := batch.Job{
+		Files:     files,
+		TargetExt: converter.NormalizeExt(*to),
+		Quality:   q,
+		OutDir:    *outDir,
+		Jobs:      *jobs,
+		Limits:    cfg.Limits(),
+		Options:   sets.merge(cfg.Options()),
+		Conflict:  conflict,
+		Cache:     c,
+		Force:     *force,
+		Verify:    *verify,
+	}
+	if *dryRun {
+		caps := converter.ProbeTools(converter.WithRunner(context.Background(), cfg.Runner()), converter.KnownTools...)
+		return printPlan(stdout, stderr, batch.NewPlan(mgr, job, caps))
+	}
+
+	sink, err := openReport(*reportPath, *reportFormat, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer sink.close()
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			fmt.Fprintf(stderr, "failed to create output directory: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = converter.WithRunner(ctx, cfg.Runner())
+
+	job.Journal = startJournal(job, stderr)
+	report := batch.Run(ctx, mgr, job, sink.result)
+	saveCache(c, stderr)
+	finishJournal(job.Journal, report, stderr)
+
+	return sink.finish(report)
+}
+
+// runConvertStream converts a single input where either side may be "-" for stdin/stdout.
+func runConvertStream(ctx context.Context, mgr *converter.Manager, input, output, from, to string, opts converter.Options, stdout, stderr io.Writer) int {
+	if to == "" {
+		fmt.Fprintln(stderr, "converting a single input requires --to")
+		return 2
+	}
+	if output == "" {
+		output = "-"
+	}
+
+	r := stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to open input: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Without --from, sniff the format from the content and fall back to the extension
+	if from == "" {
+		br := bufio.NewReader(r)
+		header, _ := br.Peek(4096)
+		det := converter.Detection{Detected: converter.DetectFormat(header)}
+		if input != "-" {
+			det.Ext = converter.NormalizeExt(filepath.Ext(input))
+		}
+		if w := det.Warning(); w != "" {
+			fmt.Fprintf(stderr, "warn %s: %s\n", input, w)
+		}
+		from = det.Format()
+		r = br
+	}
+	if from == "" {
+		fmt.Fprintln(stderr, "could not detect the input format; pass --from")
+		return 2
+	}
+
+	var err error
+	if output == "-" {
+		err = mgr.ConvertStreamContext(ctx, r, stdout, from, to, opts)
+	} else {
+		err = convertStreamToFile(ctx, mgr, r, output, from, to, opts)
+	}
+	if err != nil {
+		if errors.Is(err, converter.ErrCancelled) {
+			fmt.Fprintln(stderr, "cancelled")
+			return 130
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// convertStreamToFile converts r into a temporary file next to output and
+// moves it into place once the conversion succeeds, so a failed one never
+// leaves a truncated file behind.
+func convertStreamToFile(ctx context.Context, mgr *converter.Manager, r io.Reader, output, from, to string, opts converter.Options) error {
+	tmp, err := converter.TempTarget(output)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	err = mgr.ConvertStreamContext(ctx, r, f, from, to, opts)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write output: %w", closeErr)
+	}
+	if err == nil {
+		if err = os.Rename(tmp, output); err != nil {
+			err = fmt.Errorf("failed to move output into place: %w", err)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// parseConflictFlag parses --on-conflict. Asking needs the TUI.
+func parseConflictFlag(s string) (batch.ConflictPolicy, error) {
+	p, err := batch.ParseConflictPolicy(s)
+	if err != nil {
+		return "", err
+	}
+	if p == batch.ConflictAsk {
+		return "", fmt.Errorf("--on-conflict ask is only available in the TUI")
+	}
+	return p, nil
+}
+
+// optionFlags collects repeated --set name=value flags.
+type optionFlags converter.Options
+
+func (o optionFlags) String() string { return "" }
+
+func (o optionFlags) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("want name=value, got %q", value)
+	}
+	o[strings.TrimSpace(name)] = v
+	return nil
+}
+
+// merge returns defaults overridden by the flags.
+func (o optionFlags) merge(defaults converter.Options) converter.Options {
+	out := make(converter.Options, len(defaults)+len(o))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range o {
+		out[k] = v
+	}
+	return out
+}
+
+// withQuality returns a copy of opts with the quality option set.
+func withQuality(opts converter.Options, quality string) converter.Options {
+	out := make(converter.Options, len(opts)+1)
+	for k, v := range opts {
+		out[k] = v
+	}
+	out["quality"] = quality
+	return out
+}
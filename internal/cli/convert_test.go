@@ -0,0 +1,381 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/config"
+)
+
+func TestRunConvert(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "data.json")
+	if err := os.WriteFile(src, []byte(`{"name":"golter"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(tmpDir, "out")
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"convert", src, "--to", "yaml", "--out-dir", outDir, "--quality", "compact"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "data.yaml")); err != nil {
+		t.Errorf("expected output file: %v", err)
+	}
+}
+
+func TestRunConvert_Failure(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "broken.json")
+	if err := os.WriteFile(src, []byte(`{not json`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", "--to", ".yaml", src}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for failed conversion, got %d", code)
+	}
+}
+
+func TestRunConvert_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 without inputs, got %d", code)
+	}
+	if code := Run([]string{"convert", "--quality", "ultra", "a.png"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for invalid quality, got %d", code)
+	}
+}
+
+func TestRunConvert_Report(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.json")
+	bad := filepath.Join(tmpDir, "bad.json")
+	if err := os.WriteFile(good, []byte(`{"ok":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte(`{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"convert", good, bad, "--to", ".yaml", "--report", "-", "--report-format", "ndjson"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected only 2 ndjson lines on stdout, got %q", stdout.String())
+	}
+	for _, line := range lines {
+		var e batch.Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", line, err)
+		}
+		if e.Source == bad && e.ErrorClass != batch.ErrorClassCorruptInput {
+			t.Errorf("expected corrupt_input for %s, got %q", bad, e.ErrorClass)
+		}
+	}
+}
+
+func TestRunConvert_Stdin(t *testing.T) {
+	oldStdin := stdin
+	defer func() { stdin = oldStdin }()
+	stdin = strings.NewReader("name: golter\ntags: [cli]\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", "-", "--from", "yaml", "--to", ".json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &payload); err != nil {
+		t.Fatalf("stdout is not json: %v (%q)", err, stdout.String())
+	}
+	if payload["name"] != "golter" {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestRunConvert_StdoutFromFile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(src, []byte("# Hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".html", "--output", "-"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "<h1>Hello</h1>") {
+		t.Errorf("expected rendered HTML on stdout, got %q", stdout.String())
+	}
+}
+
+func TestRunConvert_OutputFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "page.md")
+	if err := os.WriteFile(src, []byte("# Hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "page.html")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".html", "--output", out}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if data, err := os.ReadFile(out); err != nil || !strings.Contains(string(data), "<h1>Hello</h1>") {
+		t.Errorf("expected rendered HTML in %s, got %q (%v)", out, data, err)
+	}
+
+	// A failed conversion leaves neither the output nor its temporary file
+	failed := filepath.Join(dir, "page.json")
+	if code := Run([]string{"convert", src, "--to", ".json", "--output", failed}, &stdout, &stderr); code == 0 {
+		t.Fatal("expected converting markdown to JSON to fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected only the source and the first output, got %v", entries)
+	}
+}
+
+func TestRunConvert_StreamRejectsBatchFlags(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(src, []byte("# Hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range []string{"--verify", "--cache", "--on-conflict=skip", "--dry-run"} {
+		var stdout, stderr bytes.Buffer
+		if code := Run([]string{"convert", src, "--to", ".html", "--output", "-", flag}, &stdout, &stderr); code != 2 {
+			t.Errorf("expected exit code 2 for %s, got %d", flag, code)
+		}
+		if !strings.Contains(stderr.String(), "cannot be used with --output") {
+			t.Errorf("expected %s to be refused, got %q", flag, stderr.String())
+		}
+	}
+}
+
+func TestRunConvert_StdinRequiresFormats(t *testing.T) {
+	oldStdin := stdin
+	defer func() { stdin = oldStdin }()
+	stdin = strings.NewReader("name: golter\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", "-", "--to", ".json"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 when the format cannot be detected, got %d", code)
+	}
+	if code := Run([]string{"convert", "-", "--from", "yaml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 without --to, got %d", code)
+	}
+}
+
+func TestRunConvert_StdinDetectsFormat(t *testing.T) {
+	oldStdin := stdin
+	defer func() { stdin = oldStdin }()
+	stdin = strings.NewReader("<!DOCTYPE html><html><body><h1>Hi</h1></body></html>")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", "-", "--to", ".md"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Hi") {
+		t.Errorf("expected converted markdown, got %q", stdout.String())
+	}
+}
+
+func TestRunConvert_SetOptions(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(src, []byte("# Hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".html", "--output", "-", "--set", "title=Release notes"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "<title>Release notes</title>") {
+		t.Errorf("expected the title option in the page, got %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := Run([]string{"convert", src, "--set", "title"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for a malformed --set, got %d", code)
+	}
+}
+
+func TestRunConvert_InvalidOption(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "pixel.png")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".jpg", "--set", "imageQuality=0"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "invalid option imageQuality") {
+		t.Errorf("expected the option error, got %q", stderr.String())
+	}
+}
+
+func TestRunConvert_OnConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "data.json")
+	if err := os.WriteFile(src, []byte(`{"name":"golter"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(tmpDir, "data.yaml")
+	if err := os.WriteFile(existing, []byte("keep: me\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", "yaml", "--on-conflict", "skip"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "skip ") || !strings.Contains(stdout.String(), "(1 skipped)") {
+		t.Errorf("expected the file to be reported as skipped, got %q", stdout.String())
+	}
+
+	// The default renames instead of overwriting
+	stdout.Reset()
+	if code := Run([]string{"convert", src, "--to", "yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep: me\n" {
+		t.Errorf("existing output was overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "data_1.yaml")); err != nil {
+		t.Errorf("expected a renamed output: %v", err)
+	}
+
+	if code := Run([]string{"convert", src, "--to", "yaml", "--on-conflict", "ask"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for ask outside the TUI, got %d", code)
+	}
+}
+
+func TestRunConvert_Cache(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv(cache.EnvPath, filepath.Join(tmpDir, "cache.json"))
+	src := filepath.Join(tmpDir, "data.json")
+	if err := os.WriteFile(src, []byte(`{"name":"golter"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"convert", src, "--to", "yaml", "--cache", "--on-conflict", "overwrite"}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	if code := Run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "same ") || !strings.Contains(stdout.String(), "(1 unchanged)") {
+		t.Errorf("expected the second run to reuse the output, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Run(append(args, "--force"), &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ok   ") {
+		t.Errorf("expected --force to convert again, got %q", stdout.String())
+	}
+
+	// Pruning drops the entry once its output is gone
+	os.Remove(filepath.Join(tmpDir, "data.yaml"))
+	stdout.Reset()
+	if code := Run([]string{"cache", "prune"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("cache prune exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Removed 1 of 1") {
+		t.Errorf("unexpected prune output %q", stdout.String())
+	}
+	if code := Run([]string{"cache", "clean"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for an unknown subcommand, got %d", code)
+	}
+}
+
+func TestRunConvert_Verify(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "data.json")
+	if err := os.WriteFile(src, []byte(`{"name":"golter"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", "yaml", "--verify"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Converted 1/1 files") {
+		t.Errorf("expected the verified output to count as converted, got %q", stdout.String())
+	}
+}
+
+func TestRunConvert_ConfiguredTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "fake-ffmpeg")
+	script := "#!/bin/sh\necho \"$@\" > \"$FAKE_LOG\"\nfor last; do :; done\necho audio > \"$last\"\n"
+	if err := os.WriteFile(fake, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "args.log")
+	cfgPath := filepath.Join(dir, "config.toml")
+	cfg := fmt.Sprintf("[tools]\nenv = [%q]\n\n[tools.paths]\nffmpeg = %q\n", "FAKE_LOG="+log, fake)
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvPath, cfgPath)
+
+	src := filepath.Join(dir, "song.wav")
+	if err := os.WriteFile(src, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", "mp3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	args, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("the configured ffmpeg did not run: %v", err)
+	}
+	if !strings.Contains(string(args), "-i "+src) || !strings.Contains(string(args), "libmp3lame") {
+		t.Errorf("unexpected ffmpeg arguments %q", args)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "song.mp3")); err != nil {
+		t.Errorf("expected the output: %v", err)
+	}
+
+	// Single conversions to stdout use the configured tool too
+	os.Remove(log)
+	stdout.Reset()
+	if code := Run([]string{"convert", src, "--to", "mp3", "--output", "-"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert to stdout exited with %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(log); err != nil {
+		t.Errorf("the configured ffmpeg did not run for the stream: %v", err)
+	}
+	if stdout.String() != "audio\n" {
+		t.Errorf("expected the fake ffmpeg's output on stdout, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// toolHints explain where to get each known tool and what it enables.
+var toolHints = map[string]string{
+	"ffmpeg":        "video and audio conversions (https://ffmpeg.org)",
+	"ffprobe":       "optional, progress for video and audio (ships with ffmpeg)",
+	"pandoc":        "DOCX conversions (https://pandoc.org)",
+	"ebook-convert": "MOBI, AZW3 and other ebook conversions (https://calibre-ebook.com)",
+}
+
+// encoderStatus reports whether an ffmpeg encoder golter uses is installed.
+type encoderStatus struct {
+	Name      string   `json:"name"`
+	Available bool     `json:"available"`
+	Targets   []string `json:"targets"`
+}
+
+// doctorReport is the machine-readable output of golter doctor.
+type doctorReport struct {
+	Tools       []converter.Tool       `json:"tools"`
+	Encoders    []encoderStatus        `json:"encoders"`
+	Unavailable []converter.Conversion `json:"unavailable"`
+}
+
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter doctor [--json]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	mgr := newManager(cfg, stderr)
+	caps := converter.ProbeTools(converter.WithRunner(context.Background(), cfg.Runner()), converter.KnownTools...)
+	mgr.SetCapabilities(caps)
+	report := diagnose(mgr, caps)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "failed to encode report: %v\n", err)
+			return 1
+		}
+	} else {
+		printDoctor(stdout, report)
+	}
+
+	if len(report.Unavailable) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// diagnose collects the tools, the ffmpeg encoders direct conversions use and
+// the conversions that cannot run.
+func diagnose(mgr *converter.Manager, caps *converter.Capabilities) doctorReport {
+	report := doctorReport{
+		Tools:       caps.Tools,
+		Unavailable: []converter.Conversion{},
+	}
+
+	targets := make(map[string]map[string]bool)
+	for _, c := range mgr.Conversions() {
+		if len(c.Missing) > 0 {
+			report.Unavailable = append(report.Unavailable, c)
+		}
+		if len(c.Via) > 0 {
+			continue
+		}
+		for _, e := range c.Encoders {
+			if targets[e] == nil {
+				targets[e] = make(map[string]bool)
+			}
+			targets[e][c.Target] = true
+		}
+	}
+
+	for name, set := range targets {
+		status := encoderStatus{Name: name, Available: caps.HasEncoder(name)}
+		for t := range set {
+			status.Targets = append(status.Targets, t)
+		}
+		sort.Strings(status.Targets)
+		report.Encoders = append(report.Encoders, status)
+	}
+	sort.Slice(report.Encoders, func(i, j int) bool { return report.Encoders[i].Name < report.Encoders[j].Name })
+	return report
+}
+
+func printDoctor(w io.Writer, report doctorReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tSTATUS\tVERSION\tNEEDED FOR")
+	for _, t := range report.Tools {
+		status, version := "ok", t.Version
+		if !t.Available {
+			status, version = "missing", "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Name, status, version, toolHints[t.Name])
+	}
+	tw.Flush()
+
+	ffmpeg := false
+	for _, t := range report.Tools {
+		ffmpeg = ffmpeg || (t.Name == "ffmpeg" && t.Available)
+	}
+	if ffmpeg && len(report.Encoders) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENCODER\tSTATUS\tTARGETS")
+		for _, e := range report.Encoders {
+			status := "ok"
+			if !e.Available {
+				status = "missing"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Name, status, strings.Join(e.Targets, ", "))
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w)
+	if len(report.Unavailable) == 0 {
+		fmt.Fprintln(w, "Every conversion is available.")
+		return
+	}
+	fmt.Fprintf(w, "%d conversions are unavailable until the missing tools are installed; see golter formats --available.\n", len(report.Unavailable))
+}
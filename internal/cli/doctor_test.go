@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeFFmpeg puts an ffmpeg on PATH that only has the H.264 and AAC encoders,
+// with no other tools available.
+func fakeFFmpeg(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools need a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+  *-encoders*) printf 'Encoders:\n ------\n V....D libx264  H.264\n A....D aac  AAC\n' ;;
+  *) echo "ffmpeg version 9.9-test" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestRunDoctor(t *testing.T) {
+	fakeFFmpeg(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"doctor"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 with missing tools, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"ffmpeg version 9.9-test", "pandoc", "missing", "libx265", "conversions are unavailable"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunDoctor_JSON(t *testing.T) {
+	fakeFFmpeg(t)
+
+	var stdout, stderr bytes.Buffer
+	Run([]string{"doctor", "--json"}, &stdout, &stderr)
+	var report doctorReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+
+	encoders := map[string]bool{}
+	for _, e := range report.Encoders {
+		encoders[e.Name] = e.Available
+	}
+	if !encoders["libx264"] || encoders["libx265"] {
+		t.Errorf("unexpected encoder status: %v", encoders)
+	}
+	for _, c := range report.Unavailable {
+		if c.Source == ".mp4" && c.Target == ".mov" {
+			t.Error(".mp4 to .mov only needs libx264 and aac")
+		}
+	}
+}
+
+func TestRunFormats_Available(t *testing.T) {
+	fakeFFmpeg(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"formats", "--from", "mp4", "--available"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("formats exited with %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, ".mov") || strings.Contains(out, ".mkv") {
+		t.Errorf("expected .mov listed and .mkv hidden, got:\n%s", out)
+	}
+}
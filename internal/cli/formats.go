@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func runFormats(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("formats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print the conversion matrix as JSON")
+	from := fs.String("from", "", "only show conversions from this source format")
+	available := fs.Bool("available", false, "probe external tools and only show conversions that can run")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter formats [--json] [--from .ext] [--available]")
+		fs.PrintDefaults()
+	}
+	if _, err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+
+	mgr := newManager(cfg, stderr)
+	if *available {
+		mgr.SetCapabilities(converter.ProbeTools(converter.WithRunner(context.Background(), cfg.Runner()), converter.KnownTools...))
+	}
+	conversions := mgr.Conversions()
+
+	src := converter.NormalizeExt(*from)
+	filtered := conversions[:0]
+	for _, c := range conversions {
+		if (src == "" || c.Source == src) && len(c.Missing) == 0 {
+			filtered = append(filtered, c)
+		}
+	}
+	conversions = filtered
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if conversions == nil {
+			conversions = []converter.Conversion{}
+		}
+		if err := enc.Encode(conversions); err != nil {
+			fmt.Fprintf(stderr, "failed to encode formats: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tTARGET\tCONVERTER\tREQUIRES")
+	for _, c := range conversions {
+		tools := strings.Join(c.Tools, ", ")
+		if tools == "" {
+			tools = "-"
+		}
+		name := c.Converter
+		if c.Pinned {
+			name += " (pinned)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Source, c.Target, name, tools)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "failed to write formats: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func TestRunFormats_Table(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"formats"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("formats exited with %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "SOURCE") {
+		t.Errorf("expected table header, got %q", out)
+	}
+	if !strings.Contains(out, "Video Converter (ffmpeg)") || !strings.Contains(out, "ffmpeg") {
+		t.Error("expected video conversions requiring ffmpeg in table")
+	}
+}
+
+func TestRunFormats_JSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"formats", "--json", "--from", "md"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("formats exited with %d: %s", code, stderr.String())
+	}
+
+	var conversions []converter.Conversion
+	if err := json.Unmarshal(stdout.Bytes(), &conversions); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	if len(conversions) == 0 {
+		t.Fatal("expected conversions from .md")
+	}
+	for _, c := range conversions {
+		if c.Source != ".md" {
+			t.Errorf("unexpected source %s in filtered output", c.Source)
+		}
+		if c.Target == ".docx" && (len(c.Tools) != 1 || c.Tools[0] != "pandoc") {
+			t.Errorf(".md -> .docx should require pandoc, got %v", c.Tools)
+		}
+	}
+}
+
+func TestRunFormats_Plugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	t.Setenv(config.EnvPath, filepath.Join(dir, "config.toml"))
+	plugins := filepath.Join(dir, "plugins")
+	if err := os.Mkdir(plugins, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho '{\"name\":\"Scans\",\"conversions\":[{\"source\":\".scan\",\"targets\":[\".png\"]}]}'\n"
+	if err := os.WriteFile(filepath.Join(plugins, "scans"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(plugins, "broken"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"formats", "--from", "scan"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("formats exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Scans") {
+		t.Errorf("expected the plugin conversion, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "warn plugin broken") {
+		t.Errorf("expected the broken plugin to be reported, got %q", stderr.String())
+	}
+}
+
+func TestRunFormats_Pins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	t.Setenv(config.EnvPath, filepath.Join(dir, "config.toml"))
+	pins := "[pins]\n\"jpg:png\" = \"sharper png\"\n\"jpg:xyz\" = \"Image Converter\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(pins), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plugins := filepath.Join(dir, "plugins")
+	if err := os.Mkdir(plugins, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho '{\"name\":\"Sharper PNG\",\"conversions\":[{\"source\":\".jpg\",\"targets\":[\".png\"]}]}'\n"
+	if err := os.WriteFile(filepath.Join(plugins, "sharper"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"formats", "--from", "jpg"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("formats exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Sharper PNG (pinned)") {
+		t.Errorf("expected the pinned plugin to handle .jpg to .png, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "warn pin jpg:xyz") {
+		t.Errorf("expected the unusable pin to be reported, got %q", stderr.String())
+	}
+}
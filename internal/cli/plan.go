@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sametcn99/golter/internal/batch"
+)
+
+// printPlan prints what a batch would do with each file and a summary, and
+// returns the exit code: 1 when some file would fail.
+func printPlan(stdout, stderr io.Writer, plan batch.Plan) int {
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tCONVERTER\tOUTPUT\tSIZE\tNOTES")
+	for _, it := range plan.Items {
+		if it.Err != nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t%s\t%v\n", it.Path, formatSize(it.InputSize), it.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", it.Path, it.Converter, it.OutputPath, planSize(it), strings.Join(planNotes(it), "; "))
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "failed to write plan: %v\n", err)
+		return 1
+	}
+
+	input, estimated := plan.Sizes()
+	fmt.Fprintf(stdout, "Would convert %d/%d files", plan.Converting(), len(plan.Items))
+	var notes []string
+	if n := plan.Conflicts(); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d with existing outputs", n))
+	}
+	if n := plan.Failing(); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d would fail", n))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(stdout, " (%s)", strings.Join(notes, ", "))
+	}
+	fmt.Fprintf(stdout, ", %s -> ~%s\n", formatSize(input), formatSize(estimated))
+	if plan.Failing() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// planSize shows the input size and the estimated output size of a file.
+func planSize(it batch.PlanItem) string {
+	switch {
+	case it.Skipped:
+		return formatSize(it.InputSize)
+	case it.Cached:
+		return formatSize(it.InputSize) + " -> " + formatSize(it.EstimatedSize)
+	case it.EstimatedSize == 0:
+		return formatSize(it.InputSize) + " -> ?"
+	default:
+		return formatSize(it.InputSize) + " -> ~" + formatSize(it.EstimatedSize)
+	}
+}
+
+// planNotes lists what stands out about a planned file.
+func planNotes(it batch.PlanItem) []string {
+	var notes []string
+	switch it.Conflict {
+	case batch.ConflictOverwrite:
+		notes = append(notes, "overwrites existing output")
+	case batch.ConflictRename:
+		notes = append(notes, "output exists, renamed")
+	case batch.ConflictSkip:
+		notes = append(notes, fmt.Sprintf("skipped, %s exists", filepath.Base(it.OutputPath)))
+	}
+	if it.Cached {
+		notes = append(notes, "unchanged, reused")
+	}
+	if len(it.Missing) > 0 {
+		notes = append(notes, "missing "+strings.Join(it.Missing, ", "))
+	} else if len(it.Tools) > 0 {
+		notes = append(notes, "runs "+strings.Join(it.Tools, ", "))
+	}
+	if it.Warning != "" {
+		notes = append(notes, it.Warning)
+	}
+	return notes
+}
+
+// formatSize returns size in bytes with a binary unit.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
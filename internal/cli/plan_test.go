@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a.json")
+	b := filepath.Join(tmpDir, "b.json")
+	for _, p := range []string{a, b, filepath.Join(tmpDir, "a.yaml")} {
+		if err := os.WriteFile(p, []byte(`{"ok":true}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"convert", a, b, "--to", ".yaml", "--on-conflict", "rename", "--dry-run"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("dry run exited with %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"a_1.yaml", "output exists, renamed", "b.yaml", "Would convert 2/2 files (1 with existing outputs)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the plan, got:\n%s", want, out)
+		}
+	}
+	for _, name := range []string{"a_1.yaml", "b.yaml"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("a dry run must not write %s", name)
+		}
+	}
+
+	// Files that cannot be converted fail the dry run
+	unknown := filepath.Join(tmpDir, "notes.xyz")
+	if err := os.WriteFile(unknown, []byte("?"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := Run([]string{"convert", unknown, "--to", ".yaml", "--dry-run"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unsupported file, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "1 would fail") {
+		t.Errorf("expected the failure in the summary, got:\n%s", stdout.String())
+	}
+
+	if code := Run([]string{"convert", a, "--to", ".yaml", "--dry-run", "--report", "-"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for --report with --dry-run, got %d", code)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:         "0 B",
+		1023:      "1023 B",
+		1536:      "1.5 KB",
+		5 << 20:   "5.0 MB",
+		3<<30 + 1: "3.0 GB",
+	}
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+)
+
+// reportSink prints human-readable results and, when requested, writes a
+// machine-readable report alongside them.
+type reportSink struct {
+	format string
+	logOut io.Writer
+	errOut io.Writer
+	out    io.Writer
+	file   *os.File
+}
+
+// openReport prepares the report destination for the --report and --report-format flags.
+// Human-readable output moves to stderr when the report goes to stdout.
+func openReport(path, format string, stdout, stderr io.Writer) (*reportSink, error) {
+	format, err := reportFormatFor(path, format)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &reportSink{format: format, logOut: stdout, errOut: stderr}
+	switch path {
+	case "":
+	case "-":
+		s.logOut = stderr
+		s.out = stdout
+	default:
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create report file: %w", err)
+		}
+		s.file = f
+		s.out = f
+	}
+	return s, nil
+}
+
+// result prints a finished file and streams it to NDJSON reports.
+func (s *reportSink) result(res batch.Result) {
+	printResult(s.logOut, s.errOut, res)
+	if s.out != nil && s.format == "ndjson" {
+		if err := batch.WriteNDJSONLine(s.out, res); err != nil {
+			fmt.Fprintln(s.errOut, err)
+		}
+	}
+}
+
+// finish writes JSON reports and the summary line and returns the exit code.
+func (s *reportSink) finish(report batch.Report) int {
+	if s.out != nil && s.format == "json" {
+		if err := batch.WriteJSON(s.out, report); err != nil {
+			fmt.Fprintln(s.errOut, err)
+			return 1
+		}
+	}
+
+	failed, skipped, cached := report.Failed(), report.Skipped(), report.Cached()
+	fmt.Fprintf(s.logOut, "Converted %d/%d files", len(report.Results)-failed-skipped-cached, len(report.Results))
+	var notes []string
+	if skipped > 0 {
+		notes = append(notes, fmt.Sprintf("%d skipped", skipped))
+	}
+	if cached > 0 {
+		notes = append(notes, fmt.Sprintf("%d unchanged", cached))
+	}
+	if unverified := report.Unverified(); unverified > 0 {
+		notes = append(notes, fmt.Sprintf("%d failed verification", unverified))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(s.logOut, " (%s)", strings.Join(notes, ", "))
+	}
+	fmt.Fprintf(s.logOut, " in %s\n", report.Duration.Round(time.Millisecond))
+	if report.Cancelled {
+		fmt.Fprintln(s.errOut, "cancelled")
+		return 130
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (s *reportSink) close() {
+	if s.file != nil {
+		s.file.Close()
+	}
+}
+
+// reportFormatFor resolves the report format from the flag or the report file extension.
+func reportFormatFor(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ndjson", ".jsonl":
+			return "ndjson", nil
+		default:
+			return "json", nil
+		}
+	}
+	format = strings.ToLower(format)
+	if format != "json" && format != "ndjson" {
+		return "", fmt.Errorf("invalid report format %q (want json or ndjson)", format)
+	}
+	return format, nil
+}
+
+func printResult(stdout, stderr io.Writer, res batch.Result) {
+	if res.Warning != "" {
+		fmt.Fprintf(stderr, "warn %s: %s\n", res.Path, res.Warning)
+	}
+	if res.Err != nil {
+		fmt.Fprintf(stderr, "FAIL %s: %v\n", res.Path, res.Err)
+		return
+	}
+	if res.Skipped {
+		fmt.Fprintf(stdout, "skip %s: %s already exists\n", res.Path, filepath.Base(res.OutputPath))
+		return
+	}
+	if res.Cached {
+		fmt.Fprintf(stdout, "same %s -> %s (unchanged)\n", res.Path, filepath.Base(res.OutputPath))
+		return
+	}
+	fmt.Fprintf(stdout, "ok   %s -> %s (%s)\n", res.Path, filepath.Base(res.OutputPath), res.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,23 @@
+package cli
+
+import "testing"
+
+func TestReportFormatFor(t *testing.T) {
+	tests := []struct {
+		path, format, want string
+	}{
+		{"out.json", "", "json"},
+		{"out.ndjson", "", "ndjson"},
+		{"out.jsonl", "", "ndjson"},
+		{"-", "NDJSON", "ndjson"},
+	}
+	for _, tt := range tests {
+		got, err := reportFormatFor(tt.path, tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("reportFormatFor(%q, %q) = %q, %v; want %q", tt.path, tt.format, got, err, tt.want)
+		}
+	}
+	if _, err := reportFormatFor("out.json", "xml"); err == nil {
+		t.Error("reportFormatFor should reject unknown formats")
+	}
+}
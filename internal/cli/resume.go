@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+)
+
+func runResume(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("resume", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	list := fs.Bool("list", false, "list the interrupted batches instead of resuming one")
+	discard := fs.Bool("discard", false, "forget the batch instead of resuming it")
+	reportPath := fs.String("report", "", "write a machine-readable results report to this file (- for stdout)")
+	reportFormat := fs.String("report-format", "", "report format: json or ndjson (default from the --report extension)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter resume [flags] [id]   resume an interrupted batch (default: the newest)")
+		fmt.Fprintln(stderr, "       golter resume --list          list interrupted batches")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 || (*list && (len(positional) > 0 || *discard)) {
+		fs.Usage()
+		return 2
+	}
+
+	dir, err := journal.Dir()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *list {
+		return listJournals(dir, stdout, stderr)
+	}
+
+	id := ""
+	if len(positional) == 1 {
+		id = positional[0]
+	}
+	jr, err := journal.Find(dir, id)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	// Claim the batch before touching its outputs
+	if err := jr.Claim(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer jr.Close()
+	if *discard {
+		jr.CleanPartial()
+		if err := jr.Remove(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Discarded %s\n", jr.ID)
+		return 0
+	}
+
+	sink, err := openReport(*reportPath, *reportFormat, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer sink.close()
+
+	for _, partial := range jr.CleanPartial() {
+		fmt.Fprintf(sink.logOut, "Removed partial output %s\n", partial)
+	}
+	job := batch.ResumeJob(jr)
+	job.Limits = cfg.Limits()
+	done, total := jr.Progress()
+	fmt.Fprintf(sink.logOut, "Resuming %s: %d of %d files left\n", jr.ID, total-done, total)
+
+	// Batches started in the TUI may ask about conflicts, which needs a terminal
+	if job.Conflict == batch.ConflictAsk {
+		job.Conflict = batch.ConflictRename
+	}
+	if job.OutDir != "" {
+		if err := os.MkdirAll(job.OutDir, 0755); err != nil {
+			fmt.Fprintf(stderr, "failed to create output directory: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = converter.WithRunner(ctx, cfg.Runner())
+
+	job.Cache = openCache(jr.Spec.Cache, stderr)
+	report := batch.Run(ctx, newManager(cfg, stderr), job, sink.result)
+	saveCache(job.Cache, stderr)
+	finishJournal(jr, report, stderr)
+
+	return sink.finish(report)
+}
+
+// listJournals prints the interrupted batches in dir, newest first.
+func listJournals(dir string, stdout, stderr io.Writer) int {
+	journals, err := journal.List(dir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(journals) == 0 {
+		fmt.Fprintln(stdout, "No interrupted batches")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTARTED\tTARGET\tDONE")
+	for _, jr := range journals {
+		target := jr.Spec.TargetExt
+		if target == "" {
+			target = "(compress)"
+		}
+		done, total := jr.Progress()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\n", jr.ID, jr.Created.Format("2006-01-02 15:04"), target, done, total)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "failed to write batches: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// startJournal records job on disk so it can be resumed if interrupted. A
+// journal that cannot be created is reported and left out.
+func startJournal(job batch.Job, stderr io.Writer) *journal.Journal {
+	dir, err := journal.Dir()
+	if err != nil {
+		fmt.Fprintf(stderr, "warn %v\n", err)
+		return nil
+	}
+	jr, err := journal.Create(dir, job.Spec())
+	if err != nil {
+		fmt.Fprintf(stderr, "warn %v\n", err)
+		return nil
+	}
+	return jr
+}
+
+// finishJournal deletes the journal of a batch that ran to the end, and
+// keeps that of a cancelled one, saying how to resume it.
+func finishJournal(jr *journal.Journal, report batch.Report, stderr io.Writer) {
+	if jr == nil {
+		return
+	}
+	if !report.Cancelled {
+		if err := jr.Remove(); err != nil {
+			fmt.Fprintf(stderr, "warn %v\n", err)
+		}
+		return
+	}
+	if err := jr.Close(); err != nil {
+		fmt.Fprintf(stderr, "warn %v\n", err)
+	}
+	fmt.Fprintf(stderr, "resume with: golter resume %s\n", jr.ID)
+}
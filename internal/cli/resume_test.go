@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/journal"
+)
+
+func TestRunResume(t *testing.T) {
+	journalDir := t.TempDir()
+	t.Setenv(journal.EnvDir, journalDir)
+	tmpDir := t.TempDir()
+	a, b := filepath.Join(tmpDir, "a.json"), filepath.Join(tmpDir, "b.json")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte(`{"ok":true}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a.json finished before the batch was interrupted inside b.json
+	outA, outB := filepath.Join(tmpDir, "a.yaml"), filepath.Join(tmpDir, "b.yaml")
+	if err := os.WriteFile(outA, []byte("kept: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(tmpDir, ".b.golter-123.yaml")
+	if err := os.WriteFile(partial, []byte("ok: tr"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jr, err := journal.Create(journalDir, journal.Spec{Files: []string{a, b}, TargetExt: ".yaml", Quality: "Balanced", Conflict: "rename"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jr.Done(a, outA)
+	jr.Start(b, outB)
+	jr.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"resume", "--list"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("resume --list exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), jr.ID) || !strings.Contains(stdout.String(), "1/2") {
+		t.Errorf("expected the batch in the list, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"resume", jr.ID[:8]}, &stdout, &stderr); code != 0 {
+		t.Fatalf("resume exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Converted 1/1 files") {
+		t.Errorf("expected only b.json to be converted, got %q", stdout.String())
+	}
+	if data, _ := os.ReadFile(outA); string(data) != "kept: true\n" {
+		t.Errorf("a.yaml was converted again: %q", data)
+	}
+	if _, err := os.Stat(outB); err != nil {
+		t.Errorf("expected b.yaml: %v", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Error("expected the partial output to be removed")
+	}
+	if journals, _ := journal.List(journalDir); len(journals) != 0 {
+		t.Errorf("expected the finished batch's journal to be removed, found %d", len(journals))
+	}
+
+	if code := Run([]string{"resume"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 with nothing to resume, got %d", code)
+	}
+}
+
+func TestRunConvert_RemovesJournal(t *testing.T) {
+	journalDir := t.TempDir()
+	t.Setenv(journal.EnvDir, journalDir)
+	src := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(src, []byte(`{"ok":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"convert", src, "--to", ".yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("convert exited with %d: %s", code, stderr.String())
+	}
+	if journals, _ := journal.List(journalDir); len(journals) != 0 {
+		t.Errorf("expected no journal after a finished batch, found %d", len(journals))
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/recipe"
+)
+
+func runRecipe(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	check := fs.Bool("check", false, "validate the job file without converting anything")
+	reportPath := fs.String("report", "", "write a machine-readable results report to this file (- for stdout)")
+	reportFormat := fs.String("report-format", "", "report format: json or ndjson (default from the --report extension)")
+	onConflict := fs.String("on-conflict", string(cfg.ConflictPolicy(false)), "when an output exists: overwrite, skip or rename")
+	useCache := fs.Bool("cache", cfg.Cache, "skip files converted before with the same content and settings")
+	force := fs.Bool("force", false, "convert every file even when the cache has an unchanged output")
+	verify := fs.Bool("verify", cfg.Verify, "read every output back and fail files whose output is unreadable")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter run [flags] <job.yaml>")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	conflict, err := parseConflictFlag(*onConflict)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	r, err := recipe.Load(positional[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	r.Defaults = recipe.Defaults{
+		Quality:  cfg.QualityLevel(),
+		Jobs:     cfg.Workers,
+		Limits:   cfg.Limits(),
+		Options:  cfg.Options(),
+		Conflict: conflict,
+	}
+
+	mgr := newManager(cfg, stderr)
+	if *check {
+		jobs, err := r.Validate(mgr)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid job file:\n%v\n", err)
+			return 1
+		}
+		files := 0
+		for _, job := range jobs {
+			files += len(job.Files)
+		}
+		fmt.Fprintf(stdout, "Job file is valid: %d conversions in %d steps\n", files, len(jobs))
+		return 0
+	}
+
+	sink, err := openReport(*reportPath, *reportFormat, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer sink.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = converter.WithRunner(ctx, cfg.Runner())
+
+	r.Defaults.Cache = openCache(*useCache, stderr)
+	r.Defaults.Force = *force
+	r.Defaults.Verify = *verify
+	report, err := r.Run(ctx, mgr, sink.result)
+	saveCache(r.Defaults.Cache, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "job failed:\n%v\n", err)
+		return 1
+	}
+	return sink.finish(report)
+}
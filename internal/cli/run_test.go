@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunRecipe(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobPath := filepath.Join(dir, "job.yml")
+	if err := os.WriteFile(jobPath, []byte("jobs:\n  - inputs: [a.yaml]\n    targets: [.json]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"run", "--check", jobPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run --check exited with %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); !os.IsNotExist(err) {
+		t.Error("--check must not convert anything")
+	}
+
+	if code := Run([]string{"run", jobPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run exited with %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); err != nil {
+		t.Errorf("expected output: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/server"
+)
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	maxUpload := fs.Int64("max-upload", server.DefaultMaxUpload>>20, "largest accepted upload in MB")
+	defaultWorkers := cfg.Workers
+	if defaultWorkers == 0 {
+		defaultWorkers = server.DefaultWorkers
+	}
+	workers := fs.Int("workers", defaultWorkers, "number of conversions run concurrently")
+	ttl := fs.Duration("ttl", server.DefaultResultTTL, "how long finished results are kept")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter serve [flags]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *maxUpload < 1 || *workers < 1 || *ttl <= 0 {
+		fmt.Fprintln(stderr, "--max-upload, --workers and --ttl must be positive")
+		return 2
+	}
+
+	srv, err := server.New(newManager(cfg, stderr), server.Config{
+		MaxUpload: *maxUpload << 20,
+		Workers:   *workers,
+		ResultTTL: *ttl,
+		Quality:   cfg.QualityLevel(),
+		Options:   cfg.Options(),
+		Runner:    cfg.Runner(),
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer srv.Close()
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(stdout, "Serving on http://%s (Ctrl+C to stop)\n", *addr)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
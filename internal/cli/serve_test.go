@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRunServe_InvalidFlags(t *testing.T) {
+	tests := [][]string{
+		{"--workers", "0"},
+		{"--max-upload", "0"},
+		{"extra"},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(append([]string{"serve"}, args...), &stdout, &stderr); code != 2 {
+			t.Errorf("serve %v: expected exit code 2, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/watch"
+)
+
+func runWatch(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	to := fs.String("to", "", "target format extension, e.g. .webp")
+	quality := fs.String("quality", cfg.Quality, "output quality: high, balanced or compact")
+	outDir := fs.String("out-dir", cfg.OutputDir, "write outputs to this directory instead of next to the source")
+	jobs := fs.Int("jobs", cfg.Workers, "maximum number of files converted concurrently (0 sizes it from the CPUs)")
+	interval := fs.Duration("interval", watch.DefaultInterval, "how often to scan the directory")
+	statePath := fs.String("state", "", "file recording handled files (default <dir>/"+watch.StateFileName+")")
+	useCache := fs.Bool("cache", cfg.Cache, "skip files converted before with the same content and settings")
+	verify := fs.Bool("verify", cfg.Verify, "read every output back and fail files whose output is unreadable")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: golter watch <dir> --to .ext [flags]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 || *to == "" {
+		fs.Usage()
+		return 2
+	}
+	dir := positional[0]
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(stderr, "not a directory: %s\n", dir)
+		return 2
+	}
+
+	q, err := batch.ParseQuality(*quality)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *jobs < 0 || *interval <= 0 {
+		fmt.Fprintln(stderr, "--jobs must not be negative and --interval must be positive")
+		return 2
+	}
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			fmt.Fprintf(stderr, "failed to create output directory: %v\n", err)
+			return 1
+		}
+	}
+
+	w := watch.New(newManager(cfg, stderr), dir, converter.NormalizeExt(*to))
+	w.Quality = q
+	w.OutDir = *outDir
+	w.Jobs = *jobs
+	w.Limits = cfg.Limits()
+	w.Interval = *interval
+	w.Options = cfg.Options()
+	w.Cache = openCache(*useCache, stderr)
+	w.Verify = *verify
+	if *statePath != "" {
+		w.StatePath = *statePath
+	}
+	w.OnResult = func(res batch.Result) {
+		printResult(stdout, stderr, res)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = converter.WithRunner(ctx, cfg.Runner())
+
+	fmt.Fprintf(stdout, "Watching %s for files to convert to %s (Ctrl+C to stop)\n", dir, w.TargetExt)
+	if err := w.Run(ctx); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// EnvPath overrides the location of the config file.
+const EnvPath = "GOLTER_CONFIG"
+
+// Config holds user defaults shared by the TUI and the CLI.
+//
+//	start_dir = "~/Pictures"
+//	quality = "balanced"
+//	workers = 8
+//	heavy_workers = 2
+//	output_dir = "~/Converted"
+//	on_conflict = "ask"
+//	show_hidden = false
+//	check_updates = true
+//	pandoc_args = ["--toc"]
+//	ebook_args = ["--pretty-print"]
+//	plugin_dir = "~/.local/share/golter/plugins"
+//	cache = true
+//	verify = true
+//
+//	[pins]
+//	"csv:xlsx" = "Document Converter"
+//
+//	[tools]
+//	timeout = "2h"
+//	env = ["LD_LIBRARY_PATH=/opt/ffmpeg/lib"]
+//
+//	[tools.paths]
+//	ffmpeg = "/opt/ffmpeg/bin/ffmpeg"
+type Config struct {
+	// StartDir is where the TUI opens when no path is given. Empty means the home directory.
+	StartDir string `toml:"start_dir"`
+	// Quality is the default quality: high, balanced or compact.
+	Quality string `toml:"quality"`
+	// Workers caps the number of files converted concurrently. 0 leaves it
+	// to the per-cost limits below.
+	Workers int `toml:"workers"`
+	// HeavyWorkers caps concurrent conversions that run CPU-heavy external
+	// tools such as ffmpeg. 0 means one per four CPUs.
+	HeavyWorkers int `toml:"heavy_workers"`
+	// LightWorkers caps concurrent in-process conversions. 0 means one per CPU.
+	LightWorkers int `toml:"light_workers"`
+	// OutputDir is where outputs are written. Empty writes next to the source.
+	OutputDir string `toml:"output_dir"`
+	// OnConflict is what happens when an output already exists: overwrite,
+	// skip, rename or ask. Asking only works in the TUI; the CLI renames.
+	OnConflict string `toml:"on_conflict"`
+	// ShowHidden lists dotfiles and dot-directories in the TUI.
+	ShowHidden bool `toml:"show_hidden"`
+	// CheckUpdates looks for a newer release when the TUI starts.
+	CheckUpdates bool `toml:"check_updates"`
+	// PandocArgs are extra arguments passed to Pandoc.
+	PandocArgs []string `toml:"pandoc_args"`
+	// EbookArgs are extra arguments passed to Calibre's ebook-convert.
+	EbookArgs []string `toml:"ebook_args"`
+	// PluginDir holds external converter plugins. Empty means the plugins
+	// directory next to the config file.
+	PluginDir string `toml:"plugin_dir"`
+	// Cache skips files converted before from the same content with the
+	// same settings, when their output is unchanged.
+	Cache bool `toml:"cache"`
+	// Verify reads every output back before counting the file as converted.
+	Verify bool `toml:"verify"`
+	// Pins choose the converter for a conversion that several converters
+	// handle, keyed by "<source>:<target>" and naming the converter as
+	// golter formats lists it.
+	Pins map[string]string `toml:"pins"`
+	// Tools sets where external programs are found and how they run.
+	Tools Tools `toml:"tools"`
+}
+
+// Tools configures the external programs converters run, such as ffmpeg,
+// Pandoc and Calibre's ebook-convert.
+type Tools struct {
+	// Paths maps program names to the binaries to run instead of the ones
+	// found on PATH.
+	Paths map[string]string `toml:"paths"`
+	// Env holds extra "KEY=value" variables the programs run with.
+	Env []string `toml:"env"`
+	// Dir is the working directory programs run in. Empty means golter's.
+	Dir string `toml:"dir"`
+	// Timeout stops a program that runs longer than this, e.g. "2h".
+	// Empty means no limit.
+	Timeout string `toml:"timeout"`
+}
+
+// Default returns the settings used when no config file exists.
+func Default() Config {
+	return Config{
+		Quality:      "high",
+		OnConflict:   string(batch.ConflictAsk),
+		CheckUpdates: true,
+	}
+}
+
+// Path returns the config file location: $GOLTER_CONFIG, or golter/config.toml
+// in the user config directory ($XDG_CONFIG_HOME on Linux).
+func Path() (string, error) {
+	if p := os.Getenv(EnvPath); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "golter", "config.toml"), nil
+}
+
+// Load reads the config file at Path. A missing file yields Default.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), nil
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the config file at path over the defaults. A missing file yields Default.
+func LoadFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes config file contents over the defaults and validates them.
+func Parse(data []byte) (Config, error) {
+	cfg := Default()
+	dec := toml.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if _, err := batch.ParseQuality(cfg.Quality); err != nil {
+		return Config{}, err
+	}
+	if _, err := batch.ParseConflictPolicy(cfg.OnConflict); err != nil {
+		return Config{}, err
+	}
+	if cfg.Workers < 0 || cfg.HeavyWorkers < 0 || cfg.LightWorkers < 0 {
+		return Config{}, fmt.Errorf("workers, heavy_workers and light_workers must not be negative")
+	}
+	cfg.StartDir = expandHome(cfg.StartDir)
+	cfg.OutputDir = expandHome(cfg.OutputDir)
+	cfg.PluginDir = expandHome(cfg.PluginDir)
+	if len(cfg.PandocArgs) == 0 {
+		cfg.PandocArgs = nil
+	}
+	if len(cfg.EbookArgs) == 0 {
+		cfg.EbookArgs = nil
+	}
+	for pair := range cfg.Pins {
+		if _, _, err := ParsePin(pair); err != nil {
+			return Config{}, err
+		}
+	}
+	if len(cfg.Pins) == 0 {
+		cfg.Pins = nil
+	}
+	if err := cfg.Tools.normalize(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// QualityLevel returns the quality in the form converters expect ("High", "Balanced", "Compact").
+func (c Config) QualityLevel() string {
+	q, err := batch.ParseQuality(c.Quality)
+	if err != nil {
+		return "High"
+	}
+	return q
+}
+
+// ConflictPolicy returns the policy for existing outputs. Without a user to
+// ask, ConflictAsk becomes ConflictRename so nothing is overwritten.
+func (c Config) ConflictPolicy(interactive bool) batch.ConflictPolicy {
+	p, err := batch.ParseConflictPolicy(c.OnConflict)
+	if err != nil {
+		p = batch.ConflictAsk
+	}
+	if p == batch.ConflictAsk && !interactive {
+		return batch.ConflictRename
+	}
+	return p
+}
+
+// Limits returns the per-cost concurrency limits for batches.
+func (c Config) Limits() batch.Limits {
+	return batch.Limits{Heavy: c.HeavyWorkers, Light: c.LightWorkers}
+}
+
+// PluginDirectory returns where converter plugins are loaded from, or "" when
+// the config directory cannot be determined.
+func (c Config) PluginDirectory() string {
+	if c.PluginDir != "" {
+		return c.PluginDir
+	}
+	path, err := Path()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "plugins")
+}
+
+// ParsePin splits a pins key such as "csv:xlsx" into its source and target
+// extensions.
+func ParsePin(pair string) (src, target string, err error) {
+	src, target, ok := strings.Cut(pair, ":")
+	src, target = strings.TrimSpace(src), strings.TrimSpace(target)
+	if !ok || src == "" || target == "" {
+		return "", "", fmt.Errorf("pin %q must have the form \"<source>:<target>\"", pair)
+	}
+	return src, target, nil
+}
+
+// ApplyPins pins the configured converters in m. Pins naming a converter
+// that is not registered, or cannot handle the pair, are reported in the
+// joined error and ignored.
+func (c Config) ApplyPins(m *converter.Manager) error {
+	pairs := make([]string, 0, len(c.Pins))
+	for pair := range c.Pins {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	var errs []error
+	for _, pair := range pairs {
+		src, target, err := ParsePin(pair)
+		if err == nil {
+			err = m.Pin(src, target, c.Pins[pair])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pin %s: %w", pair, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// normalize expands "~" in paths, validates the timeout and drops empty
+// lists so a parsed config compares equal to Default.
+func (t *Tools) normalize() error {
+	if t.Timeout != "" {
+		d, err := time.ParseDuration(t.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("tools timeout must be a positive duration such as \"2h\", got %q", t.Timeout)
+		}
+	}
+	for _, kv := range t.Env {
+		if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+			return fmt.Errorf("tools env entry %q must have the form \"KEY=value\"", kv)
+		}
+	}
+	for tool, path := range t.Paths {
+		t.Paths[tool] = expandHome(path)
+	}
+	t.Dir = expandHome(t.Dir)
+	if len(t.Paths) == 0 {
+		t.Paths = nil
+	}
+	if len(t.Env) == 0 {
+		t.Env = nil
+	}
+	return nil
+}
+
+// Runner returns the runner converters use for external programs.
+func (c Config) Runner() converter.Runner {
+	// Parse has validated the timeout
+	timeout, _ := time.ParseDuration(c.Tools.Timeout)
+	return converter.ExecRunner{
+		Paths:   c.Tools.Paths,
+		Env:     c.Tools.Env,
+		Dir:     c.Tools.Dir,
+		Timeout: timeout,
+	}
+}
+
+// Options returns the converter options derived from the config.
+func (c Config) Options() converter.Options {
+	opts := converter.Options{}
+	if len(c.PandocArgs) > 0 {
+		opts["pandocArgs"] = c.PandocArgs
+	}
+	if len(c.EbookArgs) > 0 {
+		opts["ebookArgs"] = c.EbookArgs
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// Write encodes the config as TOML.
+func (c Config) Write(w io.Writer) error {
+	enc := toml.NewEncoder(w)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func TestParse_OverridesDefaults(t *testing.T) {
+	cfg, err := Parse([]byte(`
+quality = "compact"
+workers = 2
+heavy_workers = 1
+show_hidden = true
+check_updates = false
+pandoc_args = ["--toc"]
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.QualityLevel() != "Compact" || cfg.Workers != 2 || !cfg.ShowHidden || cfg.CheckUpdates {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if limits := cfg.Limits(); limits != (batch.Limits{Heavy: 1}) {
+		t.Errorf("Limits = %+v, want one heavy worker and automatic light workers", limits)
+	}
+	want := []string{"--toc"}
+	if got, _ := cfg.Options()["pandocArgs"].([]string); !reflect.DeepEqual(got, want) {
+		t.Errorf("pandocArgs = %v, want %v", got, want)
+	}
+	if _, ok := cfg.Options()["ebookArgs"]; ok {
+		t.Error("ebookArgs should be unset")
+	}
+}
+
+func TestConfig_ConflictPolicy(t *testing.T) {
+	cfg := Default()
+	if got := cfg.ConflictPolicy(true); got != batch.ConflictAsk {
+		t.Errorf("interactive default = %q, want ask", got)
+	}
+	if got := cfg.ConflictPolicy(false); got != batch.ConflictRename {
+		t.Errorf("non-interactive default = %q, want rename", got)
+	}
+
+	cfg, err := Parse([]byte(`on_conflict = "skip"`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := cfg.ConflictPolicy(false); got != batch.ConflictSkip {
+		t.Errorf("ConflictPolicy = %q, want skip", got)
+	}
+}
+
+func TestConfig_PluginDirectory(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvPath, filepath.Join(dir, "config.toml"))
+	if got, want := Default().PluginDirectory(), filepath.Join(dir, "plugins"); got != want {
+		t.Errorf("default PluginDirectory = %q, want %q", got, want)
+	}
+
+	cfg := Default()
+	cfg.PluginDir = "/opt/golter"
+	if got := cfg.PluginDirectory(); got != "/opt/golter" {
+		t.Errorf("PluginDirectory = %q, want /opt/golter", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown key": `colour = "blue"`,
+		"bad quality": `quality = "ultra"`,
+		"bad workers": `workers = -1`,
+		"bad heavy":   `heavy_workers = -2`,
+		"bad policy":  `on_conflict = "merge"`,
+		"bad pin":     "[pins]\n\"csv\" = \"Document Converter\"",
+		"bad timeout": "[tools]\ntimeout = \"soon\"",
+		"bad env":     "[tools]\nenv = [\"NOEQUALS\"]",
+		"bad syntax":  `quality = `,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParse_ExpandsHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	cfg, err := Parse([]byte(`output_dir = "~/out"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.OutputDir != filepath.Join(home, "out") {
+		t.Errorf("OutputDir = %q", cfg.OutputDir)
+	}
+}
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	t.Setenv(EnvPath, filepath.Join(t.TempDir(), "missing.toml"))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	cfg := Default()
+	cfg.Workers = 3
+	cfg.EbookArgs = []string{"--pretty-print"}
+
+	var buf bytes.Buffer
+	if err := cfg.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "workers = 3") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+	got, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Parse of written config failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("round trip = %+v, want %+v", got, cfg)
+	}
+}
+
+func TestConfig_ApplyPins(t *testing.T) {
+	cfg, err := Parse([]byte(`
+[pins]
+"json:yaml" = "doc data converter"
+"json:png" = "Image Converter"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	mgr := converter.NewDefaultManager()
+	err = cfg.ApplyPins(mgr)
+	if err == nil || !strings.Contains(err.Error(), "pin json:png") || strings.Contains(err.Error(), "json:yaml") {
+		t.Errorf("expected only the json:png pin to fail, got %v", err)
+	}
+	for _, c := range mgr.Conversions() {
+		if c.Source == ".json" && c.Target == ".yaml" && !c.Pinned {
+			t.Errorf("expected .json to .yaml to be pinned: %+v", c)
+		}
+	}
+}
+
+func TestConfig_Runner(t *testing.T) {
+	cfg, err := Parse([]byte(`
+[tools]
+timeout = "90m"
+env = ["LD_LIBRARY_PATH=/opt/ffmpeg/lib"]
+dir = "/tmp"
+
+[tools.paths]
+ffmpeg = "/opt/ffmpeg/bin/ffmpeg"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := cfg.Runner().(converter.ExecRunner)
+	if !ok {
+		t.Fatalf("expected an ExecRunner, got %T", cfg.Runner())
+	}
+	if r.Paths["ffmpeg"] != "/opt/ffmpeg/bin/ffmpeg" || r.Timeout != 90*time.Minute || r.Dir != "/tmp" || len(r.Env) != 1 {
+		t.Errorf("unexpected runner %+v", r)
+	}
+
+	if r := Default().Runner().(converter.ExecRunner); r.Paths != nil || r.Timeout != 0 {
+		t.Errorf("expected the default runner to use PATH without a timeout, got %+v", r)
+	}
+}
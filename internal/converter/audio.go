@@ -1,9 +1,8 @@
 package converter
 
 import (
-	"fmt"
-	"os/exec"
-	"runtime"
+	"context"
+	"strconv"
 	"strings"
 )
 
@@ -34,27 +33,43 @@ func (c *AudioConverter) SupportedTargetFormats(srcExt string) []string {
 	return []string{".mp3", ".wav", ".ogg", ".flac", ".m4a", ".aac"}
 }
 
+func (c *AudioConverter) RequiredTools(srcExt, targetExt string) []string {
+	return []string{"ffmpeg"}
+}
+
+// audioEncoders are the ffmpeg encoders buildAudioFFmpegArgs picks per target.
+var audioEncoders = map[string][]string{
+	".mp3":  {"libmp3lame"},
+	".ogg":  {"libvorbis"},
+	".flac": {"flac"},
+	".wav":  {"pcm_s16le"},
+	".m4a":  {"aac"},
+	".aac":  {"aac"},
+}
+
+func (c *AudioConverter) RequiredEncoders(srcExt, targetExt string) []string {
+	return audioEncoders[NormalizeExt(targetExt)]
+}
+
 func (c *AudioConverter) Convert(src, target string, opts Options) error {
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
+
+// ConvertContext is Convert with cancellation; ffmpeg is killed when ctx is done.
+func (c *AudioConverter) ConvertContext(ctx context.Context, src, target string, opts Options) error {
 	// Check if ffmpeg is installed
-	_, err := exec.LookPath("ffmpeg")
-	if err != nil {
-		return fmt.Errorf("ffmpeg not found: please install ffmpeg to convert audio (https://ffmpeg.org)")
+	if err := lookTool(ctx, "ffmpeg", "ffmpeg to convert audio (https://ffmpeg.org)"); err != nil {
+		return err
 	}
 
 	// Parse quality setting
 	quality := parseAudioQuality(opts)
 
 	// Build ffmpeg arguments
-	args := buildAudioFFmpegArgs(src, target, quality)
+	args := buildAudioFFmpegArgs(absPath(src), absPath(target), quality, Threads(ctx))
 
 	// Execute ffmpeg
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
-	}
-
-	return nil
+	return runFFmpeg(ctx, src, args)
 }
 
 // audioQuality holds audio encoding parameters
@@ -70,25 +85,57 @@ func parseAudioQuality(opts Options) audioQuality {
 		sampleRate: "44100", // CD quality
 	}
 
-	if qualityStr, ok := opts["quality"].(string); ok {
-		switch {
-		case strings.Contains(qualityStr, "High"):
-			q.bitrate = "320k"
-			q.sampleRate = "48000"
-		case strings.Contains(qualityStr, "Balanced"), strings.Contains(qualityStr, "Medium"):
-			q.bitrate = "192k"
-			q.sampleRate = "44100"
-		case strings.Contains(qualityStr, "Compact"), strings.Contains(qualityStr, "Low"):
-			q.bitrate = "128k"
-			q.sampleRate = "44100"
-		}
+	switch QualityOf(opts) {
+	case QualityHigh:
+		q.bitrate = "320k"
+		q.sampleRate = "48000"
+	case QualityBalanced:
+		q.bitrate = "192k"
+		q.sampleRate = "44100"
+	case QualityCompact:
+		q.bitrate = "128k"
+		q.sampleRate = "44100"
+	}
+
+	// Explicit settings override the preset
+	if br := stringOption(opts, "bitrate"); br != "" {
+		q.bitrate = br
+	}
+	if rate := stringOption(opts, "sampleRate"); rate != "" {
+		q.sampleRate = rate
 	}
 
 	return q
 }
 
-// buildAudioFFmpegArgs constructs optimized ffmpeg arguments for audio
-func buildAudioFFmpegArgs(src, target string, quality audioQuality) []string {
+// audioBitrates are the bitrates offered for lossy audio.
+var audioBitrates = []string{"96k", "128k", "160k", "192k", "256k", "320k"}
+
+// OptionSpecs declares the quality preset and the encoder settings it picks.
+// Lossless targets have no bitrate.
+func (c *AudioConverter) OptionSpecs(srcExt, targetExt string) []OptionSpec {
+	specs := []OptionSpec{qualityOption}
+	switch NormalizeExt(targetExt) {
+	case ".flac", ".wav":
+	default:
+		specs = append(specs, OptionSpec{
+			Name:        "bitrate",
+			Type:        OptionEnum,
+			Description: "Audio bitrate",
+			Choices:     audioBitrates,
+		})
+	}
+	return append(specs, OptionSpec{
+		Name:        "sampleRate",
+		Type:        OptionEnum,
+		Description: "Sample rate in Hz",
+		Choices:     []string{"22050", "44100", "48000"},
+	})
+}
+
+// buildAudioFFmpegArgs constructs optimized ffmpeg arguments for audio,
+// encoding with at most threads threads
+func buildAudioFFmpegArgs(src, target string, quality audioQuality, threads int) []string {
 	targetLower := strings.ToLower(target)
 
 	// Base arguments
@@ -97,7 +144,7 @@ func buildAudioFFmpegArgs(src, target string, quality audioQuality) []string {
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", src,
-		"-threads", fmt.Sprintf("%d", runtime.NumCPU()),
+		"-threads", strconv.Itoa(threads),
 	}
 
 	// Format-specific encoding
@@ -195,7 +195,7 @@ func TestBuildAudioFFmpegArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			args := buildAudioFFmpegArgs(tt.src, tt.target, q)
+			args := buildAudioFFmpegArgs(tt.src, tt.target, q, 2)
 			if !tt.check(args) {
 				t.Errorf("buildAudioFFmpegArgs() args = %v, failed check", args)
 			}
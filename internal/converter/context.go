@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConvertContext runs c's conversion under ctx. Options are first validated
+// against the schema c declares. Converters implementing ContextConverter are
+// interrupted mid-way; others are only checked before they start and after
+// they finish.
+//
+// The converter writes to a hidden temporary file next to target, which is
+// renamed over target only once the conversion succeeds. A failed or
+// cancelled conversion therefore never leaves a truncated file behind nor
+// touches an existing target. Under WithVerification the temporary file must
+// also pass Verify. Cancellation returns an error wrapping both ErrCancelled
+// and ctx.Err().
+func ConvertContext(ctx context.Context, c Converter, src, target string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	if err := ValidateOptions(OptionSpecsFor(c, filepath.Ext(src), filepath.Ext(target)), opts); err != nil {
+		return err
+	}
+
+	tmp, err := TempTarget(target)
+	if err != nil {
+		return err
+	}
+
+	if cc, ok := c.(ContextConverter); ok {
+		err = cc.ConvertContext(ctx, src, tmp, opts)
+	} else {
+		err = c.Convert(src, tmp, opts)
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%w: %w", ErrCancelled, ctxErr)
+	}
+	if err == nil && verifying(ctx) {
+		err = Verify(ctx, src, tmp)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return commitTarget(tmp, target)
+}
+
+// TempTarget reserves a hidden temporary path in target's directory with the
+// same extension, so tools that pick the output format by extension still see
+// the right one. The path does not exist when TempTarget returns.
+func TempTarget(target string) (string, error) {
+	dir, base := filepath.Split(target)
+	ext := filepath.Ext(base)
+	pattern := "." + strings.TrimSuffix(base, ext) + ".golter-*" + ext
+
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp output: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	// Let the converter create the file itself so it gets the usual permissions
+	os.Remove(name)
+	return name, nil
+}
+
+// PartialOutputs returns the temporary files TempTarget reserved for target
+// that are still on disk, left behind by conversions that never finished,
+// e.g. because golter was killed.
+func PartialOutputs(target string) []string {
+	dir, base := filepath.Split(target)
+	ext := filepath.Ext(base)
+	prefix := "." + strings.TrimSuffix(base, ext) + ".golter-"
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var partial []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ext) && len(name) > len(prefix)+len(ext) {
+			partial = append(partial, filepath.Join(dir, name))
+		}
+	}
+	return partial
+}
+
+// commitTarget atomically moves the finished output at tmp to target. A
+// converter that reported success without producing a file fails, so an
+// existing target is never mistaken for its output.
+func commitTarget(tmp, target string) error {
+	if _, err := os.Stat(tmp); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: converter produced no output", ErrEncode)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to move output into place: %w", err)
+	}
+	return nil
+}
+
+// CopyFile copies the contents of src to a new or truncated file at dst.
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(src), err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create copy: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", filepath.Base(src), err)
+	}
+	return out.Close()
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := TempTarget(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return commitTarget(tmp, path)
+}
@@ -0,0 +1,164 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertContext_CancelledBeforeStart(t *testing.T) {
+	called := false
+	c := &MockConverter{convertFunc: func(src, target string, opts Options) error {
+		called = true
+		return nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ConvertContext(ctx, c, "in.a", "out.b", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("conversion should not start after cancellation")
+	}
+}
+
+func TestConvertContext_RemovesPartialOutput(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "out.b")
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &MockConverter{convertFunc: func(src, target string, opts Options) error {
+		if err := os.WriteFile(target, []byte("partial"), 0644); err != nil {
+			return err
+		}
+		cancel()
+		return nil
+	}}
+
+	err := ConvertContext(ctx, c, "in.a", target, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+		t.Errorf("expected partial output to be removed, stat err = %v", statErr)
+	}
+	assertNoTempOutputs(t, filepath.Dir(target))
+}
+
+func TestConvertContext_WritesThroughTempFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "out.b")
+	var written string
+	c := &MockConverter{convertFunc: func(src, tmp string, opts Options) error {
+		written = tmp
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			t.Errorf("target should not exist while converting, stat err = %v", err)
+		}
+		return os.WriteFile(tmp, []byte("done"), 0644)
+	}}
+
+	if err := ConvertContext(context.Background(), c, "in.a", target, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Dir(written) != dir || filepath.Ext(written) != ".b" || written == target {
+		t.Errorf("expected a temp sibling with the target extension, got %q", written)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "done" {
+		t.Errorf("expected output to be moved into place, got %q, %v", data, err)
+	}
+	assertNoTempOutputs(t, dir)
+}
+
+func TestConvertContext_FailureKeepsExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "out.b")
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &MockConverter{convertFunc: func(src, tmp string, opts Options) error {
+		os.WriteFile(tmp, []byte("trunc"), 0644)
+		return errors.New("boom")
+	}}
+
+	if err := ConvertContext(context.Background(), c, "in.a", target, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "original" {
+		t.Errorf("existing target was modified: %q", data)
+	}
+	assertNoTempOutputs(t, dir)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	if err := WriteFileAtomic(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	assertNoTempOutputs(t, dir)
+}
+
+// assertNoTempOutputs fails if dir still holds temporary outputs.
+func assertNoTempOutputs(t *testing.T, dir string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.golter-*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary outputs left behind: %v", matches)
+	}
+}
+
+func TestConvertContext_Success(t *testing.T) {
+	c := &MockConverter{convertFunc: func(src, tmp string, opts Options) error {
+		return os.WriteFile(tmp, []byte("done"), 0644)
+	}}
+	if err := ConvertContext(context.Background(), c, "in.a", filepath.Join(t.TempDir(), "out.b"), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConvertContext_NoOutput(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "out.b")
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &MockConverter{convertFunc: func(src, tmp string, opts Options) error {
+		return nil
+	}}
+
+	err := ConvertContext(context.Background(), c, "in.a", target, nil)
+	if !errors.Is(err, ErrEncode) {
+		t.Errorf("expected ErrEncode for a converter that wrote nothing, got %v", err)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "original" {
+		t.Errorf("existing target was modified: %q", data)
+	}
+}
+
+func TestPartialOutputs(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "clip.mp4")
+	tmp, err := TempTarget(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{tmp, target, filepath.Join(dir, ".other.golter-1.mp4"), filepath.Join(dir, ".clip.golter-1.mkv")} {
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := PartialOutputs(target)
+	if len(got) != 1 || got[0] != tmp {
+		t.Errorf("PartialOutputs = %v, want [%s]", got, tmp)
+	}
+}
@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"context"
+	"runtime"
+)
+
+// Cost classifies how much of the machine a conversion uses, so batches can
+// run many cheap conversions at once without oversubscribing the CPU with
+// expensive ones.
+type Cost string
+
+const (
+	// CostLight conversions run in-process and use about one core, such as
+	// data, image and in-memory document conversions.
+	CostLight Cost = "light"
+	// CostHeavy conversions run an external program that can use every
+	// core it is given, such as ffmpeg, Pandoc or Calibre.
+	CostHeavy Cost = "heavy"
+)
+
+// Costs lists every cost class, cheapest first.
+var Costs = []Cost{CostLight, CostHeavy}
+
+// CostOf returns c's cost for srcExt to targetExt: what a Coster reports,
+// otherwise heavy when the conversion runs an external program and light
+// when it does not.
+func CostOf(c Converter, srcExt, targetExt string) Cost {
+	if cc, ok := c.(Coster); ok {
+		if cost := cc.Cost(srcExt, targetExt); cost != "" {
+			return cost
+		}
+	}
+	if len(RequiredTools(c, srcExt, targetExt)) > 0 {
+		return CostHeavy
+	}
+	return CostLight
+}
+
+type threadsKey struct{}
+
+// WithThreads returns a context that limits conversions running under it to
+// n threads. External programs that take a thread count, such as ffmpeg,
+// are given n.
+func WithThreads(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, threadsKey{}, n)
+}
+
+// Threads returns the thread budget carried by ctx, or the number of CPUs
+// when there is none.
+func Threads(ctx context.Context) int {
+	if n, ok := ctx.Value(threadsKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+// cheapVideo is a video converter that declares itself light.
+type cheapVideo struct {
+	VideoConverter
+}
+
+func (c *cheapVideo) Cost(srcExt, targetExt string) Cost { return CostLight }
+
+func TestCostOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		conv     Converter
+		src, dst string
+		want     Cost
+	}{
+		{"in-process image", &ImageConverter{}, ".png", ".webp", CostLight},
+		{"in-process data", &DocumentConverter{}, ".json", ".yaml", CostLight},
+		{"ffmpeg", &VideoConverter{}, ".mov", ".mp4", CostHeavy},
+		{"pandoc", &DocumentConverter{}, ".md", ".docx", CostHeavy},
+		{"declared by the converter", &cheapVideo{}, ".mov", ".mp4", CostLight},
+		{"route with a heavy step", newChainConverter([]Step{
+			{Converter: &ImageConverter{}, Source: ".png", Target: ".gif"},
+			{Converter: &VideoConverter{}, Source: ".gif", Target: ".mp4"},
+		}), ".png", ".mp4", CostHeavy},
+	}
+	for _, tt := range tests {
+		if got := CostOf(tt.conv, tt.src, tt.dst); got != tt.want {
+			t.Errorf("%s: CostOf = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestThreads(t *testing.T) {
+	if got := Threads(context.Background()); got != runtime.NumCPU() {
+		t.Errorf("Threads without a budget = %d, want %d", got, runtime.NumCPU())
+	}
+	if got := Threads(WithThreads(context.Background(), 2)); got != 2 {
+		t.Errorf("Threads = %d, want 2", got)
+	}
+}
@@ -0,0 +1,155 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds each command run while probing a tool.
+const probeTimeout = 10 * time.Second
+
+// KnownTools are the external programs the built-in converters use.
+var KnownTools = []string{"ffmpeg", "ffprobe", "pandoc", "ebook-convert"}
+
+// Tool is the result of probing one external program.
+type Tool struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+	// Version is the first line the tool prints about its version.
+	Version string `json:"version,omitempty"`
+	// Encoders lists the encoders an ffmpeg build provides.
+	Encoders []string `json:"encoders,omitempty"`
+}
+
+// Capabilities records which external tools, and which ffmpeg encoders, are
+// installed.
+type Capabilities struct {
+	Tools []Tool `json:"tools"`
+}
+
+// Tool returns the probe result for name.
+func (c *Capabilities) Tool(name string) (Tool, bool) {
+	i := slices.IndexFunc(c.Tools, func(t Tool) bool { return t.Name == name })
+	if i < 0 {
+		return Tool{}, false
+	}
+	return c.Tools[i], true
+}
+
+// HasTool reports whether name was found. Tools that were not probed count as
+// available, so plugins and new converters are never hidden by mistake.
+func (c *Capabilities) HasTool(name string) bool {
+	t, ok := c.Tool(name)
+	return !ok || t.Available
+}
+
+// HasEncoder reports whether the probed ffmpeg provides encoder. When the
+// encoders could not be listed, e.g. because the probe timed out, every
+// encoder counts as available, as for tools that were not probed.
+func (c *Capabilities) HasEncoder(encoder string) bool {
+	t, ok := c.Tool("ffmpeg")
+	if !ok {
+		return true
+	}
+	if !t.Available {
+		return false
+	}
+	return len(t.Encoders) == 0 || slices.Contains(t.Encoders, encoder)
+}
+
+// Missing returns what conv lacks to convert srcExt to targetExt: tool names,
+// and "<name> encoder" for ffmpeg encoders. Encoders are only checked when
+// ffmpeg itself is installed.
+func (c *Capabilities) Missing(conv Converter, srcExt, targetExt string) []string {
+	var missing []string
+	for _, tool := range RequiredTools(conv, srcExt, targetExt) {
+		if !c.HasTool(tool) {
+			missing = append(missing, tool)
+		}
+	}
+	if slices.Contains(missing, "ffmpeg") {
+		return missing
+	}
+	for _, enc := range RequiredEncoders(conv, srcExt, targetExt) {
+		if !c.HasEncoder(enc) {
+			missing = append(missing, enc+" encoder")
+		}
+	}
+	return missing
+}
+
+// ProbeTools looks up each named program with the Runner carried by ctx and
+// asks it for its version. For ffmpeg the available encoders are listed too.
+func ProbeTools(ctx context.Context, names ...string) *Capabilities {
+	caps := &Capabilities{}
+	for _, name := range names {
+		caps.Tools = append(caps.Tools, probeTool(ctx, name))
+	}
+	return caps
+}
+
+func probeTool(ctx context.Context, name string) Tool {
+	t := Tool{Name: name}
+	path, err := RunnerFrom(ctx).LookPath(name)
+	if err != nil {
+		return t
+	}
+	t.Path = path
+	t.Available = true
+
+	versionFlag := "--version"
+	if name == "ffmpeg" || name == "ffprobe" {
+		versionFlag = "-version"
+	}
+	if out, err := runProbe(ctx, name, versionFlag); err == nil {
+		line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		t.Version = strings.TrimSpace(line)
+	}
+	if name == "ffmpeg" {
+		if out, err := runProbe(ctx, name, "-hide_banner", "-encoders"); err == nil {
+			t.Encoders = parseFFmpegEncoders(out)
+		}
+	}
+	return t
+}
+
+func runProbe(ctx context.Context, tool string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	_, err := RunnerFrom(ctx).Run(ctx, Command{Tool: tool, Args: args, Stdout: &out})
+	return out.Bytes(), err
+}
+
+// parseFFmpegEncoders reads the encoder names from "ffmpeg -encoders", whose
+// entries follow a " ------" separator as "<flags> <name> <description>".
+func parseFFmpegEncoders(out []byte) []string {
+	var encoders []string
+	listing := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !listing {
+			listing = strings.HasPrefix(line, "------")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			encoders = append(encoders, fields[1])
+		}
+	}
+	return encoders
+}
+
+// RequiredEncoders returns the ffmpeg encoders c needs for srcExt to targetExt.
+func RequiredEncoders(c Converter, srcExt, targetExt string) []string {
+	if er, ok := c.(EncoderRequirer); ok {
+		return er.RequiredEncoders(srcExt, targetExt)
+	}
+	return nil
+}
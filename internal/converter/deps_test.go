@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseFFmpegEncoders(t *testing.T) {
+	out := []byte(`Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC (codec h264)
+ V....D libvpx-vp9           libvpx VP9 (codec vp9)
+ A....D aac                  AAC (Advanced Audio Coding)
+`)
+	want := []string{"libx264", "libvpx-vp9", "aac"}
+	if got := parseFFmpegEncoders(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFFmpegEncoders = %v, want %v", got, want)
+	}
+}
+
+func TestProbeTools_Missing(t *testing.T) {
+	caps := ProbeTools(context.Background(), "golter-no-such-tool")
+	tool, ok := caps.Tool("golter-no-such-tool")
+	if !ok || tool.Available || tool.Path != "" {
+		t.Errorf("expected an unavailable tool, got %+v", tool)
+	}
+	if caps.HasTool("golter-no-such-tool") {
+		t.Error("HasTool should be false for a missing tool")
+	}
+	if !caps.HasTool("never-probed") {
+		t.Error("tools that were not probed should count as available")
+	}
+}
+
+func TestCapabilities_Missing(t *testing.T) {
+	video := &VideoConverter{}
+
+	noFFmpeg := &Capabilities{Tools: []Tool{{Name: "ffmpeg"}}}
+	if got := noFFmpeg.Missing(video, ".mp4", ".mkv"); !reflect.DeepEqual(got, []string{"ffmpeg"}) {
+		t.Errorf("Missing without ffmpeg = %v", got)
+	}
+
+	partial := &Capabilities{Tools: []Tool{{Name: "ffmpeg", Available: true, Encoders: []string{"libx264", "aac"}}}}
+	if got := partial.Missing(video, ".mkv", ".mp4"); len(got) != 0 {
+		t.Errorf("expected .mp4 to be available, missing %v", got)
+	}
+	if got := partial.Missing(video, ".mp4", ".mkv"); !reflect.DeepEqual(got, []string{"libx265 encoder"}) {
+		t.Errorf("Missing for .mkv = %v", got)
+	}
+	if got := partial.Missing(&ImageConverter{}, ".png", ".jpg"); len(got) != 0 {
+		t.Errorf("in-process conversions need nothing, got %v", got)
+	}
+
+	unlisted := &Capabilities{Tools: []Tool{{Name: "ffmpeg", Available: true}}}
+	if got := unlisted.Missing(video, ".mp4", ".mkv"); len(got) != 0 {
+		t.Errorf("expected encoders that could not be listed to count as available, missing %v", got)
+	}
+}
+
+func TestManager_HidesUnavailableTargets(t *testing.T) {
+	m := NewDefaultManager()
+	if !slices.Contains(m.GetSupportedTargetFormats(".mp4"), ".mkv") {
+		t.Fatal("expected .mkv before probing")
+	}
+
+	m.SetCapabilities(&Capabilities{Tools: []Tool{{Name: "ffmpeg", Available: true, Encoders: []string{"libx264", "aac"}}}})
+	targets := m.GetSupportedTargetFormats(".mp4")
+	if slices.Contains(targets, ".mkv") || !slices.Contains(targets, ".mov") {
+		t.Errorf("expected .mkv hidden and .mov offered, got %v", targets)
+	}
+
+	// Unavailable conversions are still found, so they fail with a clear error
+	if _, err := m.FindConverter(".mp4", ".mkv"); err != nil {
+		t.Errorf("FindConverter should still resolve .mkv: %v", err)
+	}
+
+	hidden := m.UnavailableTargets(".mp4")
+	if !reflect.DeepEqual(hidden[".mkv"], []string{"libx265 encoder"}) || len(hidden[".mov"]) != 0 {
+		t.Errorf("unexpected unavailable targets: %v", hidden)
+	}
+
+	var found bool
+	for _, c := range m.Conversions() {
+		if c.Source == ".mp4" && c.Target == ".mkv" {
+			found = true
+			if !reflect.DeepEqual(c.Missing, []string{"libx265 encoder"}) {
+				t.Errorf("expected .mkv to be marked, got %+v", c)
+			}
+		}
+	}
+	if !found {
+		t.Error("Conversions should list unavailable pairs")
+	}
+}
+
+func TestManager_RoutesAroundMissingTools(t *testing.T) {
+	m := NewManager()
+	m.Register(&toolConverter{appendConverter("Tool", "t", map[string][]string{".a": {".c"}})})
+	m.Register(appendConverter("AB", "b", map[string][]string{".a": {".b"}}))
+	m.Register(appendConverter("BC", "c", map[string][]string{".b": {".c"}}))
+
+	if steps, _ := m.FindRoute(".a", ".c"); len(steps) != 1 {
+		t.Fatalf("expected the direct conversion before probing, got %+v", steps)
+	}
+
+	m.SetCapabilities(&Capabilities{Tools: []Tool{{Name: "tool"}}})
+	steps, err := m.FindRoute(".a", ".c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Target != ".b" {
+		t.Errorf("expected a route via .b, got %+v", steps)
+	}
+}
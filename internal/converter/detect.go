@@ -0,0 +1,288 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// sniffLen is how much of a file is read to detect its format.
+const sniffLen = 4096
+
+// Detection compares a file's extension with the format found in its content.
+type Detection struct {
+	// Ext is the normalized extension from the file name, empty when missing.
+	Ext string
+	// Detected is the extension matching the content, empty when unknown.
+	Detected string
+}
+
+// Format returns the extension the file should be converted as: the detected
+// one when the content disagrees with the name, otherwise the name's.
+func (d Detection) Format() string {
+	if d.Detected == "" || sameFamily(d.Ext, d.Detected) {
+		return d.Ext
+	}
+	return d.Detected
+}
+
+// Mismatch reports whether the file has an extension that its content contradicts.
+func (d Detection) Mismatch() bool {
+	return d.Ext != "" && d.Detected != "" && !sameFamily(d.Ext, d.Detected)
+}
+
+// Warning describes a mismatch between extension and content, or returns "".
+func (d Detection) Warning() string {
+	if !d.Mismatch() {
+		return ""
+	}
+	return fmt.Sprintf("extension %s does not match content, converting as %s", d.Ext, d.Detected)
+}
+
+// families groups extensions that share a container, so naming a file with any
+// of them is not a mismatch.
+var families = [][]string{
+	{".jpg", ".jpeg"},
+	{".tif", ".tiff"},
+	{".htm", ".html"},
+	{".mp4", ".m4v", ".m4a", ".mov", ".3gp"},
+	{".mkv", ".webm"},
+	{".ogg", ".oga", ".ogv", ".opus"},
+}
+
+func sameFamily(a, b string) bool {
+	if a == b {
+		return true
+	}
+	for _, f := range families {
+		if slices.Contains(f, a) && slices.Contains(f, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectFormat returns the extension matching the magic bytes at the start of
+// a file, or "" when the format is not recognized. header should hold at least
+// the first few hundred bytes; ZIP-based formats are only told apart when the
+// names of their entries appear in it.
+func DetectFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return ".png"
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return ".jpg"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return ".gif"
+	case bytes.HasPrefix(header, []byte("BM")) && len(header) >= 18 && isDIBHeaderSize(le32(header[14:])):
+		return ".bmp"
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return ".tiff"
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		return ".pdf"
+	case bytes.HasPrefix(header, []byte("RIFF")) && len(header) >= 12:
+		switch string(header[8:12]) {
+		case "WEBP":
+			return ".webp"
+		case "WAVE":
+			return ".wav"
+		case "AVI ":
+			return ".avi"
+		}
+	case len(header) >= 12 && string(header[4:8]) == "ftyp":
+		return ftypFormat(string(header[8:12]))
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		// EBML; the DocType element names the flavour
+		if bytes.Contains(header[:min(len(header), 64)], []byte("webm")) {
+			return ".webm"
+		}
+		return ".mkv"
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return ".flac"
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return ".ogg"
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return ".mp3"
+	case len(header) >= 2 && header[0] == 0xFF && (header[1]&0xF6) == 0xF0:
+		return ".aac" // ADTS
+	case isMPEGFrame(header):
+		return ".mp3"
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return zipFormat(zipNamesFromHeader(header))
+	case isHTML(header):
+		return ".html"
+	}
+	return ""
+}
+
+// isMPEGFrame reports whether header starts with a valid MPEG audio frame
+// header. Frame sync alone is too weak: the UTF-16LE byte order mark FF FE
+// matches it, so the reserved versions, layers, bitrates and sample rates
+// are ruled out too.
+func isMPEGFrame(header []byte) bool {
+	if len(header) < 3 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+	if header[1] == 0xFE || header[1] == 0xFF {
+		return false
+	}
+	version := header[1] >> 3 & 0x03
+	layer := header[1] >> 1 & 0x03
+	bitrate := header[2] >> 4
+	sampleRate := header[2] >> 2 & 0x03
+	return version != 0x01 && layer != 0x00 && bitrate != 0x00 && bitrate != 0x0F && sampleRate != 0x03
+}
+
+// ftypFormat maps an ISO base media major brand to an extension.
+func ftypFormat(brand string) string {
+	switch brand {
+	case "qt  ":
+		return ".mov"
+	case "M4A ", "M4B ":
+		return ".m4a"
+	case "heic", "heix", "mif1", "avif":
+		return "" // still images, not supported
+	default:
+		return ".mp4"
+	}
+}
+
+// zipFormat tells EPUB and Office Open XML packages apart by their entries.
+// Plain ZIP archives are reported as unknown.
+func zipFormat(names []string) string {
+	for _, name := range names {
+		switch {
+		case name == "META-INF/container.xml":
+			return ".epub"
+		case strings.HasPrefix(name, "word/"):
+			return ".docx"
+		case strings.HasPrefix(name, "xl/"):
+			return ".xlsx"
+		case strings.HasPrefix(name, "ppt/"):
+			return ".pptx"
+		}
+	}
+	return ""
+}
+
+// zipNamesFromHeader reads entry names from the local file headers in b.
+func zipNamesFromHeader(b []byte) []string {
+	var names []string
+	for len(b) >= 30 && bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		flags := le16(b[6:])
+		compressed := int(le32(b[18:]))
+		nameLen := int(le16(b[26:]))
+		extraLen := int(le16(b[28:]))
+		if 30+nameLen > len(b) {
+			break
+		}
+		names = append(names, string(b[30:30+nameLen]))
+		next := 30 + nameLen + extraLen + compressed
+		// With a data descriptor the size is unknown until after the data
+		if flags&0x8 != 0 || next > len(b) {
+			break
+		}
+		b = b[next:]
+	}
+	return names
+}
+
+// isDIBHeaderSize reports whether n is the size of a known BMP info header.
+func isDIBHeaderSize(n uint32) bool {
+	switch n {
+	case 12, 40, 52, 56, 108, 124:
+		return true
+	}
+	return false
+}
+
+func le16(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// isHTML reports whether b starts with an HTML doctype or root element.
+func isHTML(b []byte) bool {
+	b = bytes.TrimPrefix(b, []byte("\xEF\xBB\xBF"))
+	b = bytes.TrimLeft(b, " \t\r\n")
+	lower := bytes.ToLower(b[:min(len(b), 15)])
+	return bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html"))
+}
+
+// DetectFile sniffs the file at path and compares the result with its extension.
+func DetectFile(path string) (Detection, error) {
+	d := Detection{Ext: NormalizeExt(filepath.Ext(path))}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return d, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return d, fmt.Errorf("failed to stat file: %w", err)
+	}
+	d.Detected, err = DetectReaderAt(f, info.Size())
+	return d, err
+}
+
+// DetectReaderAt is DetectFormat for size bytes readable from r. Unlike
+// DetectFormat it can read the ZIP central directory, so packages whose
+// telling entries are not at the start are still recognized.
+func DetectReaderAt(r io.ReaderAt, size int64) (string, error) {
+	header := make([]byte, min(size, sniffLen))
+	n, err := r.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	header = header[:n]
+
+	format := DetectFormat(header)
+	if format == "" && bytes.HasPrefix(header, []byte("PK\x03\x04")) {
+		if zr, err := zip.NewReader(r, size); err == nil {
+			names := make([]string, 0, len(zr.File))
+			for _, zf := range zr.File {
+				names = append(names, zf.Name)
+			}
+			format = zipFormat(names)
+		}
+	}
+	return format, nil
+}
+
+// StageSource makes the file at path available under the extension ext, so
+// converters that pick their decoder by extension see the real format. When
+// path already has that extension it is returned as is. Otherwise the file is
+// linked, or copied, into a temporary directory that cleanup removes.
+func StageSource(path, ext string) (string, func(), error) {
+	if NormalizeExt(filepath.Ext(path)) == ext {
+		return path, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "golter_source")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	base := filepath.Base(path)
+	staged := filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+ext)
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		err = os.Symlink(abs, staged)
+	}
+	if err != nil {
+		if err := CopyFile(path, staged); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+	return staged, cleanup, nil
+}
@@ -0,0 +1,185 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00"), ".png"},
+		{"JPEG", []byte{0xFF, 0xD8, 0xFF, 0xE0}, ".jpg"},
+		{"GIF", []byte("GIF89a\x01\x00"), ".gif"},
+		{"WebP", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), ".webp"},
+		{"WAV", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ".wav"},
+		{"PDF", []byte("%PDF-1.7\n"), ".pdf"},
+		{"MP4", []byte("\x00\x00\x00\x18ftypisom"), ".mp4"},
+		{"MOV", []byte("\x00\x00\x00\x14ftypqt  "), ".mov"},
+		{"M4A", []byte("\x00\x00\x00\x1cftypM4A "), ".m4a"},
+		{"MKV", []byte("\x1a\x45\xdf\xa3\x42\x82\x88matroska"), ".mkv"},
+		{"WebM", []byte("\x1a\x45\xdf\xa3\x42\x82\x84webm"), ".webm"},
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22"), ".flac"},
+		{"Ogg", []byte("OggS\x00\x02"), ".ogg"},
+		{"MP3 with ID3", []byte("ID3\x04\x00"), ".mp3"},
+		{"MP3 frame", []byte{0xFF, 0xFB, 0x90, 0x64}, ".mp3"},
+		{"MP3 frame with a reserved bitrate", []byte{0xFF, 0xFB, 0xF0, 0x64}, ""},
+		{"MP3 frame with a reserved sample rate", []byte{0xFF, 0xFB, 0x9C, 0x64}, ""},
+		{"MP3 frame with a reserved version", []byte{0xFF, 0xEB, 0x90, 0x64}, ""},
+		{"MP3 frame with a reserved layer", []byte{0xFF, 0xE1, 0x90, 0x64}, ""},
+		{"UTF-16LE BOM", []byte("\xFF\xFEn\x00a\x00m\x00e\x00,\x00"), ""},
+		{"UTF-16LE BOM with a frame-like byte", []byte{0xFF, 0xFE, 0x90, 0x00}, ""},
+		{"HTML", []byte("\n  <!DOCTYPE html><html>"), ".html"},
+		{"Text", []byte("name: golter\n"), ""},
+		{"Text starting with BM", []byte("BMW parts list, updated monthly\n"), ""},
+		{"Empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.header); got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeZip(t *testing.T, path string, names ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(bytes.Repeat([]byte("x"), 8192))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectFile_ZIPPackages(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		entries []string
+		want    string
+	}{
+		// Large first entries push the telling names past the sniffed header
+		{"report.docx", []string{"[Content_Types].xml", "word/document.xml"}, ".docx"},
+		{"sheet.xlsx", []string{"[Content_Types].xml", "xl/workbook.xml"}, ".xlsx"},
+		{"book.epub", []string{"mimetype", "META-INF/container.xml"}, ".epub"},
+		{"archive.zip", []string{"notes.txt"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			writeZip(t, path, tt.entries...)
+			d, err := DetectFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Detected != tt.want {
+				t.Errorf("Detected = %q, want %q", d.Detected, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetection(t *testing.T) {
+	tests := []struct {
+		name     string
+		d        Detection
+		format   string
+		mismatch bool
+	}{
+		{"Matching", Detection{Ext: ".png", Detected: ".png"}, ".png", false},
+		{"Same family", Detection{Ext: ".jpeg", Detected: ".jpg"}, ".jpeg", false},
+		{"Container family", Detection{Ext: ".m4a", Detected: ".mp4"}, ".m4a", false},
+		{"Wrong extension", Detection{Ext: ".jpg", Detected: ".png"}, ".png", true},
+		{"HTML saved as xls", Detection{Ext: ".xls", Detected: ".html"}, ".html", true},
+		{"No extension", Detection{Detected: ".pdf"}, ".pdf", false},
+		{"Unknown content", Detection{Ext: ".csv"}, ".csv", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Format(); got != tt.format {
+				t.Errorf("Format() = %q, want %q", got, tt.format)
+			}
+			if got := tt.d.Mismatch(); got != tt.mismatch {
+				t.Errorf("Mismatch() = %v, want %v", got, tt.mismatch)
+			}
+			if (tt.d.Warning() != "") != tt.mismatch {
+				t.Errorf("Warning() = %q, want a warning only on mismatch", tt.d.Warning())
+			}
+		})
+	}
+}
+
+func TestStageSource(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "download")
+	if err := os.WriteFile(src, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, cleanup, err := StageSource(src, ".pdf")
+	if err != nil {
+		t.Fatalf("StageSource failed: %v", err)
+	}
+	if filepath.Base(staged) != "download.pdf" {
+		t.Errorf("expected staged file named download.pdf, got %s", staged)
+	}
+	if data, err := os.ReadFile(staged); err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("staged file has content %q (err %v)", data, err)
+	}
+	cleanup()
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("expected cleanup to remove the staged file, stat err = %v", err)
+	}
+
+	same, cleanup, err := StageSource(filepath.Join(dir, "a.pdf"), ".pdf")
+	if err != nil || same != filepath.Join(dir, "a.pdf") {
+		t.Errorf("expected a path with the right extension to be used as is, got %q (err %v)", same, err)
+	}
+	cleanup()
+}
+
+func TestManager_FindConverterForFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewDefaultManager()
+	c, d, err := m.FindConverterForFile(path, ".webp")
+	if err != nil {
+		t.Fatalf("FindConverterForFile failed: %v", err)
+	}
+	if c.Name() != (&ImageConverter{}).Name() {
+		t.Errorf("expected the image converter, got %s", c.Name())
+	}
+	if d.Format() != ".png" || !d.Mismatch() {
+		t.Errorf("expected a .png mismatch, got %+v", d)
+	}
+}
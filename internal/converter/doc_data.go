@@ -2,9 +2,12 @@ package converter
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -34,8 +37,8 @@ func (c *DocDataConverter) isSupported(ext string) bool {
 }
 
 func (c *DocDataConverter) CanConvert(srcExt, targetExt string) bool {
-	srcExt = normalizeExt(srcExt)
-	targetExt = normalizeExt(targetExt)
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
 
 	if !c.isSupported(srcExt) || !c.isSupported(targetExt) {
 		return false
@@ -64,7 +67,7 @@ func (c *DocDataConverter) SupportedSourceExtensions() []string {
 }
 
 func (c *DocDataConverter) SupportedTargetFormats(srcExt string) []string {
-	srcExt = normalizeExt(srcExt)
+	srcExt = NormalizeExt(srcExt)
 	switch srcExt {
 	case ".json":
 		return []string{".yaml", ".yml", ".xml", ".csv", ".xlsx", ".xls"}
@@ -83,61 +86,95 @@ func (c *DocDataConverter) SupportedTargetFormats(srcExt string) []string {
 }
 
 func (c *DocDataConverter) Convert(src, target string, opts Options) error {
-	_ = opts
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
 
-	srcExt := normalizeExt(filepath.Ext(src))
-	targetExt := normalizeExt(filepath.Ext(target))
+// ConvertContext is Convert with progress reported as the share of the source read.
+func (c *DocDataConverter) ConvertContext(ctx context.Context, src, target string, opts Options) error {
+	srcExt := NormalizeExt(filepath.Ext(src))
+	targetExt := NormalizeExt(filepath.Ext(target))
 
-	if _, err := os.Stat(src); os.IsNotExist(err) {
-		return fmt.Errorf("source file not found: %s", src)
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		return sourceNotFound(src)
+	}
+	if !c.CanConvert(srcExt, targetExt) {
+		return unsupported("unsupported conversion: %s to %s", srcExt, targetExt)
 	}
 
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s file: %w", strings.TrimPrefix(srcExt, "."), err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if info != nil {
+		r = newProgressReader(ctx, in, info.Size())
+	}
+
+	// Buffer the output so a failed conversion leaves no file behind
+	var out bytes.Buffer
+	if err := c.ConvertStream(r, &out, srcExt, targetExt, opts); err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(target, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s file: %w", strings.TrimPrefix(targetExt, "."), err)
+	}
+
+	return nil
+}
+
+// CanStream reports true for every pair; all data conversions run in memory.
+func (c *DocDataConverter) CanStream(srcExt, targetExt string) bool {
+	return c.CanConvert(srcExt, targetExt)
+}
+
+func (c *DocDataConverter) ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	_ = opts
+
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+
 	switch srcExt {
 	case ".json":
 		switch targetExt {
 		case ".yaml", ".yml":
-			return c.convertJSONToYAML(src, target)
+			return c.convertJSONToYAML(r, w)
 		case ".xml":
-			return c.convertJSONToXML(src, target)
+			return c.convertJSONToXML(r, w)
 		case ".csv":
-			return c.convertJSONToCSV(src, target)
+			return c.convertJSONToCSV(r, w)
 		case ".xlsx", ".xls":
-			return c.convertJSONToExcel(src, target)
+			return c.convertJSONToExcel(r, w)
 		}
 	case ".yaml", ".yml":
 		switch targetExt {
 		case ".json":
-			return c.convertYAMLToJSON(src, target)
+			return c.convertYAMLToJSON(r, w)
 		case ".toml":
-			return c.convertYAMLToTOML(src, target)
+			return c.convertYAMLToTOML(r, w)
 		}
 	case ".toml":
 		if targetExt == ".yaml" || targetExt == ".yml" {
-			return c.convertTOMLToYAML(src, target)
+			return c.convertTOMLToYAML(r, w)
 		}
 	case ".xml":
 		if targetExt == ".json" {
-			return c.convertXMLToJSON(src, target)
+			return c.convertXMLToJSON(r, w)
 		}
 	case ".csv":
 		if targetExt == ".json" {
-			return c.convertCSVToJSON(src, target)
+			return c.convertCSVToJSON(r, w)
 		}
 	case ".xlsx", ".xls":
 		if targetExt == ".json" {
-			return c.convertExcelToJSON(src, target)
+			return c.convertExcelToJSON(r, w)
 		}
 	}
 
-	return fmt.Errorf("unsupported conversion: %s to %s", srcExt, targetExt)
-}
-
-func normalizeExt(ext string) string {
-	ext = strings.ToLower(ext)
-	if ext != "" && !strings.HasPrefix(ext, ".") {
-		ext = "." + ext
-	}
-	return ext
+	return unsupported("unsupported conversion: %s to %s", srcExt, targetExt)
 }
 
 func normalizeValue(value interface{}) interface{} {
@@ -165,111 +202,111 @@ func normalizeValue(value interface{}) interface{} {
 	}
 }
 
-func (c *DocDataConverter) convertJSONToYAML(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertJSONToYAML(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read json file: %w", err)
+		return fmt.Errorf("failed to read json: %w", err)
 	}
 
 	var payload interface{}
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse json: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse json: %w", err))
 	}
 
 	payload = normalizeValue(payload)
 	out, err := yaml.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal yaml: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal yaml: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write yaml file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write yaml: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertYAMLToJSON(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertYAMLToJSON(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read yaml file: %w", err)
+		return fmt.Errorf("failed to read yaml: %w", err)
 	}
 
 	var payload interface{}
 	if err := yaml.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse yaml: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse yaml: %w", err))
 	}
 
 	payload = normalizeValue(payload)
 	out, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal json: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write json file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertYAMLToTOML(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertYAMLToTOML(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read yaml file: %w", err)
+		return fmt.Errorf("failed to read yaml: %w", err)
 	}
 
 	var payload interface{}
 	if err := yaml.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse yaml: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse yaml: %w", err))
 	}
 
 	payload = normalizeValue(payload)
 	out, err := toml.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal toml: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal toml: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write toml file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write toml: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertTOMLToYAML(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertTOMLToYAML(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read toml file: %w", err)
+		return fmt.Errorf("failed to read toml: %w", err)
 	}
 
 	var payload map[string]interface{}
 	if err := toml.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse toml: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse toml: %w", err))
 	}
 
 	payload = normalizeValue(payload).(map[string]interface{})
 	out, err := yaml.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal yaml: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal yaml: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write yaml file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write yaml: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertJSONToXML(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertJSONToXML(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read json file: %w", err)
+		return fmt.Errorf("failed to read json: %w", err)
 	}
 
 	var payload interface{}
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse json: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse json: %w", err))
 	}
 
 	payload = normalizeValue(payload)
@@ -281,44 +318,44 @@ func (c *DocDataConverter) convertJSONToXML(src, target string) error {
 		xmlBytes, err = mxj.Map{"root": v}.XmlIndent("", "  ")
 	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal xml: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal xml: %w", err))
 	}
 
-	if err := os.WriteFile(target, xmlBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write xml file: %w", err)
+	if _, err := w.Write(xmlBytes); err != nil {
+		return fmt.Errorf("failed to write xml: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertXMLToJSON(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertXMLToJSON(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read xml file: %w", err)
+		return fmt.Errorf("failed to read xml: %w", err)
 	}
 
 	m, err := mxj.NewMapXml(data)
 	if err != nil {
-		return fmt.Errorf("failed to parse xml: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse xml: %w", err))
 	}
 
 	payload := normalizeValue(m)
 	out, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal json: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write json file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertJSONToCSV(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertJSONToCSV(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read json file: %w", err)
+		return fmt.Errorf("failed to read json: %w", err)
 	}
 
 	headers, rows, err := jsonToRows(data)
@@ -326,14 +363,7 @@ func (c *DocDataConverter) convertJSONToCSV(src, target string) error {
 		return err
 	}
 
-	file, err := os.Create(target)
-	if err != nil {
-		return fmt.Errorf("failed to create csv file: %w", err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	writer := csv.NewWriter(w)
 
 	if len(headers) > 0 {
 		if err := writer.Write(headers); err != nil {
@@ -347,26 +377,25 @@ func (c *DocDataConverter) convertJSONToCSV(src, target string) error {
 		}
 	}
 
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
 	return nil
 }
 
-func (c *DocDataConverter) convertCSVToJSON(src, target string) error {
-	file, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open csv file: %w", err)
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
+func (c *DocDataConverter) convertCSVToJSON(r io.Reader, w io.Writer) error {
+	reader := csv.NewReader(r)
 	reader.LazyQuotes = true
 	reader.TrimLeadingSpace = true
 
 	records, err := reader.ReadAll()
 	if err != nil {
-		return fmt.Errorf("failed to parse csv file: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse csv file: %w", err))
 	}
 	if len(records) == 0 {
-		return fmt.Errorf("csv file is empty")
+		return corruptInput(errors.New("csv file is empty"))
 	}
 
 	headers := normalizeHeaders(records[0])
@@ -385,20 +414,20 @@ func (c *DocDataConverter) convertCSVToJSON(src, target string) error {
 
 	out, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal json: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write json file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertJSONToExcel(src, target string) error {
-	data, err := os.ReadFile(src)
+func (c *DocDataConverter) convertJSONToExcel(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read json file: %w", err)
+		return fmt.Errorf("failed to read json: %w", err)
 	}
 
 	headers, rows, err := jsonToRows(data)
@@ -437,17 +466,17 @@ func (c *DocDataConverter) convertJSONToExcel(src, target string) error {
 		}
 	}
 
-	if err := f.SaveAs(target); err != nil {
-		return fmt.Errorf("failed to save excel file: %w", err)
+	if err := f.Write(w); err != nil {
+		return encodeFailure(fmt.Errorf("failed to write excel file: %w", err))
 	}
 
 	return nil
 }
 
-func (c *DocDataConverter) convertExcelToJSON(src, target string) error {
-	f, err := excelize.OpenFile(src)
+func (c *DocDataConverter) convertExcelToJSON(r io.Reader, w io.Writer) error {
+	f, err := excelize.OpenReader(r)
 	if err != nil {
-		return fmt.Errorf("failed to open excel file: %w", err)
+		return corruptInput(fmt.Errorf("failed to open excel file: %w", err))
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -457,15 +486,15 @@ func (c *DocDataConverter) convertExcelToJSON(src, target string) error {
 
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
-		return fmt.Errorf("excel file has no sheets")
+		return corruptInput(errors.New("excel file has no sheets"))
 	}
 
 	rows, err := f.GetRows(sheets[0])
 	if err != nil {
-		return fmt.Errorf("failed to read excel sheet: %w", err)
+		return corruptInput(fmt.Errorf("failed to read excel sheet: %w", err))
 	}
 	if len(rows) == 0 {
-		return fmt.Errorf("excel sheet is empty")
+		return corruptInput(errors.New("excel sheet is empty"))
 	}
 
 	headers := normalizeHeaders(rows[0])
@@ -484,11 +513,11 @@ func (c *DocDataConverter) convertExcelToJSON(src, target string) error {
 
 	out, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
+		return encodeFailure(fmt.Errorf("failed to marshal json: %w", err))
 	}
 
-	if err := os.WriteFile(target, out, 0644); err != nil {
-		return fmt.Errorf("failed to write json file: %w", err)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write json: %w", err)
 	}
 
 	return nil
@@ -497,7 +526,7 @@ func (c *DocDataConverter) convertExcelToJSON(src, target string) error {
 func jsonToRows(data []byte) ([]string, [][]string, error) {
 	var payload interface{}
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse json: %w", err)
+		return nil, nil, corruptInput(fmt.Errorf("failed to parse json: %w", err))
 	}
 
 	payload = normalizeValue(payload)
@@ -1,10 +1,11 @@
 package converter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -13,37 +14,30 @@ import (
 	"github.com/taylorskalyo/goreader/epub"
 )
 
-func (c *DocumentConverter) convertEbookToMarkdown(src, target string, opts Options) error {
+func (c *DocumentConverter) convertEbookToMarkdown(ctx context.Context, src, target string, opts Options) error {
 	tempHTML, cleanup, err := tempPathWithExt("golter_ebook_html", ".html")
 	if err != nil {
 		return err
 	}
 	defer cleanup()
 
-	if err := c.convertEbookWithCalibre(src, tempHTML, opts); err != nil {
+	if err := c.convertEbookWithCalibre(ctx, src, tempHTML, opts); err != nil {
 		return err
 	}
 
 	return c.convertHTMLToMarkdown(tempHTML, target)
 }
 
-func (c *DocumentConverter) convertEbookWithCalibre(src, target string, opts Options) error {
-	_, err := exec.LookPath("ebook-convert")
-	if err != nil {
-		return fmt.Errorf("ebook-convert not found: please install Calibre to convert ebook formats (https://calibre-ebook.com)")
+func (c *DocumentConverter) convertEbookWithCalibre(ctx context.Context, src, target string, opts Options) error {
+	if err := lookTool(ctx, "ebook-convert", "Calibre to convert ebook formats (https://calibre-ebook.com)"); err != nil {
+		return err
 	}
 
-	args := []string{src, target}
-	if extra, ok := opts["ebookArgs"].([]string); ok && len(extra) > 0 {
-		args = append(args, extra...)
-	} else if extraStr, ok := opts["ebookArgs"].(string); ok && strings.TrimSpace(extraStr) != "" {
-		args = append(args, strings.Fields(extraStr)...)
-	}
+	args := append([]string{absPath(src), absPath(target)}, argsOption(opts, "ebookArgs")...)
 
-	cmd := exec.Command("ebook-convert", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := RunnerFrom(ctx).Run(ctx, Command{Tool: "ebook-convert", Args: args})
 	if err != nil {
-		return fmt.Errorf("ebook-convert failed: %w\nOutput: %s", err, string(output))
+		return NewToolError("ebook-convert", err, output)
 	}
 
 	return nil
@@ -52,12 +46,12 @@ func (c *DocumentConverter) convertEbookWithCalibre(src, target string, opts Opt
 func (c *DocumentConverter) convertEPUBToMarkdown(src, target string) error {
 	rc, err := epub.OpenReader(src)
 	if err != nil {
-		return fmt.Errorf("failed to open EPUB: %w", err)
+		return corruptInput(fmt.Errorf("failed to open EPUB: %w", err))
 	}
 	defer rc.Close()
 
 	if len(rc.Rootfiles) == 0 {
-		return fmt.Errorf("no rootfiles found in EPUB")
+		return corruptInput(errors.New("no rootfiles found in EPUB"))
 	}
 
 	book := rc.Rootfiles[0]
@@ -92,7 +86,7 @@ func (c *DocumentConverter) convertEPUBToMarkdown(src, target string) error {
 		contentBuilder.WriteString("\n\n---\n\n")
 	}
 
-	if err := os.WriteFile(target, []byte(contentBuilder.String()), 0644); err != nil {
+	if err := WriteFileAtomic(target, []byte(contentBuilder.String()), 0644); err != nil {
 		return fmt.Errorf("failed to write markdown file: %w", err)
 	}
 
@@ -102,12 +96,12 @@ func (c *DocumentConverter) convertEPUBToMarkdown(src, target string) error {
 func (c *DocumentConverter) convertEPUBToHTML(src, target string) error {
 	rc, err := epub.OpenReader(src)
 	if err != nil {
-		return fmt.Errorf("failed to open EPUB: %w", err)
+		return corruptInput(fmt.Errorf("failed to open EPUB: %w", err))
 	}
 	defer rc.Close()
 
 	if len(rc.Rootfiles) == 0 {
-		return fmt.Errorf("no rootfiles found in EPUB")
+		return corruptInput(errors.New("no rootfiles found in EPUB"))
 	}
 
 	book := rc.Rootfiles[0]
@@ -142,7 +136,7 @@ func (c *DocumentConverter) convertEPUBToHTML(src, target string) error {
 
 	contentBuilder.WriteString("</body></html>")
 
-	if err := os.WriteFile(target, []byte(contentBuilder.String()), 0644); err != nil {
+	if err := WriteFileAtomic(target, []byte(contentBuilder.String()), 0644); err != nil {
 		return fmt.Errorf("failed to write HTML file: %w", err)
 	}
 
@@ -177,7 +171,7 @@ func (c *DocumentConverter) convertEPUBToPDF(src, target string) error {
 	html.Write(lineHt, string(source))
 
 	if err := pdfDoc.OutputFileAndClose(target); err != nil {
-		return fmt.Errorf("failed to create PDF: %w", err)
+		return encodeFailure(fmt.Errorf("failed to create PDF: %w", err))
 	}
 
 	return nil
@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -23,19 +24,27 @@ func (c *DocumentConverter) convertHTMLToMarkdown(src, target string) error {
 		return fmt.Errorf("failed to read HTML file: %w", err)
 	}
 
-	converter := md.NewConverter("", true, nil)
-	markdown, err := converter.ConvertString(string(b))
+	markdown, err := htmlToMarkdown(b)
 	if err != nil {
-		return fmt.Errorf("failed to convert HTML to markdown: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(target, []byte(markdown), 0644); err != nil {
+	if err := WriteFileAtomic(target, []byte(markdown), 0644); err != nil {
 		return fmt.Errorf("failed to write markdown file: %w", err)
 	}
 
 	return nil
 }
 
+func htmlToMarkdown(b []byte) (string, error) {
+	converter := md.NewConverter("", true, nil)
+	markdown, err := converter.ConvertString(string(b))
+	if err != nil {
+		return "", corruptInput(fmt.Errorf("failed to convert HTML to markdown: %w", err))
+	}
+	return markdown, nil
+}
+
 func (c *DocumentConverter) convertHTMLToEPUB(src, target string) error {
 	source, err := os.ReadFile(src)
 	if err != nil {
@@ -52,13 +61,13 @@ func (c *DocumentConverter) convertHTMLToEPUB(src, target string) error {
 	}
 
 	if err := e.Write(target); err != nil {
-		return fmt.Errorf("failed to write EPUB file: %w", err)
+		return encodeFailure(fmt.Errorf("failed to write EPUB file: %w", err))
 	}
 
 	return nil
 }
 
-func (c *DocumentConverter) convertHTMLToEbook(src, target string, opts Options) error {
+func (c *DocumentConverter) convertHTMLToEbook(ctx context.Context, src, target string, opts Options) error {
 	if strings.EqualFold(filepath.Ext(target), ".epub") {
 		return c.convertHTMLToEPUB(src, target)
 	}
@@ -73,5 +82,5 @@ func (c *DocumentConverter) convertHTMLToEbook(src, target string, opts Options)
 		return err
 	}
 
-	return c.convertEbookWithCalibre(tempEPUB, target, opts)
+	return c.convertEbookWithCalibre(ctx, tempEPUB, target, opts)
 }
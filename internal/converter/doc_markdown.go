@@ -2,6 +2,7 @@ package converter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,9 +19,23 @@ func (c *DocumentConverter) convertMarkdownToHTML(src, target string) error {
 		return fmt.Errorf("failed to read markdown file: %w", err)
 	}
 
+	html, err := renderMarkdownPage(source, filepath.Base(src))
+	if err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(target, html, 0644); err != nil {
+		return fmt.Errorf("failed to write HTML file: %w", err)
+	}
+
+	return nil
+}
+
+// renderMarkdownPage converts markdown into a standalone, styled HTML page.
+func renderMarkdownPage(source []byte, title string) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := goldmark.Convert(source, &buf); err != nil {
-		return fmt.Errorf("failed to convert markdown to HTML: %w", err)
+		return nil, fmt.Errorf("failed to convert markdown to HTML: %w", err)
 	}
 
 	// Wrap in basic HTML structure
@@ -56,13 +71,9 @@ func (c *DocumentConverter) convertMarkdownToHTML(src, target string) error {
 <body>
 %s
 </body>
-</html>`, filepath.Base(src), buf.String())
+</html>`, title, buf.String())
 
-	if err := os.WriteFile(target, []byte(html), 0644); err != nil {
-		return fmt.Errorf("failed to write HTML file: %w", err)
-	}
-
-	return nil
+	return []byte(html), nil
 }
 
 func (c *DocumentConverter) convertMarkdownToPDF(src, target string) error {
@@ -89,7 +100,7 @@ func (c *DocumentConverter) convertMarkdownToPDF(src, target string) error {
 	html.Write(lineHt, htmlBuf.String())
 
 	if err := pdfDoc.OutputFileAndClose(target); err != nil {
-		return fmt.Errorf("failed to create PDF: %w", err)
+		return encodeFailure(fmt.Errorf("failed to create PDF: %w", err))
 	}
 
 	return nil
@@ -118,13 +129,13 @@ func (c *DocumentConverter) convertMarkdownToEPUB(src, target string) error {
 	}
 
 	if err := e.Write(target); err != nil {
-		return fmt.Errorf("failed to write EPUB file: %w", err)
+		return encodeFailure(fmt.Errorf("failed to write EPUB file: %w", err))
 	}
 
 	return nil
 }
 
-func (c *DocumentConverter) convertMarkdownToEbook(src, target string, opts Options) error {
+func (c *DocumentConverter) convertMarkdownToEbook(ctx context.Context, src, target string, opts Options) error {
 	if strings.EqualFold(filepath.Ext(target), ".epub") {
 		return c.convertMarkdownToEPUB(src, target)
 	}
@@ -139,5 +150,5 @@ func (c *DocumentConverter) convertMarkdownToEbook(src, target string, opts Opti
 		return err
 	}
 
-	return c.convertEbookWithCalibre(tempEPUB, target, opts)
+	return c.convertEbookWithCalibre(ctx, tempEPUB, target, opts)
 }
@@ -1,32 +1,26 @@
 package converter
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/xuri/excelize/v2"
 )
 
-func (c *DocumentConverter) convertWithPandoc(src, target string, opts Options) error {
-	_, err := exec.LookPath("pandoc")
-	if err != nil {
-		return fmt.Errorf("pandoc not found: please install Pandoc to convert DOCX formats (https://pandoc.org)")
+func (c *DocumentConverter) convertWithPandoc(ctx context.Context, src, target string, opts Options) error {
+	if err := lookTool(ctx, "pandoc", "Pandoc to convert DOCX formats (https://pandoc.org)"); err != nil {
+		return err
 	}
 
-	args := []string{src, "-o", target}
-	if extra, ok := opts["pandocArgs"].([]string); ok && len(extra) > 0 {
-		args = append(args, extra...)
-	} else if extraStr, ok := opts["pandocArgs"].(string); ok && strings.TrimSpace(extraStr) != "" {
-		args = append(args, strings.Fields(extraStr)...)
-	}
+	args := append([]string{absPath(src), "-o", absPath(target)}, argsOption(opts, "pandocArgs")...)
 
-	cmd := exec.Command("pandoc", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := RunnerFrom(ctx).Run(ctx, Command{Tool: "pandoc", Args: args})
 	if err != nil {
-		return fmt.Errorf("pandoc failed: %w\nOutput: %s", err, string(output))
+		return NewToolError("pandoc", err, output)
 	}
 
 	return nil
@@ -48,11 +42,11 @@ func (c *DocumentConverter) convertCSVToExcel(src, target string) error {
 
 	records, err := reader.ReadAll()
 	if err != nil {
-		return fmt.Errorf("failed to parse CSV file: %w", err)
+		return corruptInput(fmt.Errorf("failed to parse CSV file: %w", err))
 	}
 
 	if len(records) == 0 {
-		return fmt.Errorf("CSV file is empty")
+		return corruptInput(errors.New("CSV file is empty"))
 	}
 
 	// Create new Excel file
@@ -116,7 +110,7 @@ func (c *DocumentConverter) convertCSVToExcel(src, target string) error {
 
 	// Save the Excel file
 	if err := f.SaveAs(target); err != nil {
-		return fmt.Errorf("failed to save Excel file: %w", err)
+		return encodeFailure(fmt.Errorf("failed to save Excel file: %w", err))
 	}
 
 	return nil
@@ -127,7 +121,7 @@ func (c *DocumentConverter) convertExcelToCSV(src, target string) error {
 	// Open Excel file
 	f, err := excelize.OpenFile(src)
 	if err != nil {
-		return fmt.Errorf("failed to open Excel file: %w", err)
+		return corruptInput(fmt.Errorf("failed to open Excel file: %w", err))
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -138,36 +132,35 @@ func (c *DocumentConverter) convertExcelToCSV(src, target string) error {
 	// Get the first sheet name
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
-		return fmt.Errorf("Excel file has no sheets")
+		return corruptInput(errors.New("Excel file has no sheets"))
 	}
 	sheetName := sheets[0]
 
 	// Get all rows from the first sheet
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return fmt.Errorf("failed to read Excel sheet: %w", err)
+		return corruptInput(fmt.Errorf("failed to read Excel sheet: %w", err))
 	}
 
 	if len(rows) == 0 {
-		return fmt.Errorf("Excel sheet is empty")
+		return corruptInput(errors.New("Excel sheet is empty"))
 	}
 
-	// Create CSV file
-	csvFile, err := os.Create(target)
-	if err != nil {
-		return fmt.Errorf("failed to create CSV file: %w", err)
-	}
-	defer csvFile.Close()
-
 	// Write CSV content
-	writer := csv.NewWriter(csvFile)
-	defer writer.Flush()
-
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
 	for _, row := range rows {
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write CSV row: %w", err)
 		}
 	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
 
+	if err := WriteFileAtomic(target, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
 	return nil
 }
@@ -2,8 +2,8 @@ package converter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/ledongthuc/pdf"
@@ -11,22 +11,34 @@ import (
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
-func (c *DocumentConverter) convertPDFToMarkdown(src, target string) error {
+func (c *DocumentConverter) convertPDFToMarkdown(ctx context.Context, src, target string) error {
 	f, r, err := pdf.Open(src)
 	if err != nil {
-		return fmt.Errorf("failed to open PDF: %w", err)
+		return corruptInput(fmt.Errorf("failed to open PDF: %w", err))
 	}
 	defer f.Close()
 
+	// Extract page by page (as Reader.GetPlainText does) to report progress
 	var buf bytes.Buffer
-	reader, err := r.GetPlainText()
-	if err != nil {
-		return fmt.Errorf("failed to extract text from PDF: %w", err)
-	}
-
-	_, err = buf.ReadFrom(reader)
-	if err != nil {
-		return fmt.Errorf("failed to read PDF content: %w", err)
+	pages := r.NumPage()
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= pages; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p := r.Page(i)
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := p.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := p.GetPlainText(fonts)
+		if err != nil {
+			return corruptInput(fmt.Errorf("failed to extract text from PDF: %w", err))
+		}
+		buf.WriteString(text)
+		ReportProgress(ctx, float64(i)/float64(pages))
 	}
 
 	// Add markdown formatting
@@ -38,7 +50,7 @@ func (c *DocumentConverter) convertPDFToMarkdown(src, target string) error {
 	// Basic markdown formatting
 	content = "# " + filepath.Base(src) + "\n\n" + content
 
-	if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+	if err := WriteFileAtomic(target, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write markdown file: %w", err)
 	}
 
@@ -50,7 +62,7 @@ func (c *DocumentConverter) compressPDF(src, target string) error {
 	conf.Cmd = model.OPTIMIZE
 
 	if err := api.OptimizeFile(src, target, conf); err != nil {
-		return fmt.Errorf("failed to compress PDF: %w", err)
+		return corruptInput(fmt.Errorf("failed to compress PDF: %w", err))
 	}
 
 	return nil
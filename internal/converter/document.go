@@ -1,7 +1,9 @@
 package converter
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -114,19 +116,78 @@ func (c *DocumentConverter) SupportedTargetFormats(srcExt string) []string {
 	return nil
 }
 
+// RequiredTools mirrors the dispatch in Convert: DOCX goes through Pandoc and
+// ebook formats other than EPUB go through Calibre's ebook-convert.
+func (c *DocumentConverter) RequiredTools(srcExt, targetExt string) []string {
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+
+	switch {
+	case srcExt == ".docx" || targetExt == ".docx":
+		return []string{"pandoc"}
+	case srcExt == ".md" || srcExt == ".html":
+		if isEbookExt(targetExt) && targetExt != ".epub" {
+			return []string{"ebook-convert"}
+		}
+	case srcExt == ".epub":
+		if isEbookExt(targetExt) || targetExt == ".txt" {
+			return []string{"ebook-convert"}
+		}
+	case isEbookExt(srcExt):
+		return []string{"ebook-convert"}
+	}
+	return nil
+}
+
+// OptionSpecs declares the extra arguments passed to Pandoc or Calibre, and
+// the page title for Markdown to HTML.
+func (c *DocumentConverter) OptionSpecs(srcExt, targetExt string) []OptionSpec {
+	var specs []OptionSpec
+	for _, tool := range c.RequiredTools(srcExt, targetExt) {
+		switch tool {
+		case "pandoc":
+			specs = append(specs, OptionSpec{
+				Name:        "pandocArgs",
+				Type:        OptionArgs,
+				Description: "Extra arguments passed to pandoc",
+			})
+		case "ebook-convert":
+			specs = append(specs, OptionSpec{
+				Name:        "ebookArgs",
+				Type:        OptionArgs,
+				Description: "Extra arguments passed to Calibre's ebook-convert",
+			})
+		}
+	}
+	if NormalizeExt(srcExt) == ".md" && NormalizeExt(targetExt) == ".html" {
+		specs = append(specs, OptionSpec{
+			Name:        "title",
+			Type:        OptionString,
+			Description: "Title of the generated HTML page",
+			Default:     "Document",
+		})
+	}
+	return specs
+}
+
 func (c *DocumentConverter) Convert(src, target string, opts Options) error {
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
+
+// ConvertContext is Convert with cancellation; Pandoc and Calibre are killed when ctx is done.
+func (c *DocumentConverter) ConvertContext(ctx context.Context, src, target string, opts Options) error {
 	srcExt := strings.ToLower(filepath.Ext(src))
 	targetExt := strings.ToLower(filepath.Ext(target))
 
 	// Validate source file exists
 	if _, err := os.Stat(src); os.IsNotExist(err) {
-		return fmt.Errorf("source file not found: %s", src)
+		return sourceNotFound(src)
 	}
 
 	switch srcExt {
 	case ".pdf":
 		if targetExt == ".md" {
-			return c.convertPDFToMarkdown(src, target)
+			return c.convertPDFToMarkdown(ctx, src, target)
 		} else if targetExt == ".pdf" {
 			return c.compressPDF(src, target)
 		}
@@ -136,25 +197,25 @@ func (c *DocumentConverter) Convert(src, target string, opts Options) error {
 		} else if targetExt == ".pdf" {
 			return c.convertMarkdownToPDF(src, target)
 		} else if targetExt == ".docx" {
-			return c.convertWithPandoc(src, target, opts)
+			return c.convertWithPandoc(ctx, src, target, opts)
 		} else if targetExt == ".epub" {
 			return c.convertMarkdownToEPUB(src, target)
 		} else if isEbookExt(targetExt) {
-			return c.convertMarkdownToEbook(src, target, opts)
+			return c.convertMarkdownToEbook(ctx, src, target, opts)
 		}
 	case ".html":
 		if targetExt == ".md" {
 			return c.convertHTMLToMarkdown(src, target)
 		} else if targetExt == ".docx" {
-			return c.convertWithPandoc(src, target, opts)
+			return c.convertWithPandoc(ctx, src, target, opts)
 		} else if targetExt == ".epub" {
 			return c.convertHTMLToEPUB(src, target)
 		} else if isEbookExt(targetExt) {
-			return c.convertHTMLToEbook(src, target, opts)
+			return c.convertHTMLToEbook(ctx, src, target, opts)
 		}
 	case ".docx":
 		if targetExt == ".md" || targetExt == ".html" || targetExt == ".txt" {
-			return c.convertWithPandoc(src, target, opts)
+			return c.convertWithPandoc(ctx, src, target, opts)
 		}
 	case ".csv":
 		if targetExt == ".xlsx" || targetExt == ".xls" {
@@ -175,20 +236,61 @@ func (c *DocumentConverter) Convert(src, target string, opts Options) error {
 		} else if targetExt == ".pdf" {
 			return c.convertEPUBToPDF(src, target)
 		} else if isEbookExt(targetExt) {
-			return c.convertEbookWithCalibre(src, target, opts)
+			return c.convertEbookWithCalibre(ctx, src, target, opts)
 		} else if targetExt == ".txt" {
-			return c.convertEbookWithCalibre(src, target, opts)
+			return c.convertEbookWithCalibre(ctx, src, target, opts)
 		}
 	case ".mobi", ".azw", ".azw3", ".fb2":
 		if targetExt == srcExt {
 			break
 		}
 		if targetExt == ".md" {
-			return c.convertEbookToMarkdown(src, target, opts)
+			return c.convertEbookToMarkdown(ctx, src, target, opts)
 		} else if targetExt == ".html" || targetExt == ".pdf" || targetExt == ".txt" || isEbookExt(targetExt) {
-			return c.convertEbookWithCalibre(src, target, opts)
+			return c.convertEbookWithCalibre(ctx, src, target, opts)
+		}
+	}
+
+	return unsupported("unsupported conversion: %s to %s", srcExt, targetExt)
+}
+
+// CanStream reports whether the pair is handled purely in memory (Markdown and HTML).
+func (c *DocumentConverter) CanStream(srcExt, targetExt string) bool {
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+	return (srcExt == ".md" && targetExt == ".html") || (srcExt == ".html" && targetExt == ".md")
+}
+
+func (c *DocumentConverter) ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+	if !c.CanStream(srcExt, targetExt) {
+		return unsupported("unsupported stream conversion: %s to %s", srcExt, targetExt)
+	}
+
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	var out []byte
+	if srcExt == ".md" {
+		title := "Document"
+		if t := stringOption(opts, "title"); t != "" {
+			title = t
 		}
+		out, err = renderMarkdownPage(source, title)
+	} else {
+		var markdown string
+		markdown, err = htmlToMarkdown(source)
+		out = []byte(markdown)
+	}
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("unsupported conversion: %s to %s", srcExt, targetExt)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
 }
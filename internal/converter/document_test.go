@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -285,3 +286,25 @@ func ensurePandocInPath(t *testing.T) bool {
 
 	return false
 }
+
+func TestDocumentConverter_RequiredTools(t *testing.T) {
+	c := &DocumentConverter{}
+	tests := []struct {
+		src, target string
+		want        []string
+	}{
+		{".md", ".docx", []string{"pandoc"}},
+		{".docx", ".md", []string{"pandoc"}},
+		{".md", ".epub", nil},
+		{".md", ".mobi", []string{"ebook-convert"}},
+		{".epub", ".md", nil},
+		{".epub", ".azw3", []string{"ebook-convert"}},
+		{".mobi", ".md", []string{"ebook-convert"}},
+		{".pdf", ".md", nil},
+	}
+	for _, tt := range tests {
+		if got := c.RequiredTools(tt.src, tt.target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RequiredTools(%s, %s) = %v, want %v", tt.src, tt.target, got, tt.want)
+		}
+	}
+}
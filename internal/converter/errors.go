@@ -0,0 +1,177 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Conversion errors wrap one of these sentinels, alongside ErrNoConverter,
+// ErrInvalidOption and ErrVerification, so callers can tell failures apart
+// with errors.Is without matching messages.
+var (
+	// ErrMissingTool is wrapped when an external program a conversion needs
+	// is not installed. See MissingToolError.
+	ErrMissingTool = errors.New("missing dependency")
+	// ErrCorruptInput is wrapped when the source cannot be read as the
+	// format its extension names.
+	ErrCorruptInput = errors.New("corrupt input")
+	// ErrEncode is wrapped when the output could not be produced from a
+	// source that was read successfully.
+	ErrEncode = errors.New("encode failed")
+	// ErrToolFailed is wrapped when an external program exits with an error.
+	// See ToolError.
+	ErrToolFailed = errors.New("external tool failed")
+	// ErrCancelled is wrapped, together with the context's error, when a
+	// conversion is cancelled.
+	ErrCancelled = errors.New("conversion cancelled")
+)
+
+// stderrTailLines and stderrTailBytes bound how much of a failed tool's
+// output ToolError keeps.
+const (
+	stderrTailLines = 20
+	stderrTailBytes = 4096
+)
+
+// MissingToolError reports an external program that is not installed.
+type MissingToolError struct {
+	Tool string
+	// Install says what to install and where to get it.
+	Install string
+}
+
+func (e *MissingToolError) Error() string {
+	return fmt.Sprintf("%s not found: please install %s", e.Tool, e.Install)
+}
+
+func (e *MissingToolError) Is(target error) bool {
+	return target == ErrMissingTool
+}
+
+// lookTool returns a MissingToolError when the Runner carried by ctx cannot
+// find tool.
+func lookTool(ctx context.Context, tool, install string) error {
+	if _, err := RunnerFrom(ctx).LookPath(tool); err != nil {
+		return &MissingToolError{Tool: tool, Install: install}
+	}
+	return nil
+}
+
+// ToolError reports an external program that failed.
+type ToolError struct {
+	Tool string
+	// ExitCode is the program's exit status, or -1 when it did not exit
+	// normally, e.g. it could not be started or was killed.
+	ExitCode int
+	// Stderr is the tail of what the program printed.
+	Stderr string
+	Err    error
+}
+
+// NewToolError wraps err from running tool, keeping the last lines of output.
+func NewToolError(tool string, err error, output []byte) *ToolError {
+	code := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+	return &ToolError{Tool: tool, ExitCode: code, Stderr: tail(string(output)), Err: err}
+}
+
+func (e *ToolError) Error() string {
+	msg := fmt.Sprintf("%s failed: %v", e.Tool, e.Err)
+	if e.Stderr != "" {
+		msg += "\nOutput: " + e.Stderr
+	}
+	return msg
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ToolError) Is(target error) bool {
+	return target == ErrToolFailed
+}
+
+// tail returns the last stderrTailLines lines of s, at most stderrTailBytes long.
+func tail(s string) string {
+	s = strings.TrimSpace(s)
+	lines := strings.Split(s, "\n")
+	if len(lines) > stderrTailLines {
+		s = strings.Join(lines[len(lines)-stderrTailLines:], "\n")
+	}
+	if len(s) > stderrTailBytes {
+		s = s[len(s)-stderrTailBytes:]
+	}
+	return s
+}
+
+// classified keeps err's message while also matching kind with errors.Is.
+type classified struct {
+	err  error
+	kind error
+}
+
+func (e *classified) Error() string   { return e.err.Error() }
+func (e *classified) Unwrap() []error { return []error{e.err, e.kind} }
+
+func classify(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classified{err: err, kind: kind}
+}
+
+// corruptInput marks err as caused by a source that cannot be read.
+func corruptInput(err error) error {
+	return classify(ErrCorruptInput, err)
+}
+
+// encodeFailure marks err as a failure to produce the output.
+func encodeFailure(err error) error {
+	return classify(ErrEncode, err)
+}
+
+// unsupported returns an error wrapping ErrNoConverter for a pair a
+// converter was asked for but cannot handle.
+func unsupported(format string, args ...interface{}) error {
+	return classify(ErrNoConverter, fmt.Errorf(format, args...))
+}
+
+// sourceNotFound reports a missing source; it wraps os.ErrNotExist.
+func sourceNotFound(src string) error {
+	return classify(os.ErrNotExist, fmt.Errorf("source file not found: %s", src))
+}
+
+// Hint suggests how to fix the failure err describes, or returns "" when
+// there is nothing to suggest.
+func Hint(err error) string {
+	var missing *MissingToolError
+	var tool *ToolError
+	switch {
+	case err == nil, errors.Is(err, ErrCancelled):
+		return ""
+	case errors.As(err, &missing):
+		return fmt.Sprintf("install %s, then run golter doctor to check", missing.Install)
+	case errors.Is(err, ErrVerification):
+		return "the output did not read back and was discarded; try another quality or target format"
+	case errors.Is(err, ErrInvalidOption):
+		return "check the option values against golter formats"
+	case errors.Is(err, ErrNoConverter):
+		return "pick another target format; golter formats lists what is supported"
+	case errors.Is(err, ErrCorruptInput):
+		return "the source may be damaged or not the format its extension names"
+	case errors.Is(err, ErrEncode):
+		return "check there is free disk space, or try another quality or target format"
+	case errors.As(err, &tool):
+		return fmt.Sprintf("see the %s output; golter doctor shows which version is installed", tool.Tool)
+	case errors.Is(err, os.ErrNotExist):
+		return "check that the source still exists"
+	}
+	return ""
+}
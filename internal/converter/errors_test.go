@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewToolError(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	output, err := exec.Command("sh", "-c", "echo first; echo last; exit 3").CombinedOutput()
+	if err == nil {
+		t.Fatal("expected the command to fail")
+	}
+
+	toolErr := NewToolError("sh", err, output)
+	if toolErr.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", toolErr.ExitCode)
+	}
+	if toolErr.Stderr != "first\nlast" {
+		t.Errorf("Stderr = %q", toolErr.Stderr)
+	}
+	if !errors.Is(toolErr, ErrToolFailed) {
+		t.Error("ToolError should wrap ErrToolFailed")
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(toolErr, &exitErr) {
+		t.Error("ToolError should unwrap to the exec error")
+	}
+	if msg := toolErr.Error(); !strings.HasPrefix(msg, "sh failed: exit status 3") || !strings.HasSuffix(msg, "Output: first\nlast") {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	if got := NewToolError("sh", errors.New("not started"), nil).ExitCode; got != -1 {
+		t.Errorf("ExitCode without an exit status = %d, want -1", got)
+	}
+}
+
+func TestToolError_KeepsTail(t *testing.T) {
+	lines := make([]string, stderrTailLines+5)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[len(lines)-1] = "the real error"
+	toolErr := NewToolError("tool", errors.New("exit status 1"), []byte(strings.Join(lines, "\n")))
+	if n := strings.Count(toolErr.Stderr, "\n") + 1; n != stderrTailLines {
+		t.Errorf("kept %d lines, want %d", n, stderrTailLines)
+	}
+	if !strings.HasSuffix(toolErr.Stderr, "the real error") {
+		t.Errorf("Stderr lost the last line: %q", toolErr.Stderr)
+	}
+
+	long := NewToolError("tool", errors.New("exit status 1"), []byte(strings.Repeat("x", 2*stderrTailBytes)))
+	if len(long.Stderr) != stderrTailBytes {
+		t.Errorf("kept %d bytes, want %d", len(long.Stderr), stderrTailBytes)
+	}
+}
+
+func TestLookTool(t *testing.T) {
+	err := lookTool(context.Background(), "golter-no-such-tool", "the missing tool (https://example.com)")
+	if !errors.Is(err, ErrMissingTool) {
+		t.Fatalf("expected ErrMissingTool, got %v", err)
+	}
+	want := "golter-no-such-tool not found: please install the missing tool (https://example.com)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestConverterErrorsAreClassified(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.png")
+	if err := os.WriteFile(bad, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	badJSON := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(badJSON, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"corrupt image", (&ImageConverter{}).Convert(bad, filepath.Join(dir, "out.jpg"), nil), ErrCorruptInput},
+		{"corrupt json", (&DocDataConverter{}).Convert(badJSON, filepath.Join(dir, "out.yaml"), nil), ErrCorruptInput},
+		{"unsupported pair", (&DocDataConverter{}).Convert(badJSON, filepath.Join(dir, "out.png"), nil), ErrNoConverter},
+		{"missing source", (&DocDataConverter{}).Convert(filepath.Join(dir, "gone.json"), filepath.Join(dir, "out.yaml"), nil), os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: got %v, want it to wrap %v", tt.name, tt.err, tt.want)
+		}
+	}
+
+	// Classifying keeps the original message
+	if err := tests[1].err; !strings.HasPrefix(err.Error(), "failed to parse json") {
+		t.Errorf("message changed: %q", err.Error())
+	}
+}
+
+func TestConvertContext_CancelledWrapsErrCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ConvertContext(ctx, &ImageConverter{}, "a.png", filepath.Join(t.TempDir(), "a.jpg"), nil)
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected ErrCancelled and context.Canceled, got %v", err)
+	}
+}
+
+func TestHint(t *testing.T) {
+	if Hint(nil) != "" || Hint(ErrCancelled) != "" {
+		t.Error("nil and cancelled errors should have no hint")
+	}
+	missing := &MissingToolError{Tool: "ffmpeg", Install: "ffmpeg (https://ffmpeg.org)"}
+	if got := Hint(missing); !strings.Contains(got, "ffmpeg (https://ffmpeg.org)") || !strings.Contains(got, "golter doctor") {
+		t.Errorf("Hint(missing tool) = %q", got)
+	}
+	for _, err := range []error{ErrNoConverter, ErrCorruptInput, ErrEncode, ErrVerification, ErrInvalidOption, NewToolError("pandoc", errors.New("exit status 1"), nil)} {
+		if Hint(err) == "" {
+			t.Errorf("Hint(%v) is empty", err)
+		}
+	}
+}
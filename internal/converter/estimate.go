@@ -0,0 +1,71 @@
+package converter
+
+import "math"
+
+// sizeProfile describes how large a format is compared with other formats
+// of the same kind holding the same content.
+type sizeProfile struct {
+	kind string
+	// density is the format's size relative to the others of its kind; for
+	// audio it is a typical bitrate in kbit/s.
+	density float64
+	// lossy formats grow and shrink with the quality preset.
+	lossy bool
+}
+
+var sizeProfiles = map[string]sizeProfile{
+	".png":  {"image", 4, false},
+	".jpg":  {"image", 1, true},
+	".jpeg": {"image", 1, true},
+	".webp": {"image", 0.7, true},
+
+	".wav":  {"audio", 1411, false},
+	".flac": {"audio", 850, false},
+	".mp3":  {"audio", 192, true},
+	".m4a":  {"audio", 192, true},
+	".aac":  {"audio", 192, true},
+	".ogg":  {"audio", 160, true},
+
+	".mp4":  {"video", 1, true},
+	".mov":  {"video", 1, true},
+	".mkv":  {"video", 0.7, true},
+	".webm": {"video", 0.8, true},
+	".avi":  {"video", 1.5, true},
+	".gif":  {"video", 8, false},
+}
+
+// qualityScale is how much the quality presets change the size of lossy
+// outputs compared with Balanced.
+var qualityScale = map[string]float64{
+	QualityHigh:     1.5,
+	QualityBalanced: 1,
+	QualityCompact:  0.6,
+}
+
+// EstimateSize guesses how large the output of converting inputSize bytes
+// of srcExt to targetExt with c will be. Converters that implement
+// Estimator answer for themselves; otherwise the guess comes from how large
+// the two formats typically are, which is only known for image, audio and
+// video formats of the same kind. It returns 0 when there is no estimate.
+func EstimateSize(c Converter, srcExt, targetExt string, inputSize int64, opts Options) int64 {
+	if e, ok := c.(Estimator); ok {
+		return e.EstimateSize(srcExt, targetExt, inputSize, opts)
+	}
+	return estimateByFormat(srcExt, targetExt, inputSize, opts)
+}
+
+func estimateByFormat(srcExt, targetExt string, inputSize int64, opts Options) int64 {
+	src, ok := sizeProfiles[NormalizeExt(srcExt)]
+	if !ok {
+		return 0
+	}
+	target, ok := sizeProfiles[NormalizeExt(targetExt)]
+	if !ok || target.kind != src.kind {
+		return 0
+	}
+	ratio := target.density / src.density
+	if scale, ok := qualityScale[QualityOf(opts)]; ok && target.lossy {
+		ratio *= scale
+	}
+	return max(1, int64(math.Round(float64(inputSize)*ratio)))
+}
@@ -0,0 +1,41 @@
+package converter
+
+import "testing"
+
+// fixedEstimate is an image converter that knows its output size.
+type fixedEstimate struct {
+	ImageConverter
+}
+
+func (c *fixedEstimate) EstimateSize(srcExt, targetExt string, inputSize int64, opts Options) int64 {
+	return 42
+}
+
+func TestEstimateSize(t *testing.T) {
+	balanced := Options{"quality": QualityBalanced}
+	tests := []struct {
+		name     string
+		conv     Converter
+		src, dst string
+		opts     Options
+		want     int64
+	}{
+		{"png to jpg", &ImageConverter{}, ".png", ".jpg", balanced, 250},
+		{"compact scales lossy outputs", &ImageConverter{}, ".png", ".jpg", Options{"quality": QualityCompact}, 150},
+		{"lossless outputs ignore quality", &ImageConverter{}, ".jpg", ".png", Options{"quality": QualityCompact}, 4000},
+		{"audio by bitrate", &AudioConverter{}, ".wav", ".mp3", balanced, 136},
+		{"compress in place", &VideoConverter{}, ".mp4", ".mp4", Options{"quality": QualityHigh}, 1500},
+		{"different kinds", &VideoConverter{}, ".mp4", ".mp3", balanced, 0},
+		{"unknown format", &DocumentConverter{}, ".md", ".html", balanced, 0},
+		{"declared by the converter", &fixedEstimate{}, ".png", ".jpg", balanced, 42},
+		{"route", newChainConverter([]Step{
+			{Converter: &ImageConverter{}, Source: ".png", Target: ".jpg"},
+			{Converter: &ImageConverter{}, Source: ".jpg", Target: ".webp"},
+		}), ".png", ".webp", balanced, 175},
+	}
+	for _, tt := range tests {
+		if got := EstimateSize(tt.conv, tt.src, tt.dst, 1000, tt.opts); got != tt.want {
+			t.Errorf("%s: EstimateSize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
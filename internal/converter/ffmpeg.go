@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runFFmpeg runs ffmpeg with args converting src. When ctx carries a
+// ProgressFunc, ffmpeg's -progress output is parsed against the input
+// duration reported by ffprobe.
+func runFFmpeg(ctx context.Context, src string, args []string) error {
+	var duration time.Duration
+	if hasProgress(ctx) {
+		duration = probeDuration(ctx, src)
+	}
+
+	runner := RunnerFrom(ctx)
+	if duration <= 0 {
+		output, err := runner.Run(ctx, Command{Tool: "ffmpeg", Args: args})
+		if err != nil {
+			return NewToolError("ffmpeg", err, output)
+		}
+		return nil
+	}
+
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	pr, pw := io.Pipe()
+	parsed := make(chan struct{})
+	go func() {
+		defer close(parsed)
+		parseFFmpegProgress(pr, duration, func(fraction float64) {
+			ReportProgress(ctx, fraction)
+		})
+		// Keep ffmpeg from blocking should parsing stop early
+		io.Copy(io.Discard, pr)
+	}()
+
+	output, err := runner.Run(ctx, Command{Tool: "ffmpeg", Args: args, Stdout: pw})
+	pw.Close()
+	<-parsed
+	if err != nil {
+		return NewToolError("ffmpeg", err, output)
+	}
+	return nil
+}
+
+// parseFFmpegProgress reads ffmpeg "-progress" key=value lines until r is
+// exhausted and reports the encoded time as a fraction of duration.
+func parseFFmpegProgress(r io.Reader, duration time.Duration, report func(float64)) {
+	total := float64(duration.Microseconds())
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us", "out_time_ms": // both are in microseconds
+			us, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || us < 0 {
+				continue
+			}
+			report(float64(us) / total)
+		case "progress":
+			if value == "end" {
+				report(1)
+			}
+		}
+	}
+}
+
+// probeDuration returns the media duration of src using ffprobe, or 0 when it
+// cannot be determined.
+func probeDuration(ctx context.Context, src string) time.Duration {
+	var out bytes.Buffer
+	_, err := RunnerFrom(ctx).Run(ctx, Command{
+		Tool: "ffprobe",
+		Args: []string{
+			"-v", "error",
+			"-show_entries", "format=duration",
+			"-of", "default=noprint_wrappers=1:nokey=1",
+			absPath(src),
+		},
+		Stdout: &out,
+	})
+	if err != nil {
+		return 0
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(out.String()), 64)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
+}
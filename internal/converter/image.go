@@ -1,12 +1,14 @@
 package converter
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
-	"runtime"
+	"path/filepath"
 	"strings"
 
 	"github.com/chai2010/webp"
@@ -47,69 +49,92 @@ func (c *ImageConverter) Convert(src, target string, opts Options) error {
 	}
 	defer file.Close()
 
+	// Encode into memory so a failed decode leaves no output file behind
+	var buf bytes.Buffer
+	if err := c.ConvertStream(file, &buf, filepath.Ext(src), filepath.Ext(target), opts); err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(target, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	return nil
+}
+
+func (c *ImageConverter) CanStream(srcExt, targetExt string) bool {
+	return c.CanConvert(srcExt, targetExt)
+}
+
+func (c *ImageConverter) ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
 	// Decode image
-	img, format, err := image.Decode(file)
+	img, format, err := image.Decode(r)
 	if err != nil {
-		return fmt.Errorf("failed to decode image (format: %s): %w", format, err)
+		return corruptInput(fmt.Errorf("failed to decode image (format: %s): %w", format, err))
 	}
 
 	// Parse quality option
 	quality := parseQuality(opts)
 
-	// Create output file
-	outFile, err := os.Create(target)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outFile.Close()
-
 	// Encode to target format with optimized settings
-	targetLower := strings.ToLower(target)
-	switch {
-	case strings.HasSuffix(targetLower, ".png"):
+	switch strings.ToLower(strings.TrimPrefix(targetExt, ".")) {
+	case "png":
 		encoder := png.Encoder{
 			CompressionLevel: getPNGCompressionLevel(quality),
 		}
-		if err := encoder.Encode(outFile, img); err != nil {
-			return fmt.Errorf("failed to encode PNG: %w", err)
+		if err := encoder.Encode(w, img); err != nil {
+			return encodeFailure(fmt.Errorf("failed to encode PNG: %w", err))
 		}
 		return nil
 
-	case strings.HasSuffix(targetLower, ".jpg"), strings.HasSuffix(targetLower, ".jpeg"):
-		if err := jpeg.Encode(outFile, img, &jpeg.Options{Quality: quality}); err != nil {
-			return fmt.Errorf("failed to encode JPEG: %w", err)
+	case "jpg", "jpeg":
+		if err := jpeg.Encode(w, img, &jpeg.Options{Quality: quality}); err != nil {
+			return encodeFailure(fmt.Errorf("failed to encode JPEG: %w", err))
 		}
 		return nil
 
-	case strings.HasSuffix(targetLower, ".webp"):
+	case "webp":
 		// WebP is excellent for compression
-		if err := webp.Encode(outFile, img, &webp.Options{
+		if err := webp.Encode(w, img, &webp.Options{
 			Quality:  float32(quality),
 			Lossless: quality >= 95,
 		}); err != nil {
-			return fmt.Errorf("failed to encode WebP: %w", err)
+			return encodeFailure(fmt.Errorf("failed to encode WebP: %w", err))
 		}
 		return nil
 
 	default:
-		return fmt.Errorf("unsupported target format: %s", target)
+		return unsupported("unsupported target format: %s", targetExt)
+	}
+}
+
+// OptionSpecs declares the quality preset and an explicit encoder quality.
+func (c *ImageConverter) OptionSpecs(srcExt, targetExt string) []OptionSpec {
+	return []OptionSpec{
+		qualityOption,
+		{
+			Name:        "imageQuality",
+			Type:        OptionInt,
+			Description: "Encoder quality (JPEG/WebP, PNG compression); overrides the preset",
+			Min:         1,
+			Max:         100,
+		},
 	}
 }
 
 // parseQuality extracts and normalizes quality from options
 func parseQuality(opts Options) int {
-	quality := 80 // Default
-	if q, ok := opts["quality"].(string); ok {
-		switch {
-		case strings.Contains(q, "High"):
-			quality = 92
-		case strings.Contains(q, "Balanced"), strings.Contains(q, "Medium"):
-			quality = 75
-		case strings.Contains(q, "Compact"), strings.Contains(q, "Low"):
-			quality = 55
-		}
+	if q, ok := intOption(opts, "imageQuality"); ok {
+		return q
 	}
-	return quality
+	switch QualityOf(opts) {
+	case QualityHigh:
+		return 92
+	case QualityBalanced:
+		return 75
+	case QualityCompact:
+		return 55
+	}
+	return 80 // Default
 }
 
 // getPNGCompressionLevel returns the appropriate PNG compression level
@@ -125,9 +150,6 @@ func getPNGCompressionLevel(quality int) png.CompressionLevel {
 }
 
 func init() {
-	// Optimize for multi-core processing
-	runtime.GOMAXPROCS(runtime.NumCPU())
-
 	// Register image decoders
 	_ = jpeg.Decode
 	_ = png.Decode
@@ -1,5 +1,10 @@
 package converter
 
+import (
+	"context"
+	"io"
+)
+
 // Options is a map of options for the conversion.
 type Options map[string]interface{}
 
@@ -16,3 +21,55 @@ type Converter interface {
 	// Convert performs the conversion.
 	Convert(src, target string, opts Options) error
 }
+
+// ToolRequirer is implemented by converters that depend on external programs.
+type ToolRequirer interface {
+	// RequiredTools returns the external programs needed to convert srcExt to targetExt.
+	RequiredTools(srcExt, targetExt string) []string
+}
+
+// EncoderRequirer is implemented by converters that depend on specific
+// ffmpeg encoders, which not every ffmpeg build includes.
+type EncoderRequirer interface {
+	// RequiredEncoders returns the ffmpeg encoders used to convert srcExt to targetExt.
+	RequiredEncoders(srcExt, targetExt string) []string
+}
+
+// Prioritizer is implemented by converters that should win, or yield, when
+// several converters handle the same pair. Converters without it have
+// priority 0; ties go to the converter registered first.
+type Prioritizer interface {
+	// Priority ranks the converter for srcExt to targetExt; higher wins.
+	Priority(srcExt, targetExt string) int
+}
+
+// Coster is implemented by converters whose cost is not implied by the
+// external tools they require (see CostOf).
+type Coster interface {
+	// Cost classifies converting srcExt to targetExt.
+	Cost(srcExt, targetExt string) Cost
+}
+
+// Estimator is implemented by converters that can predict the size of their
+// output better than EstimateSize's guess from the formats.
+type Estimator interface {
+	// EstimateSize returns the expected output size of converting inputSize
+	// bytes of srcExt to targetExt, or 0 when it cannot tell.
+	EstimateSize(srcExt, targetExt string, inputSize int64, opts Options) int64
+}
+
+// StreamConverter is implemented by converters that can convert in memory
+// without touching disk.
+type StreamConverter interface {
+	// CanStream reports whether srcExt to targetExt can be converted between streams.
+	CanStream(srcExt, targetExt string) bool
+	// ConvertStream reads srcExt data from r and writes targetExt data to w.
+	ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error
+}
+
+// ContextConverter is implemented by converters that can stop a conversion
+// early, killing any external process, when ctx is cancelled.
+type ContextConverter interface {
+	// ConvertContext performs the conversion until it finishes or ctx is done.
+	ConvertContext(ctx context.Context, src, target string, opts Options) error
+}
@@ -1,13 +1,44 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 )
 
+// ErrNoConverter is returned by FindConverter when no registered converter handles a pair.
+var ErrNoConverter = errors.New("no converter found")
+
+// Conversion describes a source to target pair and the converter that handles it.
+type Conversion struct {
+	Source    string       `json:"source"`
+	Target    string       `json:"target"`
+	Converter string       `json:"converter"`
+	Via       []string     `json:"via,omitempty"`
+	Tools     []string     `json:"tools,omitempty"`
+	Encoders  []string     `json:"encoders,omitempty"`
+	Options   []OptionSpec `json:"options,omitempty"`
+	// Pinned is set when the user chose Converter for this pair (see Manager.Pin).
+	Pinned bool `json:"pinned,omitempty"`
+	// Alternatives are the other converters that handle the pair directly,
+	// in the order they would be used.
+	Alternatives []string `json:"alternatives,omitempty"`
+	// Missing lists the tools and encoders that are not installed, when the
+	// manager has probed for them (see SetCapabilities).
+	Missing []string `json:"missing,omitempty"`
+}
+
 // Manager handles the registration and retrieval of converters.
 type Manager struct {
 	converters []Converter
+	// pins maps a source and target pair to the index of the converter the
+	// user chose for it.
+	pins map[[2]string]int
+
+	mu   sync.RWMutex
+	caps *Capabilities
 }
 
 // NewManager creates a new Manager.
@@ -17,54 +48,208 @@ func NewManager() *Manager {
 	}
 }
 
+// NewDefaultManager creates a Manager with all built-in converters registered.
+func NewDefaultManager() *Manager {
+	m := NewManager()
+	m.Register(&ImageConverter{})
+	m.Register(&VideoConverter{})
+	m.Register(&DocumentConverter{})
+	m.Register(&DocDataConverter{})
+	m.Register(&AudioConverter{})
+	return m
+}
+
 // Register adds a converter to the manager.
 func (m *Manager) Register(c Converter) {
 	m.converters = append(m.converters, c)
 }
 
-// FindConverter returns a converter that can handle the specified extensions.
-func (m *Manager) FindConverter(srcExt, targetExt string) (Converter, error) {
-	srcExt = strings.ToLower(srcExt)
-	targetExt = strings.ToLower(targetExt)
+// Pin makes the converter called name handle srcExt to targetExt, whatever
+// the priorities say. Names are matched ignoring case, and the converter must
+// already be registered.
+func (m *Manager) Pin(srcExt, targetExt, name string) error {
+	srcExt, targetExt = NormalizeExt(srcExt), NormalizeExt(targetExt)
+	for i, c := range m.converters {
+		if strings.EqualFold(c.Name(), name) && c.CanConvert(srcExt, targetExt) {
+			if m.pins == nil {
+				m.pins = make(map[[2]string]int)
+			}
+			m.pins[[2]string{srcExt, targetExt}] = i
+			return nil
+		}
+	}
+	return fmt.Errorf("%w named %q for %s to %s", ErrNoConverter, name, srcExt, targetExt)
+}
 
-	// Ensure extensions start with dot
-	if !strings.HasPrefix(srcExt, ".") {
-		srcExt = "." + srcExt
+// pinned reports whether the user chose a converter for srcExt to targetExt.
+func (m *Manager) pinned(srcExt, targetExt string) bool {
+	_, ok := m.pins[[2]string{srcExt, targetExt}]
+	return ok
+}
+
+// candidates returns the converters that handle srcExt to targetExt in one
+// step, best first: the pinned converter, then by descending priority, then
+// in registration order.
+func (m *Manager) candidates(srcExt, targetExt string) []Converter {
+	type ranked struct {
+		c        Converter
+		pinned   bool
+		priority int
 	}
-	if !strings.HasPrefix(targetExt, ".") {
-		targetExt = "." + targetExt
+	pin, hasPin := m.pins[[2]string{srcExt, targetExt}]
+	var rs []ranked
+	for i, c := range m.converters {
+		if c.CanConvert(srcExt, targetExt) {
+			rs = append(rs, ranked{c: c, pinned: hasPin && i == pin, priority: Priority(c, srcExt, targetExt)})
+		}
 	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].pinned != rs[j].pinned {
+			return rs[i].pinned
+		}
+		return rs[i].priority > rs[j].priority
+	})
 
-	for _, c := range m.converters {
-		if c.CanConvert(srcExt, targetExt) {
-			return c, nil
+	result := make([]Converter, len(rs))
+	for i, r := range rs {
+		result[i] = r.c
+	}
+	return result
+}
+
+// direct returns the best converter for srcExt to targetExt in one step,
+// optionally only among those whose tools are installed, or nil. A pinned
+// converter is never passed over for another.
+func (m *Manager) direct(srcExt, targetExt string, availableOnly bool) Converter {
+	for _, c := range m.candidates(srcExt, targetExt) {
+		if !availableOnly || m.available(c, srcExt, targetExt) {
+			return c
+		}
+		if m.pinned(srcExt, targetExt) {
+			return nil
 		}
 	}
-	return nil, fmt.Errorf("no converter found for %s to %s", srcExt, targetExt)
+	return nil
+}
+
+// SetCapabilities makes the manager prefer, and only offer, conversions whose
+// tools and ffmpeg encoders caps reports as installed. Nil, the default,
+// assumes everything is installed.
+func (m *Manager) SetCapabilities(caps *Capabilities) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.caps = caps
+}
+
+// Capabilities returns what SetCapabilities recorded, or nil.
+func (m *Manager) Capabilities() *Capabilities {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.caps
 }
 
-// GetSupportedTargetFormats returns a list of supported target extensions for a given source extension.
+// Missing returns the tools and encoders c lacks to convert srcExt to
+// targetExt, or nil when nothing is missing or nothing was probed.
+func (m *Manager) Missing(c Converter, srcExt, targetExt string) []string {
+	caps := m.Capabilities()
+	if caps == nil {
+		return nil
+	}
+	return caps.Missing(c, srcExt, targetExt)
+}
+
+// available reports whether c can convert srcExt to targetExt with the installed tools.
+func (m *Manager) available(c Converter, srcExt, targetExt string) bool {
+	return len(m.Missing(c, srcExt, targetExt)) == 0
+}
+
+// FindConverter returns a converter that can handle the specified extensions.
+// When no single converter handles the pair, the returned converter chains
+// several through intermediate formats (see FindRoute).
+func (m *Manager) FindConverter(srcExt, targetExt string) (Converter, error) {
+	steps, err := m.FindRoute(srcExt, targetExt)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 1 {
+		return steps[0].Converter, nil
+	}
+	return newChainConverter(steps), nil
+}
+
+// FindConverterForFile is FindConverter for the file at path, with the source
+// format sniffed from its content rather than taken from the extension. The
+// returned Detection tells callers whether the file must be staged under its
+// real extension (see StageSource) and whether to warn about a mismatch.
+func (m *Manager) FindConverterForFile(path, targetExt string) (Converter, Detection, error) {
+	d, err := DetectFile(path)
+	if err != nil {
+		return nil, d, err
+	}
+	c, err := m.FindConverter(d.Format(), targetExt)
+	return c, d, err
+}
+
+// GetSupportedTargetFormats returns the target extensions for a given source
+// extension: those a single converter handles, sorted, followed by those
+// reached through intermediate formats, sorted. After SetCapabilities,
+// targets that need a missing tool or encoder are left out.
 func (m *Manager) GetSupportedTargetFormats(srcExt string) []string {
-	targets := make(map[string]bool)
-	srcExt = strings.ToLower(srcExt)
-	if !strings.HasPrefix(srcExt, ".") {
-		srcExt = "." + srcExt
+	return m.targets(srcExt, true)
+}
+
+// UnavailableTargets returns the targets for srcExt that
+// GetSupportedTargetFormats hides, with the tools or encoders each is missing.
+func (m *Manager) UnavailableTargets(srcExt string) map[string][]string {
+	if m.Capabilities() == nil {
+		return nil
+	}
+	available := make(map[string]bool)
+	for _, t := range m.targets(srcExt, true) {
+		available[t] = true
 	}
+	result := make(map[string][]string)
+	for _, t := range m.targets(srcExt, false) {
+		if available[t] {
+			continue
+		}
+		if c, err := m.FindConverter(srcExt, t); err == nil {
+			result[t] = m.Missing(c, NormalizeExt(srcExt), t)
+		}
+	}
+	return result
+}
+
+// targets lists the targets for srcExt in GetSupportedTargetFormats order,
+// optionally only those available.
+func (m *Manager) targets(srcExt string, availableOnly bool) []string {
+	srcExt = NormalizeExt(srcExt)
 
+	seen := make(map[string]bool)
+	var direct, routed []string
 	for _, c := range m.converters {
 		for _, t := range c.SupportedTargetFormats(srcExt) {
-			targets[t] = true
+			t = NormalizeExt(t)
+			if !seen[t] && m.direct(srcExt, t, availableOnly) != nil {
+				seen[t] = true
+				direct = append(direct, t)
+			}
 		}
 	}
-
-	result := make([]string, 0, len(targets))
-	for t := range targets {
-		result = append(result, t)
+	// Targets reachable through intermediate formats
+	for t := range m.routes(srcExt, availableOnly) {
+		if !seen[t] {
+			seen[t] = true
+			routed = append(routed, t)
+		}
 	}
-	return result
+
+	sort.Strings(direct)
+	sort.Strings(routed)
+	return append(direct, routed...)
 }
 
-// SupportedExtensions returns a list of supported source extensions.
+// SupportedExtensions returns the supported source extensions, sorted.
 func (m *Manager) SupportedExtensions() []string {
 	exts := make(map[string]bool)
 	for _, c := range m.converters {
@@ -77,5 +262,72 @@ func (m *Manager) SupportedExtensions() []string {
 	for ext := range exts {
 		result = append(result, ext)
 	}
+	sort.Strings(result)
 	return result
 }
+
+// Conversions returns every supported source to target pair, sorted by source
+// and target, together with the converter that handles it and its external
+// tools. Conversions that need missing tools are included and say so.
+func (m *Manager) Conversions() []Conversion {
+	sources := m.SupportedExtensions()
+
+	var result []Conversion
+	for _, src := range sources {
+		targets := m.targets(src, false)
+		sort.Strings(targets)
+		for _, target := range targets {
+			c, err := m.FindConverter(src, target)
+			if err != nil {
+				continue
+			}
+			conv := Conversion{
+				Source:    src,
+				Target:    target,
+				Converter: c.Name(),
+				Tools:     RequiredTools(c, src, target),
+				Encoders:  RequiredEncoders(c, src, target),
+				Options:   OptionSpecsFor(c, src, target),
+				Missing:   m.Missing(c, src, target),
+			}
+			if chain, ok := c.(*chainConverter); ok {
+				conv.Via = chain.Via()
+			} else {
+				conv.Pinned = m.pinned(src, target)
+				for _, alt := range m.candidates(src, target) {
+					if alt.Name() != c.Name() {
+						conv.Alternatives = append(conv.Alternatives, alt.Name())
+					}
+				}
+			}
+			result = append(result, conv)
+		}
+	}
+	return result
+}
+
+// Priority returns c's priority for srcExt to targetExt, 0 unless c is a Prioritizer.
+func Priority(c Converter, srcExt, targetExt string) int {
+	if p, ok := c.(Prioritizer); ok {
+		return p.Priority(srcExt, targetExt)
+	}
+	return 0
+}
+
+// RequiredTools returns the external programs c needs for srcExt to targetExt.
+func RequiredTools(c Converter, srcExt, targetExt string) []string {
+	if tr, ok := c.(ToolRequirer); ok {
+		return tr.RequiredTools(srcExt, targetExt)
+	}
+	return nil
+}
+
+// NormalizeExt lowercases an extension and ensures it starts with a dot, so
+// "PNG", ".png" and " .Png" all become ".png". Empty stays empty.
+func NormalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
@@ -1,8 +1,8 @@
 package converter
 
 import (
+	"errors"
 	"reflect"
-	"sort"
 	"testing"
 )
 
@@ -46,6 +46,16 @@ func (m *MockConverter) Convert(src, target string, opts Options) error {
 	return nil
 }
 
+// rankedConverter is a MockConverter with a priority.
+type rankedConverter struct {
+	*MockConverter
+	priority int
+}
+
+func (r *rankedConverter) Priority(srcExt, targetExt string) int {
+	return r.priority
+}
+
 func TestNewManager(t *testing.T) {
 	m := NewManager()
 	if m == nil {
@@ -129,9 +139,7 @@ func TestManager_GetSupportedTargetFormats(t *testing.T) {
 	m.Register(c2)
 
 	got := m.GetSupportedTargetFormats(".jpg")
-	sort.Strings(got)
 	want := []string{".bmp", ".png", ".webp"}
-	sort.Strings(want)
 
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("GetSupportedTargetFormats() = %v, want %v", got, want)
@@ -155,11 +163,147 @@ func TestManager_SupportedExtensions(t *testing.T) {
 	m.Register(c2)
 
 	got := m.SupportedExtensions()
-	sort.Strings(got)
 	want := []string{".jpg", ".mp4", ".png"}
-	sort.Strings(want)
 
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("SupportedExtensions() = %v, want %v", got, want)
 	}
 }
+
+func TestManager_Conversions(t *testing.T) {
+	m := NewManager()
+	m.Register(&MockConverter{
+		name:             "C1",
+		supportedSources: []string{".png", ".jpg"},
+		supportedTargets: map[string][]string{
+			".jpg": {".webp", ".png"},
+			".png": {".jpg"},
+		},
+	})
+	m.Register(&AudioConverter{})
+
+	got := m.Conversions()
+	if len(got) < 3 {
+		t.Fatalf("expected at least 3 conversions, got %d", len(got))
+	}
+	want := []Conversion{
+		{Source: ".aac", Target: ".aac", Converter: "Audio Converter (ffmpeg)", Tools: []string{"ffmpeg"}, Encoders: []string{"aac"}, Options: (&AudioConverter{}).OptionSpecs(".aac", ".aac")},
+	}
+	if !reflect.DeepEqual(got[:1], want) {
+		t.Errorf("Conversions()[0] = %v, want %v", got[0], want[0])
+	}
+
+	var images []Conversion
+	for _, c := range got {
+		if c.Converter == "C1" {
+			images = append(images, c)
+		}
+	}
+	wantImages := []Conversion{
+		{Source: ".jpg", Target: ".png", Converter: "C1"},
+		{Source: ".jpg", Target: ".webp", Converter: "C1"},
+		{Source: ".png", Target: ".jpg", Converter: "C1"},
+	}
+	if !reflect.DeepEqual(images, wantImages) {
+		t.Errorf("Conversions() for C1 = %v, want %v", images, wantImages)
+	}
+}
+
+func TestManager_GetSupportedTargetFormats_RoutedLast(t *testing.T) {
+	m := NewManager()
+	m.Register(&MockConverter{
+		supportedSources: []string{".a", ".m"},
+		supportedTargets: map[string][]string{
+			".a": {".z", ".m"},
+			".m": {".b"},
+		},
+	})
+
+	// .b is only reachable through .m, so it follows the direct targets
+	want := []string{".m", ".z", ".b"}
+	for i := 0; i < 5; i++ {
+		if got := m.GetSupportedTargetFormats(".a"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetSupportedTargetFormats() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestManager_Priority(t *testing.T) {
+	pair := map[string][]string{".csv": {".xlsx"}}
+	first := &MockConverter{name: "First", supportedSources: []string{".csv"}, supportedTargets: pair}
+	preferred := &rankedConverter{
+		MockConverter: &MockConverter{name: "Preferred", supportedSources: []string{".csv"}, supportedTargets: pair},
+		priority:      5,
+	}
+	m := NewManager()
+	m.Register(first)
+	m.Register(preferred)
+
+	got, err := m.FindConverter(".csv", ".xlsx")
+	if err != nil || got != preferred {
+		t.Fatalf("FindConverter() = %v, %v, want the higher priority converter", got, err)
+	}
+	conv := m.Conversions()[0]
+	if conv.Converter != "Preferred" || !reflect.DeepEqual(conv.Alternatives, []string{"First"}) || conv.Pinned {
+		t.Errorf("unexpected conversion %+v", conv)
+	}
+}
+
+func TestManager_Pin(t *testing.T) {
+	pair := map[string][]string{".csv": {".xlsx"}}
+	first := &MockConverter{name: "First", supportedSources: []string{".csv"}, supportedTargets: pair}
+	preferred := &rankedConverter{
+		MockConverter: &MockConverter{name: "Preferred", supportedSources: []string{".csv"}, supportedTargets: pair},
+		priority:      5,
+	}
+	m := NewManager()
+	m.Register(first)
+	m.Register(preferred)
+
+	if err := m.Pin("CSV", "xlsx", "first"); err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+	if got, _ := m.FindConverter(".csv", ".xlsx"); got != first {
+		t.Errorf("FindConverter() = %v, want the pinned converter", got)
+	}
+	if conv := m.Conversions()[0]; !conv.Pinned || conv.Converter != "First" {
+		t.Errorf("expected the conversion to be pinned: %+v", conv)
+	}
+
+	if err := m.Pin(".csv", ".xlsx", "Missing"); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected ErrNoConverter for an unknown converter, got %v", err)
+	}
+	if err := m.Pin(".csv", ".pdf", "First"); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected ErrNoConverter for a pair the converter cannot handle, got %v", err)
+	}
+}
+
+func TestManager_PinnedUnavailable(t *testing.T) {
+	m := NewManager()
+	m.Register(&toolConverter{appendConverter("Tooled", "b", map[string][]string{".a": {".b"}})})
+	fallback := &MockConverter{name: "Fallback", supportedSources: []string{".a"}, supportedTargets: map[string][]string{".a": {".b"}}}
+	m.Register(fallback)
+	m.SetCapabilities(&Capabilities{Tools: []Tool{{Name: "tool"}}})
+
+	if got, _ := m.FindConverter(".a", ".b"); got != fallback {
+		t.Errorf("expected the installed converter, got %v", got)
+	}
+	// The pin is kept even though its tool is missing
+	if err := m.Pin(".a", ".b", "Tooled"); err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+	if got, _ := m.FindConverter(".a", ".b"); got == fallback {
+		t.Error("expected the pinned converter")
+	}
+	if got := m.GetSupportedTargetFormats(".a"); len(got) != 0 {
+		t.Errorf("expected the unavailable pinned target to be hidden, got %v", got)
+	}
+}
+
+func TestNormalizeExt(t *testing.T) {
+	for in, want := range map[string]string{"PNG": ".png", ".png": ".png", " .Png ": ".png", "": ""} {
+		if got := NormalizeExt(in); got != want {
+			t.Errorf("NormalizeExt(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidOption is returned when an option value does not match the
+// schema declared by the converter.
+var ErrInvalidOption = errors.New("invalid option")
+
+// OptionType is the kind of value an option accepts.
+type OptionType string
+
+const (
+	// OptionString is free text.
+	OptionString OptionType = "string"
+	// OptionInt is an integer, bounded by Min and Max when Max > Min.
+	OptionInt OptionType = "int"
+	// OptionBool is true or false.
+	OptionBool OptionType = "bool"
+	// OptionEnum is one of Choices.
+	OptionEnum OptionType = "enum"
+	// OptionArgs is extra command-line arguments for an external tool, given
+	// as a []string or a whitespace-separated string.
+	OptionArgs OptionType = "args"
+)
+
+// Quality presets accepted by the "quality" option.
+const (
+	QualityHigh     = "High"
+	QualityBalanced = "Balanced"
+	QualityCompact  = "Compact"
+)
+
+// OptionSpec describes one option a converter accepts.
+type OptionSpec struct {
+	Name        string     `json:"name"`
+	Type        OptionType `json:"type"`
+	Description string     `json:"description"`
+	// Default is the value used when the option is not set. Nil means the
+	// converter derives it, usually from the quality preset.
+	Default interface{} `json:"default,omitempty"`
+	Choices []string    `json:"choices,omitempty"`
+	Min     int         `json:"min,omitempty"`
+	Max     int         `json:"max,omitempty"`
+}
+
+// OptionDescriber is implemented by converters that declare the options they
+// accept for a conversion.
+type OptionDescriber interface {
+	// OptionSpecs returns the options used when converting srcExt to targetExt.
+	OptionSpecs(srcExt, targetExt string) []OptionSpec
+}
+
+// OptionSpecsFor returns the options c declares for srcExt to targetExt, or
+// nil when it declares none.
+func OptionSpecsFor(c Converter, srcExt, targetExt string) []OptionSpec {
+	if d, ok := c.(OptionDescriber); ok {
+		return d.OptionSpecs(NormalizeExt(srcExt), NormalizeExt(targetExt))
+	}
+	return nil
+}
+
+// qualityOption is shared by every converter that honours quality presets.
+var qualityOption = OptionSpec{
+	Name:        "quality",
+	Type:        OptionEnum,
+	Description: "Preset trading output size for quality",
+	Choices:     []string{QualityHigh, QualityBalanced, QualityCompact},
+}
+
+// ValidateOptions checks the options named in specs against their types,
+// choices and ranges. Options the specs do not mention are ignored, since the
+// same Options are shared by every converter in a batch or route; unset and
+// empty values are left to the converter's defaults.
+func ValidateOptions(specs []OptionSpec, opts Options) error {
+	var errs []error
+	for _, spec := range specs {
+		v, ok := opts[spec.Name]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		if err := spec.check(v); err != nil {
+			errs = append(errs, fmt.Errorf("%w %s: %v", ErrInvalidOption, spec.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s OptionSpec) check(v interface{}) error {
+	switch s.Type {
+	case OptionString:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("want a string, got %T", v)
+		}
+	case OptionEnum:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %T", v)
+		}
+		if matchChoice(s.Choices, str) == "" {
+			return fmt.Errorf("%q is not one of %s", str, strings.Join(s.Choices, ", "))
+		}
+	case OptionInt:
+		n, err := toInt(v)
+		if err != nil {
+			return err
+		}
+		if s.Max > s.Min && (n < s.Min || n > s.Max) {
+			return fmt.Errorf("%d is outside %d-%d", n, s.Min, s.Max)
+		}
+	case OptionBool:
+		if _, err := toBool(v); err != nil {
+			return err
+		}
+	case OptionArgs:
+		switch v.(type) {
+		case string, []string:
+		default:
+			return fmt.Errorf("want arguments as a string or list, got %T", v)
+		}
+	}
+	return nil
+}
+
+// matchChoice returns the choice equal to s, ignoring case, or "".
+func matchChoice(choices []string, s string) string {
+	i := slices.IndexFunc(choices, func(c string) bool { return strings.EqualFold(c, strings.TrimSpace(s)) })
+	if i < 0 {
+		return ""
+	}
+	return choices[i]
+}
+
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("want a whole number, got %v", n)
+		}
+		return int(n), nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return 0, fmt.Errorf("want a whole number, got %q", n)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("want a whole number, got %T", v)
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+		if err != nil {
+			return false, fmt.Errorf("want true or false, got %q", b)
+		}
+		return parsed, nil
+	default:
+		return false, fmt.Errorf("want true or false, got %T", v)
+	}
+}
+
+// QualityOf returns the quality preset in opts, or "" when none is set.
+// Older labels ("Medium", "Low", or descriptive strings containing a preset
+// name) are still recognized.
+func QualityOf(opts Options) string {
+	q, _ := opts["quality"].(string)
+	if preset := matchChoice(qualityOption.Choices, q); preset != "" {
+		return preset
+	}
+	switch {
+	case strings.Contains(q, QualityHigh):
+		return QualityHigh
+	case strings.Contains(q, QualityBalanced), strings.Contains(q, "Medium"):
+		return QualityBalanced
+	case strings.Contains(q, QualityCompact), strings.Contains(q, "Low"):
+		return QualityCompact
+	}
+	return ""
+}
+
+// intOption returns the integer option name, if set and valid.
+func intOption(opts Options, name string) (int, bool) {
+	v, ok := opts[name]
+	if !ok || v == nil || v == "" {
+		return 0, false
+	}
+	n, err := toInt(v)
+	return n, err == nil
+}
+
+// stringOption returns the string option name, or "".
+func stringOption(opts Options, name string) string {
+	s, _ := opts[name].(string)
+	return strings.TrimSpace(s)
+}
+
+// argsOption returns the arguments option name as a list.
+func argsOption(opts Options, name string) []string {
+	switch v := opts[name].(type) {
+	case []string:
+		return v
+	case string:
+		return strings.Fields(v)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateOptions(t *testing.T) {
+	specs := []OptionSpec{
+		qualityOption,
+		{Name: "crf", Type: OptionInt, Min: 0, Max: 51},
+		{Name: "title", Type: OptionString},
+		{Name: "lossless", Type: OptionBool},
+		{Name: "pandocArgs", Type: OptionArgs},
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"Empty", nil, false},
+		{"Valid", Options{"quality": "balanced", "crf": 20, "title": "x", "lossless": "true", "pandocArgs": []string{"--toc"}}, false},
+		{"JSON numbers", Options{"crf": float64(30)}, false},
+		{"Numeric string", Options{"crf": "30"}, false},
+		{"Unset values", Options{"quality": "", "crf": nil}, false},
+		{"Unknown options are ignored", Options{"ebookArgs": 42}, false},
+		{"Bad choice", Options{"quality": "Ultra"}, true},
+		{"Out of range", Options{"crf": 60}, true},
+		{"Fractional int", Options{"crf": 20.5}, true},
+		{"Wrong type", Options{"title": 3}, true},
+		{"Bad bool", Options{"lossless": "maybe"}, true},
+		{"Bad args", Options{"pandocArgs": 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOptions(specs, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("expected ErrInvalidOption, got %v", err)
+			}
+		})
+	}
+}
+
+func TestQualityOf(t *testing.T) {
+	tests := map[string]string{
+		"High":                         QualityHigh,
+		"compact":                      QualityCompact,
+		"⚖️  Balanced (moderate size)": QualityBalanced,
+		"Medium":                       QualityBalanced,
+		"Low":                          QualityCompact,
+		"":                             "",
+	}
+	for in, want := range tests {
+		if got := QualityOf(Options{"quality": in}); got != want {
+			t.Errorf("QualityOf(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOptionOverrides(t *testing.T) {
+	if got := parseQuality(Options{"quality": "Compact", "imageQuality": "88"}); got != 88 {
+		t.Errorf("image quality = %d, want the explicit 88", got)
+	}
+
+	v := parseVideoQuality(Options{"quality": "High", "crf": 30, "preset": "veryfast"})
+	if v.crf != "30" || v.preset != "veryfast" || v.audioBr != "256k" {
+		t.Errorf("unexpected video settings %+v", v)
+	}
+
+	a := parseAudioQuality(Options{"quality": "Compact", "sampleRate": "48000"})
+	if a.bitrate != "128k" || a.sampleRate != "48000" {
+		t.Errorf("unexpected audio settings %+v", a)
+	}
+}
+
+func TestOptionSpecsFor(t *testing.T) {
+	if specs := OptionSpecsFor(&DocDataConverter{}, ".json", ".yaml"); specs != nil {
+		t.Errorf("expected no options for data conversions, got %+v", specs)
+	}
+
+	names := func(specs []OptionSpec) []string {
+		var out []string
+		for _, s := range specs {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+	if got := names(OptionSpecsFor(&VideoConverter{}, ".mp4", ".gif")); len(got) != 1 || got[0] != "quality" {
+		t.Errorf("expected only quality for GIF output, got %v", got)
+	}
+	if got := names(OptionSpecsFor(&DocumentConverter{}, ".docx", ".md")); len(got) != 1 || got[0] != "pandocArgs" {
+		t.Errorf("expected pandocArgs for DOCX, got %v", got)
+	}
+
+	// Routes combine the options of every step
+	chain := newChainConverter([]Step{
+		{Converter: &VideoConverter{}, Source: ".mkv", Target: ".mp4"},
+		{Converter: &AudioConverter{}, Source: ".mp4", Target: ".mp3"},
+	})
+	got := names(chain.OptionSpecs(".mkv", ".mp3"))
+	want := []string{"quality", "crf", "preset", "audioBitrate", "bitrate", "sampleRate"}
+	if len(got) != len(want) {
+		t.Fatalf("chain options = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chain options = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConvertContext_ValidatesOptions(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.png")
+	if err := os.WriteFile(src, []byte("not read"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ConvertContext(context.Background(), &ImageConverter{}, src, filepath.Join(dir, "a.jpg"), Options{"imageQuality": 0})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+}
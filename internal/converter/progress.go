@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"context"
+	"io"
+)
+
+// ProgressFunc receives the completed fraction (0 to 1) of a conversion.
+type ProgressFunc func(fraction float64)
+
+type progressKey struct{}
+
+// WithProgress returns a context that carries fn, so converters running under
+// it can report how far along they are.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress forwards fraction to the ProgressFunc carried by ctx, if any.
+// Values are clamped to [0, 1].
+func ReportProgress(ctx context.Context, fraction float64) {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || fn == nil {
+		return
+	}
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	fn(fraction)
+}
+
+// hasProgress reports whether anyone is listening for progress on ctx.
+func hasProgress(ctx context.Context) bool {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	return ok && fn != nil
+}
+
+// progressReader reports the fraction of an input of known size that has been read.
+type progressReader struct {
+	ctx   context.Context
+	r     io.Reader
+	total int64
+	read  int64
+}
+
+func newProgressReader(ctx context.Context, r io.Reader, total int64) io.Reader {
+	if total <= 0 || !hasProgress(ctx) {
+		return r
+	}
+	return &progressReader{ctx: ctx, r: r, total: total}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	ReportProgress(p.ctx, float64(p.read)/float64(p.total))
+	return n, err
+}
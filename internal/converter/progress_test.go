@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportProgress_Clamps(t *testing.T) {
+	var got []float64
+	ctx := WithProgress(context.Background(), func(f float64) { got = append(got, f) })
+
+	ReportProgress(ctx, -1)
+	ReportProgress(ctx, 0.5)
+	ReportProgress(ctx, 2)
+	ReportProgress(context.Background(), 0.5) // no listener, must not panic
+
+	want := []float64{0, 0.5, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var last float64
+	ctx := WithProgress(context.Background(), func(f float64) { last = f })
+
+	r := newProgressReader(ctx, strings.NewReader("0123456789"), 10)
+	buf := make([]byte, 4)
+	if _, err := r.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if last != 0.4 {
+		t.Errorf("after 4 of 10 bytes progress = %v, want 0.4", last)
+	}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	if last != 1 {
+		t.Errorf("after reading everything progress = %v, want 1", last)
+	}
+
+	plain := strings.NewReader("x")
+	if newProgressReader(context.Background(), plain, 1) != io.Reader(plain) {
+		t.Error("expected the reader to be returned unwrapped without a listener")
+	}
+}
+
+func TestParseFFmpegProgress(t *testing.T) {
+	out := strings.Join([]string{
+		"frame=10",
+		"out_time_us=2500000",
+		"progress=continue",
+		"out_time_ms=5000000",
+		"out_time_us=N/A",
+		"progress=end",
+	}, "\n")
+
+	var got []float64
+	parseFFmpegProgress(strings.NewReader(out), 10*time.Second, func(f float64) { got = append(got, f) })
+
+	want := []float64{0.25, 0.5, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestChainConverter_ScalesProgress(t *testing.T) {
+	m := NewManager()
+	m.Register(&DocDataConverter{})
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.toml")
+	if err := os.WriteFile(src, []byte("name = \"golter\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, err := m.FindConverter(".toml", ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []float64
+	ctx := WithProgress(context.Background(), func(f float64) { got = append(got, f) })
+	if err := ConvertContext(ctx, conv, src, filepath.Join(dir, "data.json"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) == 0 || got[len(got)-1] != 1 {
+		t.Fatalf("expected progress to finish at 1, got %v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] < got[i-1] {
+			t.Errorf("progress went backwards: %v", got)
+		}
+	}
+}
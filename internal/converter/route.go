@@ -0,0 +1,340 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaxRouteSteps limits how many conversions a multi-hop route may chain.
+const MaxRouteSteps = 3
+
+// Step is one conversion in a route.
+type Step struct {
+	Converter Converter
+	Source    string
+	Target    string
+}
+
+// edge is a direct conversion offered by a registered converter.
+type edge struct {
+	converter Converter
+	target    string
+	cost      int
+}
+
+// stepCost prefers in-process conversions over ones that shell out to tools.
+func stepCost(c Converter, srcExt, targetExt string) int {
+	if len(RequiredTools(c, srcExt, targetExt)) > 0 {
+		return 3
+	}
+	return 1
+}
+
+// graph returns every direct conversion keyed by source extension, optionally
+// only those whose tools are installed. Each pair uses the converter
+// FindConverter would pick for it.
+func (m *Manager) graph(availableOnly bool) map[string][]edge {
+	g := make(map[string][]edge)
+	seen := make(map[[2]string]bool)
+	for _, c := range m.converters {
+		for _, src := range c.SupportedSourceExtensions() {
+			src = NormalizeExt(src)
+			for _, target := range c.SupportedTargetFormats(src) {
+				target = NormalizeExt(target)
+				key := [2]string{src, target}
+				if target == src || seen[key] {
+					continue
+				}
+				seen[key] = true
+				if best := m.direct(src, target, availableOnly); best != nil {
+					g[src] = append(g[src], edge{converter: best, target: target, cost: stepCost(best, src, target)})
+				}
+			}
+		}
+	}
+	for src := range g {
+		sort.SliceStable(g[src], func(i, j int) bool { return g[src][i].target < g[src][j].target })
+	}
+	return g
+}
+
+// routeNode tracks the cheapest known way to reach an extension.
+type routeNode struct {
+	cost  int
+	steps []Step
+}
+
+// routes finds the cheapest route from srcExt to every reachable extension
+// within MaxRouteSteps, ties broken by fewer steps and then extension name.
+func (m *Manager) routes(srcExt string, availableOnly bool) map[string]routeNode {
+	g := m.graph(availableOnly)
+	best := map[string]routeNode{srcExt: {}}
+	done := make(map[string]bool)
+
+	for {
+		// Pick the cheapest unfinished node; the graph is small enough that a
+		// linear scan beats maintaining a heap.
+		current := ""
+		for ext, n := range best {
+			if done[ext] {
+				continue
+			}
+			if current == "" || less(n, ext, best[current], current) {
+				current = ext
+			}
+		}
+		if current == "" {
+			break
+		}
+		done[current] = true
+
+		node := best[current]
+		if len(node.steps) == MaxRouteSteps {
+			continue
+		}
+		for _, e := range g[current] {
+			next := routeNode{
+				cost:  node.cost + e.cost,
+				steps: append(append([]Step(nil), node.steps...), Step{Converter: e.converter, Source: current, Target: e.target}),
+			}
+			if old, ok := best[e.target]; !ok || less(next, e.target, old, e.target) {
+				best[e.target] = next
+			}
+		}
+	}
+
+	delete(best, srcExt)
+	return best
+}
+
+func less(a routeNode, aExt string, b routeNode, bExt string) bool {
+	if a.cost != b.cost {
+		return a.cost < b.cost
+	}
+	if len(a.steps) != len(b.steps) {
+		return len(a.steps) < len(b.steps)
+	}
+	return aExt < bExt
+}
+
+// FindRoute returns the conversions needed to turn srcExt into targetExt: a
+// single step when a converter handles the pair (the pinned one, else the one
+// with the highest priority), otherwise the cheapest chain through
+// intermediate formats. Routes whose tools are installed are
+// preferred; when there is none, an unavailable route is returned so the
+// conversion fails with the converter's own explanation.
+func (m *Manager) FindRoute(srcExt, targetExt string) ([]Step, error) {
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+
+	if steps := m.findRoute(srcExt, targetExt, true); steps != nil {
+		return steps, nil
+	}
+	if m.Capabilities() != nil {
+		if steps := m.findRoute(srcExt, targetExt, false); steps != nil {
+			return steps, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for %s to %s", ErrNoConverter, srcExt, targetExt)
+}
+
+func (m *Manager) findRoute(srcExt, targetExt string, availableOnly bool) []Step {
+	if c := m.direct(srcExt, targetExt, availableOnly); c != nil {
+		return []Step{{Converter: c, Source: srcExt, Target: targetExt}}
+	}
+	// A pinned pair is never routed around its converter
+	if srcExt != targetExt && !m.pinned(srcExt, targetExt) {
+		if node, ok := m.routes(srcExt, availableOnly)[targetExt]; ok {
+			return node.steps
+		}
+	}
+	return nil
+}
+
+// chainConverter runs a multi-step route, passing intermediate results
+// through temporary files (or memory when every step can stream).
+type chainConverter struct {
+	steps []Step
+}
+
+func newChainConverter(steps []Step) *chainConverter {
+	return &chainConverter{steps: steps}
+}
+
+// Name lists the converters involved and the intermediate formats, e.g.
+// "DocData via .yaml".
+func (c *chainConverter) Name() string {
+	var names, via []string
+	for i, s := range c.steps {
+		name := s.Converter.Name()
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+		if i > 0 {
+			via = append(via, s.Source)
+		}
+	}
+	return strings.Join(names, " + ") + " via " + strings.Join(via, ", ")
+}
+
+// Via returns the intermediate formats of the route.
+func (c *chainConverter) Via() []string {
+	via := make([]string, 0, len(c.steps)-1)
+	for _, s := range c.steps[1:] {
+		via = append(via, s.Source)
+	}
+	return via
+}
+
+func (c *chainConverter) source() string { return c.steps[0].Source }
+
+func (c *chainConverter) target() string { return c.steps[len(c.steps)-1].Target }
+
+func (c *chainConverter) CanConvert(srcExt, targetExt string) bool {
+	return NormalizeExt(srcExt) == c.source() && NormalizeExt(targetExt) == c.target()
+}
+
+func (c *chainConverter) SupportedSourceExtensions() []string {
+	return []string{c.source()}
+}
+
+func (c *chainConverter) SupportedTargetFormats(srcExt string) []string {
+	if NormalizeExt(srcExt) != c.source() {
+		return nil
+	}
+	return []string{c.target()}
+}
+
+// RequiredTools is the union of the tools every step needs.
+func (c *chainConverter) RequiredTools(srcExt, targetExt string) []string {
+	var tools []string
+	seen := make(map[string]bool)
+	for _, s := range c.steps {
+		for _, t := range RequiredTools(s.Converter, s.Source, s.Target) {
+			if !seen[t] {
+				seen[t] = true
+				tools = append(tools, t)
+			}
+		}
+	}
+	return tools
+}
+
+// RequiredEncoders is the union of the ffmpeg encoders every step needs.
+func (c *chainConverter) RequiredEncoders(srcExt, targetExt string) []string {
+	var encoders []string
+	seen := make(map[string]bool)
+	for _, s := range c.steps {
+		for _, e := range RequiredEncoders(s.Converter, s.Source, s.Target) {
+			if !seen[e] {
+				seen[e] = true
+				encoders = append(encoders, e)
+			}
+		}
+	}
+	return encoders
+}
+
+// Cost is the cost of the most expensive step.
+func (c *chainConverter) Cost(srcExt, targetExt string) Cost {
+	for _, s := range c.steps {
+		if CostOf(s.Converter, s.Source, s.Target) == CostHeavy {
+			return CostHeavy
+		}
+	}
+	return CostLight
+}
+
+// EstimateSize estimates each step from the estimate of the one before.
+func (c *chainConverter) EstimateSize(srcExt, targetExt string, inputSize int64, opts Options) int64 {
+	size := inputSize
+	for _, s := range c.steps {
+		if size = EstimateSize(s.Converter, s.Source, s.Target, size, opts); size == 0 {
+			return 0
+		}
+	}
+	return size
+}
+
+// OptionSpecs is the union of the options every step declares.
+func (c *chainConverter) OptionSpecs(srcExt, targetExt string) []OptionSpec {
+	var specs []OptionSpec
+	seen := make(map[string]bool)
+	for _, s := range c.steps {
+		for _, spec := range OptionSpecsFor(s.Converter, s.Source, s.Target) {
+			if !seen[spec.Name] {
+				seen[spec.Name] = true
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return specs
+}
+
+func (c *chainConverter) Convert(src, target string, opts Options) error {
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
+
+// ConvertContext stops between steps, and inside steps that support it, when ctx is done.
+func (c *chainConverter) ConvertContext(ctx context.Context, src, target string, opts Options) error {
+	dir, err := os.MkdirTemp("", "golter_chain")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := src
+	for i, s := range c.steps {
+		output := target
+		if i < len(c.steps)-1 {
+			output = filepath.Join(dir, fmt.Sprintf("step%d%s", i+1, s.Target))
+		}
+		if err := ConvertContext(stepProgress(ctx, i, len(c.steps)), s.Converter, input, output, opts); err != nil {
+			return fmt.Errorf("failed to convert %s to %s: %w", s.Source, s.Target, err)
+		}
+		ReportProgress(ctx, float64(i+1)/float64(len(c.steps)))
+		input = output
+	}
+	return nil
+}
+
+// stepProgress scales the progress of step i of n into its share of the whole route.
+func stepProgress(ctx context.Context, i, n int) context.Context {
+	if !hasProgress(ctx) {
+		return ctx
+	}
+	return WithProgress(ctx, func(fraction float64) {
+		ReportProgress(ctx, (float64(i)+fraction)/float64(n))
+	})
+}
+
+func (c *chainConverter) CanStream(srcExt, targetExt string) bool {
+	for _, s := range c.steps {
+		sc, ok := s.Converter.(StreamConverter)
+		if !ok || !sc.CanStream(s.Source, s.Target) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *chainConverter) ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	for i, s := range c.steps {
+		out := w
+		var buf bytes.Buffer
+		if i < len(c.steps)-1 {
+			out = &buf
+		}
+		if err := s.Converter.(StreamConverter).ConvertStream(r, out, s.Source, s.Target, opts); err != nil {
+			return fmt.Errorf("failed to convert %s to %s: %w", s.Source, s.Target, err)
+		}
+		r = &buf
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// appendConverter returns a MockConverter whose conversion appends tag to the file contents.
+func appendConverter(name, tag string, targets map[string][]string) *MockConverter {
+	var sources []string
+	for src := range targets {
+		sources = append(sources, src)
+	}
+	return &MockConverter{
+		name:             name,
+		supportedSources: sources,
+		supportedTargets: targets,
+		convertFunc: func(src, target string, opts Options) error {
+			data, err := os.ReadFile(src)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, append(data, tag...), 0644)
+		},
+	}
+}
+
+// toolConverter is a MockConverter that reports an external tool requirement.
+type toolConverter struct {
+	*MockConverter
+}
+
+func (c *toolConverter) RequiredTools(srcExt, targetExt string) []string { return []string{"tool"} }
+
+func TestManager_FindRoute(t *testing.T) {
+	m := NewManager()
+	m.Register(appendConverter("AB", "b", map[string][]string{".a": {".b"}}))
+	m.Register(appendConverter("BC", "c", map[string][]string{".b": {".c"}}))
+	m.Register(appendConverter("CD", "d", map[string][]string{".c": {".d"}}))
+	m.Register(appendConverter("DE", "e", map[string][]string{".d": {".e"}}))
+
+	steps, err := m.FindRoute(".a", ".c")
+	if err != nil {
+		t.Fatalf("FindRoute failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Target != ".b" || steps[1].Converter.Name() != "BC" {
+		t.Errorf("unexpected route: %+v", steps)
+	}
+
+	if _, err := m.FindRoute(".a", ".e"); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected routes longer than %d steps to be rejected, got %v", MaxRouteSteps, err)
+	}
+	if _, err := m.FindRoute(".c", ".a"); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected no route backwards, got %v", err)
+	}
+
+	targets := m.GetSupportedTargetFormats(".a")
+	sort.Strings(targets)
+	if want := []string{".b", ".c", ".d"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("targets = %v, want %v", targets, want)
+	}
+}
+
+func TestManager_FindRoute_PrefersInProcessSteps(t *testing.T) {
+	m := NewManager()
+	m.Register(&toolConverter{appendConverter("Tool", "t", map[string][]string{".a": {".t"}, ".t": {".z"}})})
+	m.Register(appendConverter("Native1", "n", map[string][]string{".a": {".n"}}))
+	m.Register(appendConverter("Native2", "m", map[string][]string{".n": {".m"}}))
+	m.Register(appendConverter("Native3", "z", map[string][]string{".m": {".z"}}))
+
+	steps, err := m.FindRoute(".a", ".z")
+	if err != nil {
+		t.Fatalf("FindRoute failed: %v", err)
+	}
+	if len(steps) != 3 || steps[0].Converter.Name() != "Native1" {
+		t.Errorf("expected the three native steps, got %+v", steps)
+	}
+}
+
+func TestChainConverter_Convert(t *testing.T) {
+	m := NewManager()
+	m.Register(appendConverter("AB", "b", map[string][]string{".a": {".b"}}))
+	m.Register(appendConverter("BC", "c", map[string][]string{".b": {".c"}}))
+
+	c, err := m.FindConverter(".a", ".c")
+	if err != nil {
+		t.Fatalf("FindConverter failed: %v", err)
+	}
+	if c.Name() != "AB + BC via .b" {
+		t.Errorf("unexpected name %q", c.Name())
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.a")
+	target := filepath.Join(dir, "out.c")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Convert(src, target, nil); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "abc" {
+		t.Errorf("output = %q, want abc", data)
+	}
+
+	var routed *Conversion
+	for _, conv := range m.Conversions() {
+		if conv.Source == ".a" && conv.Target == ".c" {
+			routed = &conv
+		}
+	}
+	if routed == nil || !reflect.DeepEqual(routed.Via, []string{".b"}) {
+		t.Errorf("unexpected routed conversion: %+v", routed)
+	}
+}
+
+func TestChainConverter_Stream(t *testing.T) {
+	m := NewDefaultManager()
+
+	var out bytes.Buffer
+	if err := m.ConvertStream(strings.NewReader("title = \"golter\"\n"), &out, ".toml", ".json", nil); err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"title": "golter"`) {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Command is one run of an external tool.
+type Command struct {
+	// Tool is the program's name, such as "ffmpeg"; the Runner decides which
+	// binary runs it.
+	Tool string
+	Args []string
+	// Stdout receives the tool's standard output when set. Otherwise it is
+	// returned by Run together with standard error.
+	Stdout io.Writer
+	// Env holds extra "KEY=value" variables for this run only.
+	Env []string
+}
+
+// Runner runs the external tools converters depend on. ExecRunner runs them
+// as processes; RecordingRunner stands in for them in tests.
+type Runner interface {
+	// LookPath returns the binary that runs tool, or an error when it is
+	// not installed.
+	LookPath(tool string) (string, error)
+	// Run runs cmd until it exits or ctx is done and returns what it
+	// printed: standard output and error combined, or only standard error
+	// when cmd.Stdout is set.
+	Run(ctx context.Context, cmd Command) ([]byte, error)
+}
+
+type runnerKey struct{}
+
+// WithRunner returns a context whose conversions, tool probes and output
+// verification run external tools through r.
+func WithRunner(ctx context.Context, r Runner) context.Context {
+	return context.WithValue(ctx, runnerKey{}, r)
+}
+
+// RunnerFrom returns the Runner carried by ctx, or an ExecRunner with the
+// default settings.
+func RunnerFrom(ctx context.Context) Runner {
+	if r, ok := ctx.Value(runnerKey{}).(Runner); ok && r != nil {
+		return r
+	}
+	return ExecRunner{}
+}
+
+// ExecRunner runs tools as child processes.
+type ExecRunner struct {
+	// Paths maps tool names to the binary to run, e.g. "ffmpeg" to
+	// "/opt/ffmpeg/bin/ffmpeg". Other tools are looked up on PATH.
+	Paths map[string]string
+	// Env holds extra "KEY=value" variables added to golter's environment.
+	Env []string
+	// Dir is the working directory tools run in. Empty means golter's.
+	Dir string
+	// Timeout stops a tool that runs longer than this. Zero means no limit.
+	Timeout time.Duration
+}
+
+func (r ExecRunner) LookPath(tool string) (string, error) {
+	if p := r.Paths[tool]; p != "" {
+		return exec.LookPath(p)
+	}
+	return exec.LookPath(tool)
+}
+
+func (r ExecRunner) Run(ctx context.Context, cmd Command) ([]byte, error) {
+	path, err := r.LookPath(cmd.Tool)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx := ctx
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	c := exec.CommandContext(runCtx, path, cmd.Args...)
+	c.Dir = r.Dir
+	if len(r.Env) > 0 || len(cmd.Env) > 0 {
+		c.Env = slices.Concat(os.Environ(), r.Env, cmd.Env)
+	}
+	var output bytes.Buffer
+	c.Stderr = &output
+	c.Stdout = &output
+	if cmd.Stdout != nil {
+		c.Stdout = cmd.Stdout
+	}
+
+	err = c.Run()
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", r.Timeout)
+	}
+	return output.Bytes(), err
+}
+
+// RecordingRunner is a Runner for tests. It records every command instead of
+// running it and reports every tool as installed unless listed in Missing.
+type RecordingRunner struct {
+	// Missing lists the tools LookPath reports as not installed.
+	Missing []string
+	// Respond, when set, is called for every command and returns what the
+	// tool printed and how it failed. Without it commands succeed silently.
+	// Recorded commands write no files, so a conversion only succeeds when
+	// Respond creates its output.
+	Respond func(Command) ([]byte, error)
+
+	mu       sync.Mutex
+	commands []Command
+}
+
+func (r *RecordingRunner) LookPath(tool string) (string, error) {
+	if slices.Contains(r.Missing, tool) {
+		return "", &exec.Error{Name: tool, Err: exec.ErrNotFound}
+	}
+	return filepath.Join("/recorded", tool), nil
+}
+
+func (r *RecordingRunner) Run(ctx context.Context, cmd Command) ([]byte, error) {
+	if _, err := r.LookPath(cmd.Tool); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.commands = append(r.commands, Command{Tool: cmd.Tool, Args: slices.Clone(cmd.Args), Env: slices.Clone(cmd.Env)})
+	r.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if r.Respond == nil {
+		return nil, nil
+	}
+	return r.Respond(cmd)
+}
+
+// Commands returns the commands run so far, in order.
+func (r *RecordingRunner) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.commands)
+}
+
+// absPath makes path absolute, so tools find it whatever directory the
+// Runner starts them in.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
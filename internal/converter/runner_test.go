@@ -0,0 +1,212 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedTarget returns the output path a recorded command was given: the
+// temporary file ConvertContext created next to target.
+func recordedTarget(t *testing.T, args []string, target string) string {
+	t.Helper()
+	for _, arg := range args {
+		if filepath.Dir(arg) == filepath.Dir(target) && strings.HasSuffix(arg, filepath.Ext(target)) && strings.Contains(arg, ".golter-") {
+			return arg
+		}
+	}
+	t.Fatalf("no temporary output for %s in %q", target, args)
+	return ""
+}
+
+// writeOutput stands in for a tool by creating the temporary output a
+// recorded command was given.
+func writeOutput(cmd Command) ([]byte, error) {
+	for _, arg := range cmd.Args {
+		if strings.Contains(filepath.Base(arg), ".golter-") {
+			return nil, os.WriteFile(arg, []byte("out"), 0644)
+		}
+	}
+	return nil, nil
+}
+
+func TestRecordingRunner_FFmpegArgs(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		conv      Converter
+		src, dst  string
+		opts      Options
+		buildArgs func(src, target string, opts Options) []string
+	}{
+		{
+			name: "audio", conv: &AudioConverter{}, src: "song.wav", dst: "song.mp3",
+			opts: Options{"quality": QualityCompact},
+			buildArgs: func(src, target string, opts Options) []string {
+				return buildAudioFFmpegArgs(src, target, parseAudioQuality(opts), 3)
+			},
+		},
+		{
+			name: "video", conv: &VideoConverter{}, src: "clip.mov", dst: "clip.webm",
+			opts: Options{"quality": QualityHigh},
+			buildArgs: func(src, target string, opts Options) []string {
+				return buildFFmpegArgs(src, target, parseVideoQuality(opts), 3)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &RecordingRunner{Respond: writeOutput}
+			src, dst := filepath.Join(dir, tt.src), filepath.Join(dir, tt.dst)
+			if err := ConvertContext(WithThreads(WithRunner(context.Background(), rec), 3), tt.conv, src, dst, tt.opts); err != nil {
+				t.Fatalf("ConvertContext failed: %v", err)
+			}
+
+			cmds := rec.Commands()
+			if len(cmds) != 1 || cmds[0].Tool != "ffmpeg" {
+				t.Fatalf("expected one ffmpeg command, got %+v", cmds)
+			}
+			want := tt.buildArgs(src, recordedTarget(t, cmds[0].Args, dst), tt.opts)
+			if !reflect.DeepEqual(cmds[0].Args, want) {
+				t.Errorf("args = %q\nwant   %q", cmds[0].Args, want)
+			}
+		})
+	}
+}
+
+func TestRecordingRunner_DocumentTools(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"notes.md", "book.epub"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		src, dst string
+		opts     Options
+		tool     string
+		extra    []string
+	}{
+		{"notes.md", "notes.docx", Options{"pandocArgs": []string{"--toc"}}, "pandoc", []string{"--toc"}},
+		{"book.epub", "book.mobi", Options{"ebookArgs": []string{"--pretty-print"}}, "ebook-convert", []string{"--pretty-print"}},
+	}
+	for _, tt := range tests {
+		rec := &RecordingRunner{Respond: writeOutput}
+		src, dst := filepath.Join(dir, tt.src), filepath.Join(dir, tt.dst)
+		if err := ConvertContext(WithRunner(context.Background(), rec), &DocumentConverter{}, src, dst, tt.opts); err != nil {
+			t.Fatalf("%s: %v", tt.tool, err)
+		}
+		cmds := rec.Commands()
+		if len(cmds) != 1 || cmds[0].Tool != tt.tool {
+			t.Fatalf("expected one %s command, got %+v", tt.tool, cmds)
+		}
+		args := cmds[0].Args
+		if args[0] != src || !reflect.DeepEqual(args[len(args)-len(tt.extra):], tt.extra) {
+			t.Errorf("%s args = %q", tt.tool, args)
+		}
+		recordedTarget(t, args, dst)
+	}
+}
+
+func TestRecordingRunner_MissingAndFailingTools(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "a.wav"), filepath.Join(dir, "a.mp3")
+
+	rec := &RecordingRunner{Missing: []string{"ffmpeg"}}
+	err := ConvertContext(WithRunner(context.Background(), rec), &AudioConverter{}, src, dst, nil)
+	if !errors.Is(err, ErrMissingTool) || len(rec.Commands()) != 0 {
+		t.Errorf("expected a missing tool error without running anything, got %v", err)
+	}
+
+	rec = &RecordingRunner{Respond: func(Command) ([]byte, error) {
+		return []byte("Unknown encoder 'libmp3lame'"), errors.New("exit status 1")
+	}}
+	err = ConvertContext(WithRunner(context.Background(), rec), &AudioConverter{}, src, dst, nil)
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Tool != "ffmpeg" || toolErr.Stderr != "Unknown encoder 'libmp3lame'" {
+		t.Errorf("expected an ffmpeg ToolError, got %v", err)
+	}
+}
+
+func TestRecordingRunner_FFmpegProgress(t *testing.T) {
+	dir := t.TempDir()
+	rec := &RecordingRunner{Respond: func(cmd Command) ([]byte, error) {
+		switch cmd.Tool {
+		case "ffprobe":
+			fmt.Fprintln(cmd.Stdout, "10.0")
+		case "ffmpeg":
+			fmt.Fprintln(cmd.Stdout, "out_time_us=5000000")
+			fmt.Fprintln(cmd.Stdout, "progress=end")
+			return writeOutput(cmd)
+		}
+		return nil, nil
+	}}
+
+	var last float64
+	ctx := WithProgress(WithRunner(context.Background(), rec), func(f float64) { last = f })
+	if err := ConvertContext(ctx, &AudioConverter{}, filepath.Join(dir, "a.wav"), filepath.Join(dir, "a.ogg"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if last != 1 {
+		t.Errorf("expected progress to reach 1, got %v", last)
+	}
+	cmds := rec.Commands()
+	if len(cmds) != 2 || cmds[0].Tool != "ffprobe" || cmds[1].Args[0] != "-progress" {
+		t.Errorf("unexpected commands %+v", cmds)
+	}
+}
+
+func TestProbeTools_UsesRunner(t *testing.T) {
+	rec := &RecordingRunner{Missing: []string{"pandoc"}, Respond: func(cmd Command) ([]byte, error) {
+		fmt.Fprintln(cmd.Stdout, cmd.Tool+" version 1.0")
+		return nil, nil
+	}}
+	caps := ProbeTools(WithRunner(context.Background(), rec), "ffprobe", "pandoc")
+	if ffprobe, _ := caps.Tool("ffprobe"); !ffprobe.Available || ffprobe.Version != "ffprobe version 1.0" || ffprobe.Path != "/recorded/ffprobe" {
+		t.Errorf("unexpected ffprobe probe %+v", ffprobe)
+	}
+	if caps.HasTool("pandoc") {
+		t.Error("expected pandoc to be reported missing")
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	r := ExecRunner{
+		Paths: map[string]string{"fake": sh},
+		Env:   []string{"GOLTER_RUNNER_TEST=set"},
+		Dir:   dir,
+	}
+
+	if path, err := r.LookPath("fake"); err != nil || path != sh {
+		t.Errorf("LookPath = %q, %v; want %q", path, err, sh)
+	}
+	out, err := r.Run(context.Background(), Command{Tool: "fake", Args: []string{"-c", "echo $GOLTER_RUNNER_TEST; pwd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, _ := filepath.EvalSymlinks(dir)
+	if got := strings.TrimSpace(string(out)); got != "set\n"+dir && got != "set\n"+resolved {
+		t.Errorf("output = %q", got)
+	}
+
+	r.Timeout = 50 * time.Millisecond
+	_, err = r.Run(context.Background(), Command{Tool: "fake", Args: []string{"-c", "exec sleep 5"}})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Error("a tool timeout should not read as cancellation")
+	}
+}
@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ConvertStream converts srcExt data read from r into targetExt data written to w.
+// Converters that work on bytes stream without touching disk; file-based ones
+// (ffmpeg, Pandoc, Calibre) are spooled through temporary files.
+func (m *Manager) ConvertStream(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	return m.ConvertStreamContext(context.Background(), r, w, srcExt, targetExt, opts)
+}
+
+// ConvertStreamContext is ConvertStream with a context: spooled conversions
+// run their tools through the Runner ctx carries and stop when it is done.
+func (m *Manager) ConvertStreamContext(ctx context.Context, r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	srcExt = NormalizeExt(srcExt)
+	targetExt = NormalizeExt(targetExt)
+
+	c, err := m.FindConverter(srcExt, targetExt)
+	if err != nil {
+		return err
+	}
+	if err := ValidateOptions(OptionSpecsFor(c, srcExt, targetExt), opts); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+
+	if sc, ok := c.(StreamConverter); ok && sc.CanStream(srcExt, targetExt) {
+		return sc.ConvertStream(r, w, srcExt, targetExt, opts)
+	}
+	return spoolConvert(ctx, c, r, w, srcExt, targetExt, opts)
+}
+
+// spoolConvert runs a file-based conversion by writing r to a temp file and
+// copying the converted temp output to w.
+func spoolConvert(ctx context.Context, c Converter, r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	dir, err := os.MkdirTemp("", "golter_stream")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "input"+srcExt)
+	target := filepath.Join(dir, "output"+targetExt)
+
+	in, err := os.Create(src)
+	if err != nil {
+		return fmt.Errorf("failed to create temp input: %w", err)
+	}
+	if _, err := io.Copy(in, r); err != nil {
+		in.Close()
+		return fmt.Errorf("failed to spool input: %w", err)
+	}
+	if err := in.Close(); err != nil {
+		return fmt.Errorf("failed to spool input: %w", err)
+	}
+
+	if err := ConvertContext(ctx, c, src, target, opts); err != nil {
+		return err
+	}
+
+	out, err := os.Open(target)
+	if err != nil {
+		return fmt.Errorf("failed to open converted output: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(w, out); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_ConvertStream_Native(t *testing.T) {
+	m := NewDefaultManager()
+
+	var out bytes.Buffer
+	if err := m.ConvertStream(strings.NewReader(`{"a":1}`), &out, "json", ".yaml", Options{}); err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "a: 1" {
+		t.Errorf("unexpected yaml output %q", out.String())
+	}
+
+	out.Reset()
+	if err := m.ConvertStream(strings.NewReader("<h1>Title</h1>"), &out, ".html", ".md", Options{}); err != nil {
+		t.Fatalf("ConvertStream(html->md) failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "# Title") {
+		t.Errorf("unexpected markdown output %q", out.String())
+	}
+}
+
+func TestManager_ConvertStream_Image(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "in.png")
+	createTestImage(t, src)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := NewDefaultManager().ConvertStream(bytes.NewReader(data), &out, ".png", ".jpg", Options{"quality": "Compact"}); err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+	if _, format, err := image.DecodeConfig(&out); err != nil || format != "jpeg" {
+		t.Errorf("expected jpeg output, got %q (%v)", format, err)
+	}
+}
+
+func TestManager_ConvertStream_Spooled(t *testing.T) {
+	// A converter without stream support is run through temp files.
+	m := NewManager()
+	m.Register(&MockConverter{
+		name:             "Copy",
+		supportedSources: []string{".a"},
+		supportedTargets: map[string][]string{".a": {".b"}},
+		convertFunc: func(src, target string, opts Options) error {
+			if filepath.Ext(src) != ".a" || filepath.Ext(target) != ".b" {
+				t.Errorf("unexpected temp paths %s -> %s", src, target)
+			}
+			data, err := os.ReadFile(src)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, bytes.ToUpper(data), 0644)
+		},
+	})
+
+	var out bytes.Buffer
+	if err := m.ConvertStream(strings.NewReader("spooled"), &out, ".a", ".b", Options{}); err != nil {
+		t.Fatalf("ConvertStream failed: %v", err)
+	}
+	if out.String() != "SPOOLED" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestImageConverter_ConvertStream_InvalidInput(t *testing.T) {
+	c := &ImageConverter{}
+	var out bytes.Buffer
+	if err := c.ConvertStream(strings.NewReader("not an image"), &out, ".png", ".webp", Options{}); err == nil {
+		t.Error("ConvertStream should fail for invalid image data")
+	}
+}
+
+func TestManager_ConvertStreamContext_Runner(t *testing.T) {
+	m := NewDefaultManager()
+	rec := &RecordingRunner{Respond: writeOutput}
+	ctx := WithRunner(context.Background(), rec)
+
+	var out bytes.Buffer
+	if err := m.ConvertStreamContext(ctx, strings.NewReader("RIFF"), &out, ".wav", ".mp3", Options{}); err != nil {
+		t.Fatalf("ConvertStreamContext failed: %v", err)
+	}
+	if cmds := rec.Commands(); len(cmds) != 1 || cmds[0].Tool != "ffmpeg" {
+		t.Errorf("expected ffmpeg to run through the context's runner, got %+v", cmds)
+	}
+	if out.String() != "out" {
+		t.Errorf("expected the spooled output, got %q", out.String())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err := m.ConvertStreamContext(cancelled, strings.NewReader("RIFF"), &out, ".wav", ".mp3", Options{})
+	if !errors.Is(err, ErrCancelled) {
+		t.Errorf("expected a cancelled conversion, got %v", err)
+	}
+}
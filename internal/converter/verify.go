@@ -0,0 +1,251 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF for verifying GIF outputs
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrVerification is wrapped by the error of a conversion whose output was
+// written but could not be read back as its format.
+var ErrVerification = errors.New("output verification failed")
+
+// minDurationDrift is the smallest difference between source and output
+// durations that fails verification; longer media may drift by
+// maxDurationDrift of their length instead.
+const (
+	minDurationDrift = time.Second
+	maxDurationDrift = 0.05
+)
+
+type verifyKey struct{}
+
+// WithVerification returns a context that makes ConvertContext verify every
+// output (see Verify) before moving it into place, so an output that fails
+// verification never replaces an existing file.
+func WithVerification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verifyKey{}, true)
+}
+
+func verifying(ctx context.Context) bool {
+	v, _ := ctx.Value(verifyKey{}).(bool)
+	return v
+}
+
+// Verify checks that output, converted from src, is a non-empty file that
+// reads back as the format its extension names: images are decoded, audio
+// and video are probed with ffprobe for streams and a duration close to the
+// source's, PDF, EPUB, DOCX and XLSX files are reopened, and data formats are
+// parsed. Formats without a reader are only checked for content. Failures
+// wrap ErrVerification.
+func Verify(ctx context.Context, src, output string) error {
+	if err := verifyOutput(ctx, src, output); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerification, err)
+	}
+	return nil
+}
+
+func verifyOutput(ctx context.Context, src, output string) error {
+	info, err := os.Stat(output)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("no output was written")
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return errors.New("output is empty")
+	}
+
+	switch ext := NormalizeExt(filepath.Ext(output)); ext {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+		return verifyImage(output)
+	case ".mp4", ".avi", ".mkv", ".webm", ".mov", ".mp3", ".wav", ".ogg", ".flac", ".m4a", ".aac":
+		return verifyMedia(ctx, src, output)
+	case ".pdf":
+		return verifyPDF(output)
+	case ".epub":
+		return verifyEPUB(output)
+	case ".docx":
+		return verifyZipEntry(output, "word/document.xml")
+	case ".xlsx":
+		return verifyXLSX(output)
+	case ".json", ".yaml", ".yml", ".toml", ".xml", ".csv":
+		return verifyData(output, ext)
+	}
+	return nil
+}
+
+func verifyImage(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return fmt.Errorf("cannot decode image: %w", err)
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return errors.New("image has no pixels")
+	}
+	return nil
+}
+
+// verifyMedia probes output for streams and compares its duration with
+// src's. Without ffprobe only the earlier size check applies.
+func verifyMedia(ctx context.Context, src, output string) error {
+	runner := RunnerFrom(ctx)
+	if _, err := runner.LookPath("ffprobe"); err != nil {
+		return nil
+	}
+	var stdout bytes.Buffer
+	_, err := runner.Run(ctx, Command{
+		Tool:   "ffprobe",
+		Args:   []string{"-v", "error", "-show_entries", "stream=codec_type:format=duration", "-of", "json", absPath(output)},
+		Stdout: &stdout,
+	})
+	if err != nil {
+		return fmt.Errorf("ffprobe cannot read the output: %w", err)
+	}
+	out := stdout.Bytes()
+
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	if len(probe.Streams) == 0 {
+		return errors.New("output has no audio or video streams")
+	}
+
+	secs, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil || secs <= 0 {
+		return nil
+	}
+	got := time.Duration(secs * float64(time.Second))
+	want := probeDuration(ctx, src)
+	if want <= 0 {
+		return nil
+	}
+	drift := max(minDurationDrift, time.Duration(float64(want)*maxDurationDrift))
+	if diff := got - want; diff > drift || -diff > drift {
+		return fmt.Errorf("output lasts %s but the source lasts %s", got.Round(time.Millisecond), want.Round(time.Millisecond))
+	}
+	return nil
+}
+
+func verifyPDF(path string) error {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open PDF: %w", err)
+	}
+	defer f.Close()
+	if r.NumPage() == 0 {
+		return errors.New("PDF has no pages")
+	}
+	return nil
+}
+
+func verifyEPUB(path string) error {
+	rc, err := epub.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("cannot open EPUB: %w", err)
+	}
+	defer rc.Close()
+	if len(rc.Rootfiles) == 0 || len(rc.Rootfiles[0].Spine.Itemrefs) == 0 {
+		return errors.New("EPUB has no content")
+	}
+	return nil
+}
+
+// verifyZipEntry checks that path is a ZIP archive containing name, as
+// Office documents are.
+func verifyZipEntry(path, name string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("cannot open document: %w", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("document has no %s", name)
+}
+
+func verifyXLSX(path string) error {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot open spreadsheet: %w", err)
+	}
+	defer f.Close()
+	if len(f.GetSheetList()) == 0 {
+		return errors.New("spreadsheet has no sheets")
+	}
+	return nil
+}
+
+func verifyData(path, ext string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(data, &v)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &v)
+	case ".toml":
+		err = toml.Unmarshal(data, &v)
+	case ".xml":
+		err = verifyXML(data)
+	case ".csv":
+		_, err = csv.NewReader(bytes.NewReader(data)).ReadAll()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", strings.ToUpper(strings.TrimPrefix(ext, ".")), err)
+	}
+	return nil
+}
+
+// verifyXML reads every token of data, which fails on malformed documents.
+func verifyXML(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
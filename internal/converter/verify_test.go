@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.AddPage()
+	pdfPath := filepath.Join(dir, "good.pdf")
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := excelize.NewFile()
+	xlsxPath := filepath.Join(dir, "good.xlsx")
+	if err := sheet.SaveAs(xlsxPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	if _, err := zw.Create("word/document.xml"); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	valid := []string{
+		write("good.png", img.Bytes()),
+		pdfPath,
+		xlsxPath,
+		write("good.docx", docx.Bytes()),
+		write("good.json", []byte(`{"a":[1,2]}`)),
+		write("good.yaml", []byte("a: [1, 2]\n")),
+		write("good.toml", []byte("a = [1, 2]\n")),
+		write("good.xml", []byte("<a><b>1</b></a>")),
+		write("good.csv", []byte("a,b\n1,2\n")),
+		write("notes.md", []byte("# Notes\n")),
+	}
+	for _, path := range valid {
+		if err := Verify(context.Background(), "", path); err != nil {
+			t.Errorf("Verify(%s) failed: %v", filepath.Base(path), err)
+		}
+	}
+
+	invalid := []string{
+		filepath.Join(dir, "missing.png"),
+		write("empty.md", nil),
+		write("bad.png", []byte("not an image")),
+		write("bad.pdf", []byte("%PDF-1.4 truncated")),
+		write("bad.xlsx", []byte("not a workbook")),
+		write("bad.docx", img.Bytes()),
+		write("bad.json", []byte(`{"a":`)),
+		write("bad.xml", []byte("<a><b></a>")),
+		write("bad.csv", []byte("a,\"b\n")),
+	}
+	for _, path := range invalid {
+		if err := Verify(context.Background(), "", path); !errors.Is(err, ErrVerification) {
+			t.Errorf("Verify(%s) = %v, want ErrVerification", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestConvertContext_VerificationKeepsExistingTarget(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(target, []byte(`{"old":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &MockConverter{convertFunc: func(src, target string, opts Options) error {
+		return os.WriteFile(target, []byte(`{"truncated":`), 0644)
+	}}
+
+	// Without verification the broken output counts as converted
+	if err := ConvertContext(context.Background(), c, "in.yaml", target, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(target, []byte(`{"old":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ConvertContext(WithVerification(context.Background()), c, "in.yaml", target, nil)
+	if !errors.Is(err, ErrVerification) {
+		t.Fatalf("expected ErrVerification, got %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != `{"old":true}` {
+		t.Errorf("existing target was replaced: %q", data)
+	}
+	assertNoTempOutputs(t, filepath.Dir(target))
+}
@@ -1,9 +1,8 @@
 package converter
 
 import (
-	"fmt"
-	"os/exec"
-	"runtime"
+	"context"
+	"strconv"
 	"strings"
 )
 
@@ -34,27 +33,43 @@ func (c *VideoConverter) SupportedTargetFormats(srcExt string) []string {
 	return []string{".mp4", ".avi", ".mkv", ".webm", ".gif", ".mov"}
 }
 
+func (c *VideoConverter) RequiredTools(srcExt, targetExt string) []string {
+	return []string{"ffmpeg"}
+}
+
+// videoEncoders are the ffmpeg encoders buildFFmpegArgs picks per target.
+var videoEncoders = map[string][]string{
+	".gif":  {"gif"},
+	".webm": {"libvpx-vp9", "libopus"},
+	".mp4":  {"libx264", "aac"},
+	".mkv":  {"libx265", "aac"},
+	".avi":  {"mpeg4", "libmp3lame"}, // what ffmpeg encodes -c:a mp3 with
+	".mov":  {"libx264", "aac"},
+}
+
+func (c *VideoConverter) RequiredEncoders(srcExt, targetExt string) []string {
+	return videoEncoders[NormalizeExt(targetExt)]
+}
+
 func (c *VideoConverter) Convert(src, target string, opts Options) error {
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
+
+// ConvertContext is Convert with cancellation; ffmpeg is killed when ctx is done.
+func (c *VideoConverter) ConvertContext(ctx context.Context, src, target string, opts Options) error {
 	// Check if ffmpeg is installed
-	_, err := exec.LookPath("ffmpeg")
-	if err != nil {
-		return fmt.Errorf("ffmpeg not found: please install ffmpeg to convert videos (https://ffmpeg.org)")
+	if err := lookTool(ctx, "ffmpeg", "ffmpeg to convert videos (https://ffmpeg.org)"); err != nil {
+		return err
 	}
 
 	// Parse quality setting
 	quality := parseVideoQuality(opts)
 
 	// Build ffmpeg arguments
-	args := buildFFmpegArgs(src, target, quality)
+	args := buildFFmpegArgs(absPath(src), absPath(target), quality, Threads(ctx))
 
 	// Execute ffmpeg
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
-	}
-
-	return nil
+	return runFFmpeg(ctx, src, args)
 }
 
 // videoQuality holds encoding parameters
@@ -72,28 +87,68 @@ func parseVideoQuality(opts Options) videoQuality {
 		audioBr: "192k",   // Default audio bitrate
 	}
 
-	if qualityStr, ok := opts["quality"].(string); ok {
-		switch {
-		case strings.Contains(qualityStr, "High"):
-			q.crf = "18"
-			q.preset = "slow"
-			q.audioBr = "256k"
-		case strings.Contains(qualityStr, "Balanced"), strings.Contains(qualityStr, "Medium"):
-			q.crf = "23"
-			q.preset = "medium"
-			q.audioBr = "192k"
-		case strings.Contains(qualityStr, "Compact"), strings.Contains(qualityStr, "Low"):
-			q.crf = "28"
-			q.preset = "fast"
-			q.audioBr = "128k"
-		}
+	switch QualityOf(opts) {
+	case QualityHigh:
+		q.crf = "18"
+		q.preset = "slow"
+		q.audioBr = "256k"
+	case QualityBalanced:
+		q.crf = "23"
+		q.preset = "medium"
+		q.audioBr = "192k"
+	case QualityCompact:
+		q.crf = "28"
+		q.preset = "fast"
+		q.audioBr = "128k"
+	}
+
+	// Explicit settings override the preset
+	if crf, ok := intOption(opts, "crf"); ok {
+		q.crf = strconv.Itoa(crf)
+	}
+	if preset := stringOption(opts, "preset"); preset != "" {
+		q.preset = strings.ToLower(preset)
+	}
+	if br := stringOption(opts, "audioBitrate"); br != "" {
+		q.audioBr = br
 	}
 
 	return q
 }
 
-// buildFFmpegArgs constructs optimized ffmpeg arguments
-func buildFFmpegArgs(src, target string, quality videoQuality) []string {
+// OptionSpecs declares the quality preset and the encoder settings it picks.
+// GIF output ignores everything but the preset.
+func (c *VideoConverter) OptionSpecs(srcExt, targetExt string) []OptionSpec {
+	specs := []OptionSpec{qualityOption}
+	if NormalizeExt(targetExt) == ".gif" {
+		return specs
+	}
+	return append(specs,
+		OptionSpec{
+			Name:        "crf",
+			Type:        OptionInt,
+			Description: "Constant rate factor; lower is better quality and larger files",
+			Min:         0,
+			Max:         51,
+		},
+		OptionSpec{
+			Name:        "preset",
+			Type:        OptionEnum,
+			Description: "Encoder speed preset; slower presets compress better",
+			Choices:     []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"},
+		},
+		OptionSpec{
+			Name:        "audioBitrate",
+			Type:        OptionEnum,
+			Description: "Audio track bitrate",
+			Choices:     audioBitrates,
+		},
+	)
+}
+
+// buildFFmpegArgs constructs optimized ffmpeg arguments, encoding with at
+// most threads threads
+func buildFFmpegArgs(src, target string, quality videoQuality, threads int) []string {
 	targetLower := strings.ToLower(target)
 
 	// Base arguments: input, overwrite, hide banner
@@ -104,8 +159,8 @@ func buildFFmpegArgs(src, target string, quality videoQuality) []string {
 		"-i", src,
 	}
 
-	// Use hardware acceleration if available
-	args = append(args, "-threads", fmt.Sprintf("%d", runtime.NumCPU()))
+	// Stay within the thread budget the batch scheduler gave this file
+	args = append(args, "-threads", strconv.Itoa(threads))
 
 	// Format-specific encoding
 	switch {
@@ -208,7 +208,7 @@ func TestBuildFFmpegArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			args := buildFFmpegArgs(tt.src, tt.target, q)
+			args := buildFFmpegArgs(tt.src, tt.target, q, 2)
 			if !tt.check(args) {
 				t.Errorf("buildFFmpegArgs() args = %v, failed check", args)
 			}
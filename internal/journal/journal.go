@@ -0,0 +1,453 @@
+// Package journal records the progress of a batch on disk as it runs, so a
+// batch that was interrupted, by Ctrl+C, a closed terminal or a machine going
+// to sleep, can be resumed without converting its finished files again.
+//
+// A journal is a JSON Lines file: a header describing the batch, followed by
+// one line per change of a file's state. Lines are appended and synced as
+// they happen, so the journal survives the process being killed; a torn last
+// line is ignored when the journal is read back.
+//
+// The process recording a journal holds a lock on it, so other golter
+// processes neither resume nor discard a batch that is still running.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// EnvDir overrides the directory journals are kept in.
+const EnvDir = "GOLTER_JOURNAL_DIR"
+
+// ext is the file extension of journals.
+const ext = ".jsonl"
+
+// ErrRunning is returned when a journal is held by a batch still running in
+// another golter process.
+var ErrRunning = errors.New("batch is still running in another golter process")
+
+// State is where a file stands in its batch.
+type State string
+
+const (
+	// StatePlanned files have not started.
+	StatePlanned State = "planned"
+	// StateRunning files started converting but never finished.
+	StateRunning State = "running"
+	// StateDone files were converted, skipped or reused from the cache.
+	StateDone State = "done"
+	// StateFailed files finished with an error.
+	StateFailed State = "failed"
+)
+
+// Spec is everything needed to run a batch again.
+type Spec struct {
+	Files     []string          `json:"files"`
+	TargetExt string            `json:"targetExt,omitempty"`
+	Quality   string            `json:"quality,omitempty"`
+	OutDir    string            `json:"outDir,omitempty"`
+	Jobs      int               `json:"jobs,omitempty"`
+	Options   converter.Options `json:"options,omitempty"`
+	Conflict  string            `json:"conflict,omitempty"`
+	Verify    bool              `json:"verify,omitempty"`
+	Cache     bool              `json:"cache,omitempty"`
+}
+
+// Item is the last recorded state of one file.
+type Item struct {
+	Path  string `json:"path"`
+	State State  `json:"state"`
+	// Output is where the file is being, or was, converted to.
+	Output string    `json:"output,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+type header struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Spec    Spec      `json:"spec"`
+}
+
+// Journal is the on-disk record of one batch. It is safe for concurrent use.
+type Journal struct {
+	ID      string
+	Created time.Time
+	Spec    Spec
+
+	path string
+
+	mu    sync.Mutex
+	f     *os.File
+	items map[string]Item
+	// err is the first failure to write, reported by Close.
+	err error
+}
+
+// Dir returns where journals are kept: $GOLTER_JOURNAL_DIR, or golter/journal
+// in the user cache directory.
+func Dir() (string, error) {
+	if d := os.Getenv(EnvDir); d != "" {
+		return d, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "golter", "journal"), nil
+}
+
+// Create starts a journal for spec in dir.
+func Create(dir string, spec Spec) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{
+		ID:      id,
+		Created: time.Now(),
+		Spec:    spec,
+		path:    filepath.Join(dir, id+ext),
+		items:   make(map[string]Item),
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		os.Remove(j.path)
+		return nil, fmt.Errorf("failed to lock journal: %w", err)
+	}
+	j.f = f
+	if err := j.append(header{ID: j.ID, Created: j.Created, Spec: spec}); err != nil {
+		f.Close()
+		os.Remove(j.path)
+		return nil, err
+	}
+	return j, nil
+}
+
+// Open reads the journal at path. Recording on it appends to the file.
+func Open(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("journal %s is empty", path)
+	}
+	var h header
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil || h.ID == "" {
+		return nil, fmt.Errorf("journal %s has no valid header", path)
+	}
+	h.Spec.Options = decodeOptions(h.Spec.Options)
+
+	j := &Journal{
+		ID:      h.ID,
+		Created: h.Created,
+		Spec:    h.Spec,
+		path:    path,
+		items:   make(map[string]Item),
+	}
+	for scanner.Scan() {
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil || item.Path == "" {
+			// A line torn by a crash; everything before it still counts
+			continue
+		}
+		j.items[item.Path] = item
+	}
+	return j, nil
+}
+
+// List returns the journals of interrupted batches in dir, newest first.
+// Files that are not valid journals, and journals of batches still running,
+// are skipped.
+func List(dir string) ([]*Journal, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+	var journals []*Journal
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ext {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if running(path) {
+			continue
+		}
+		if j, err := Open(path); err == nil {
+			journals = append(journals, j)
+		}
+	}
+	sort.SliceStable(journals, func(a, b int) bool { return journals[a].Created.After(journals[b].Created) })
+	return journals, nil
+}
+
+// Find returns the journal in dir whose ID starts with id, or the newest
+// one when id is empty.
+func Find(dir, id string) (*Journal, error) {
+	journals, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []*Journal
+	for _, j := range journals {
+		if strings.HasPrefix(j.ID, id) {
+			found = append(found, j)
+		}
+	}
+	switch {
+	case len(found) == 0 && id == "":
+		return nil, errors.New("no interrupted batch to resume")
+	case len(found) == 0:
+		return nil, fmt.Errorf("no interrupted batch %q", id)
+	case len(found) > 1 && id != "":
+		return nil, fmt.Errorf("%q matches %d batches; give more of the ID", id, len(found))
+	}
+	return found[0], nil
+}
+
+// Claim takes the journal over for this process, before resuming or
+// discarding its batch, so no other process does the same meanwhile. It
+// fails with ErrRunning when another process got there first. Close
+// releases the claim.
+func (j *Journal) Claim() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f != nil {
+		return nil
+	}
+	f, err := openAppend(j.path)
+	if err != nil {
+		return err
+	}
+	j.f = f
+	return nil
+}
+
+// File returns the journal's path.
+func (j *Journal) File() string {
+	return j.path
+}
+
+// Item returns the last recorded state of path.
+func (j *Journal) Item(path string) Item {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if item, ok := j.items[path]; ok {
+		return item
+	}
+	return Item{Path: path, State: StatePlanned}
+}
+
+// Start records that path started converting to output.
+func (j *Journal) Start(path, output string) {
+	j.record(Item{Path: path, State: StateRunning, Output: output})
+}
+
+// Done records that path finished; output is what it converted to.
+func (j *Journal) Done(path, output string) {
+	j.record(Item{Path: path, State: StateDone, Output: output})
+}
+
+// Fail records that path failed with err.
+func (j *Journal) Fail(path, output string, err error) {
+	j.record(Item{Path: path, State: StateFailed, Output: output, Error: err.Error()})
+}
+
+// Pending returns the files still to convert, in the order of the batch:
+// every file that is not done, and done files whose output has since
+// disappeared.
+func (j *Journal) Pending() []string {
+	var pending []string
+	for _, path := range j.Spec.Files {
+		item := j.Item(path)
+		if item.State == StateDone && (item.Output == "" || exists(item.Output)) {
+			continue
+		}
+		pending = append(pending, path)
+	}
+	return pending
+}
+
+// Progress returns how many files are done out of the batch.
+func (j *Journal) Progress() (done, total int) {
+	total = len(j.Spec.Files)
+	return total - len(j.Pending()), total
+}
+
+// CleanPartial removes the temporary outputs left behind by files that were
+// running when the batch stopped and returns their paths.
+func (j *Journal) CleanPartial() []string {
+	var removed []string
+	for _, path := range j.Spec.Files {
+		item := j.Item(path)
+		if item.State != StateRunning || item.Output == "" {
+			continue
+		}
+		for _, partial := range converter.PartialOutputs(item.Output) {
+			if os.Remove(partial) == nil {
+				removed = append(removed, partial)
+			}
+		}
+	}
+	return removed
+}
+
+// Close stops recording and returns the first error recording hit.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f != nil {
+		if err := j.f.Close(); err != nil && j.err == nil {
+			j.err = fmt.Errorf("failed to close journal: %w", err)
+		}
+		j.f = nil
+	}
+	return j.err
+}
+
+// Remove closes and deletes the journal, once its batch has finished.
+func (j *Journal) Remove() error {
+	j.Close()
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+// record appends item and keeps it as the file's state. Failures are kept
+// for Close rather than failing the conversion being recorded.
+func (j *Journal) record(item Item) {
+	item.Time = time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.items[item.Path] = item
+	if j.f == nil {
+		f, err := openAppend(j.path)
+		if err != nil {
+			j.fail(err)
+			return
+		}
+		j.f = f
+	}
+	j.fail(j.append(item))
+}
+
+// openAppend reopens and locks the journal at path for recording. A line
+// torn by a crash is terminated first so the next entry starts on a line of
+// its own.
+func openAppend(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock journal: %w", err)
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := f.Write([]byte{'\n'}); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to write journal: %w", err)
+			}
+		}
+	}
+	return f, nil
+}
+
+// append writes v as one synced line. j.mu must be held, or j unshared.
+func (j *Journal) append(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) fail(err error) {
+	if err != nil && j.err == nil {
+		j.err = err
+	}
+}
+
+// decodeOptions restores the option types JSON loses: lists of strings and
+// whole numbers.
+func decodeOptions(opts converter.Options) converter.Options {
+	for name, v := range opts {
+		switch v := v.(type) {
+		case float64:
+			if v == math.Trunc(v) {
+				opts[name] = int(v)
+			}
+		case []interface{}:
+			strs := make([]string, 0, len(v))
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					strs = append(strs, str)
+				}
+			}
+			opts[name] = strs
+		}
+	}
+	return opts
+}
+
+// newID returns a sortable, unique journal ID such as 20261017-153012-4f1c.
+func newID() (string, error) {
+	b := make([]byte, 2)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate journal ID: %w", err)
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b), nil
+}
+
+// running reports whether the journal at path is locked by the process
+// recording it.
+func running(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return errors.Is(lockFile(f), ErrRunning)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
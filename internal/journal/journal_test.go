@@ -0,0 +1,198 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJournal_RecordAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.mp4"), filepath.Join(dir, "b.mp4"), filepath.Join(dir, "c.mp4"), filepath.Join(dir, "d.mp4")}
+	spec := Spec{
+		Files:     files,
+		TargetExt: ".webm",
+		Quality:   "Balanced",
+		Options:   converter.Options{"crf": 20, "pandocArgs": []string{"--toc"}},
+		Conflict:  "rename",
+	}
+	j, err := Create(filepath.Join(dir, "journal"), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outA := filepath.Join(dir, "a.webm")
+	writeFile(t, outA)
+	j.Start(files[0], outA)
+	j.Done(files[0], outA)
+	j.Start(files[1], filepath.Join(dir, "b.webm"))
+	j.Start(files[2], filepath.Join(dir, "c.webm"))
+	j.Fail(files[2], filepath.Join(dir, "c.webm"), errors.New("ffmpeg failed"))
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash can tear the last line
+	f, err := os.OpenFile(j.File(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"path":"` + files[3] + `","sta`)
+	f.Close()
+
+	got, err := Open(j.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != j.ID || got.Spec.TargetExt != ".webm" || got.Spec.Conflict != "rename" {
+		t.Errorf("unexpected header %+v", got)
+	}
+	if !reflect.DeepEqual(got.Spec.Options, spec.Options) {
+		t.Errorf("options = %#v, want %#v", got.Spec.Options, spec.Options)
+	}
+	if s := got.Item(files[1]).State; s != StateRunning {
+		t.Errorf("b is %s, want running", s)
+	}
+	if item := got.Item(files[2]); item.State != StateFailed || item.Error != "ffmpeg failed" {
+		t.Errorf("unexpected c %+v", item)
+	}
+	if s := got.Item(files[3]).State; s != StatePlanned {
+		t.Errorf("d is %s, want planned", s)
+	}
+	if pending := got.Pending(); !reflect.DeepEqual(pending, files[1:]) {
+		t.Errorf("Pending = %v, want %v", pending, files[1:])
+	}
+
+	// A done file whose output went missing is converted again
+	os.Remove(outA)
+	if done, total := got.Progress(); done != 0 || total != 4 {
+		t.Errorf("Progress = %d/%d, want 0/4", done, total)
+	}
+
+	// Recording continues on the reopened journal
+	got.Done(files[3], filepath.Join(dir, "d.webm"))
+	got.Close()
+	again, err := Open(j.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := again.Item(files[3]).State; s != StateDone {
+		t.Errorf("d is %s after reopening, want done", s)
+	}
+}
+
+func TestJournal_CleanPartial(t *testing.T) {
+	dir := t.TempDir()
+	src, out := filepath.Join(dir, "a.mov"), filepath.Join(dir, "a.mp4")
+	j, err := Create(dir, Spec{Files: []string{src}, TargetExt: ".mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	tmp, err := converter.TempTarget(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, tmp)
+	j.Start(src, out)
+
+	removed := j.CleanPartial()
+	if len(removed) != 1 || removed[0] != tmp {
+		t.Errorf("CleanPartial = %v, want [%s]", removed, tmp)
+	}
+	if _, err := os.Stat(tmp); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected the partial output to be removed")
+	}
+}
+
+func TestList_FindAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	older, err := Create(dir, Spec{Files: []string{"a.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	older.Close()
+	newer, err := Create(dir, Spec{Files: []string{"b.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer.Close()
+	writeFile(t, filepath.Join(dir, "junk"+ext))
+
+	journals, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journals) != 2 || journals[0].ID != newer.ID {
+		t.Fatalf("expected two journals, newest first, got %d", len(journals))
+	}
+
+	if j, err := Find(dir, ""); err != nil || j.ID != newer.ID {
+		t.Errorf("Find(\"\") = %v, %v; want the newest", j, err)
+	}
+	if j, err := Find(dir, older.ID); err != nil || j.ID != older.ID {
+		t.Errorf("Find(%q) = %v, %v", older.ID, j, err)
+	}
+	if _, err := Find(dir, "nope"); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected an unknown ID error, got %v", err)
+	}
+
+	if err := newer.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if journals, _ := List(dir); len(journals) != 1 {
+		t.Errorf("expected one journal after Remove, got %d", len(journals))
+	}
+	if journals, err := List(filepath.Join(dir, "missing")); err != nil || journals != nil {
+		t.Errorf("a missing directory should hold no journals, got %v, %v", journals, err)
+	}
+}
+
+func TestList_SkipsRunning(t *testing.T) {
+	dir := t.TempDir()
+	running, err := Create(dir, Spec{Files: []string{"a.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer running.Close()
+
+	if journals, _ := List(dir); len(journals) != 0 {
+		t.Errorf("expected the running batch to be left out, got %d journals", len(journals))
+	}
+	if _, err := Find(dir, ""); err == nil {
+		t.Error("expected no batch to resume while it runs")
+	}
+	other, err := Open(running.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Claim(); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected ErrRunning claiming a running batch, got %v", err)
+	}
+
+	running.Close()
+	found, err := Find(dir, "")
+	if err != nil || found.ID != running.ID {
+		t.Fatalf("expected the interrupted batch, got %v, %v", found, err)
+	}
+	if err := found.Claim(); err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	defer found.Close()
+	if journals, _ := List(dir); len(journals) != 0 {
+		t.Error("expected a claimed batch to be left out")
+	}
+}
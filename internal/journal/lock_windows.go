@@ -0,0 +1,24 @@
+//go:build windows
+
+package journal
+
+import (
+	"errors"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on f that lasts until f is closed, or the
+// process exits. It fails with ErrRunning when another file holds it. The
+// locked byte lies far past the end of the journal, so reading it is not
+// blocked.
+func lockFile(f *os.File) error {
+	ol := &windows.Overlapped{Offset: math.MaxUint32, OffsetHigh: math.MaxInt32}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrRunning
+	}
+	return err
+}
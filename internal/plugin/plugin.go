@@ -0,0 +1,341 @@
+// Package plugin registers external converters: executables in a plugins
+// directory that describe the conversions they support and convert files on
+// request.
+//
+// A plugin describes itself with a JSON Manifest, either printed when run
+// with --describe or shipped next to the executable as <name>.json:
+//
+//	{
+//	  "name": "Acme Scans",
+//	  "priority": 10,
+//	  "cost": "light",
+//	  "conversions": [{"source": ".scan", "targets": [".png", ".pdf"]}],
+//	  "options": [{"name": "dpi", "type": "int", "description": "Output resolution", "min": 72, "max": 1200}]
+//	}
+//
+// A conversion runs the plugin as
+//
+//	<plugin> convert --from .scan --to .png [--option name=value]... <src> <target>
+//
+// A priority above 0 makes the plugin win over built-in converters that
+// handle the same pair. Plugins cost "heavy" unless the manifest says
+// "light" (see converter.Cost); GOLTER_THREADS holds the number of threads
+// a conversion may use. Only options the manifest declares are passed.
+// While converting, the plugin may print "progress=<fraction>" lines (0 to
+// 1) to stdout; other output is ignored. A non-zero exit status fails the
+// conversion, with stderr as the reason.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// describeTimeout bounds how long a plugin may take to answer --describe.
+const describeTimeout = 5 * time.Second
+
+// Manifest is what a plugin reports about itself.
+type Manifest struct {
+	Name string `json:"name"`
+	// Priority ranks the plugin against other converters for the same pair
+	// (see converter.Prioritizer).
+	Priority int `json:"priority,omitempty"`
+	// Cost classifies the plugin's conversions; empty means heavy.
+	Cost        converter.Cost `json:"cost,omitempty"`
+	Conversions []Conversion   `json:"conversions"`
+	// Options are accepted by every conversion.
+	Options []converter.OptionSpec `json:"options,omitempty"`
+}
+
+// Conversion lists the targets a plugin produces from one source format.
+type Conversion struct {
+	Source  string   `json:"source"`
+	Targets []string `json:"targets"`
+	// Options are accepted only by conversions from Source.
+	Options []converter.OptionSpec `json:"options,omitempty"`
+}
+
+// validate normalizes the manifest's extensions and checks it is usable.
+func (m *Manifest) validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errors.New("manifest has no name")
+	}
+	if len(m.Conversions) == 0 {
+		return errors.New("manifest lists no conversions")
+	}
+	if m.Cost != "" && !slices.Contains(converter.Costs, m.Cost) {
+		return fmt.Errorf("cost %q must be light or heavy", m.Cost)
+	}
+	for i := range m.Conversions {
+		conv := &m.Conversions[i]
+		conv.Source = converter.NormalizeExt(conv.Source)
+		if conv.Source == "" || len(conv.Targets) == 0 {
+			return fmt.Errorf("conversion %d needs a source and at least one target", i+1)
+		}
+		for j, t := range conv.Targets {
+			conv.Targets[j] = converter.NormalizeExt(t)
+		}
+	}
+	return nil
+}
+
+// Converter runs a plugin executable. It is a first-class converter.Converter,
+// including options and progress.
+type Converter struct {
+	path     string
+	manifest Manifest
+}
+
+// Path returns the plugin executable.
+func (c *Converter) Path() string {
+	return c.path
+}
+
+// Name returns the name from the plugin's manifest.
+func (c *Converter) Name() string {
+	return c.manifest.Name
+}
+
+// CanConvert checks if the plugin declares srcExt to targetExt.
+func (c *Converter) CanConvert(srcExt, targetExt string) bool {
+	return slices.Contains(c.SupportedTargetFormats(srcExt), converter.NormalizeExt(targetExt))
+}
+
+// SupportedSourceExtensions returns the sources the plugin declares.
+func (c *Converter) SupportedSourceExtensions() []string {
+	var exts []string
+	for _, conv := range c.manifest.Conversions {
+		if !slices.Contains(exts, conv.Source) {
+			exts = append(exts, conv.Source)
+		}
+	}
+	return exts
+}
+
+// SupportedTargetFormats returns the targets the plugin declares for srcExt.
+func (c *Converter) SupportedTargetFormats(srcExt string) []string {
+	srcExt = converter.NormalizeExt(srcExt)
+	var targets []string
+	for _, conv := range c.manifest.Conversions {
+		if conv.Source != srcExt {
+			continue
+		}
+		for _, t := range conv.Targets {
+			if !slices.Contains(targets, t) {
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets
+}
+
+// Priority returns the priority from the plugin's manifest.
+func (c *Converter) Priority(srcExt, targetExt string) int {
+	return c.manifest.Priority
+}
+
+// Cost returns the cost from the plugin's manifest. Plugins are external
+// programs, so they are heavy unless they say otherwise.
+func (c *Converter) Cost(srcExt, targetExt string) converter.Cost {
+	if c.manifest.Cost == "" {
+		return converter.CostHeavy
+	}
+	return c.manifest.Cost
+}
+
+// OptionSpecs returns the plugin-wide options and those of srcExt's conversions.
+func (c *Converter) OptionSpecs(srcExt, targetExt string) []converter.OptionSpec {
+	specs := slices.Clone(c.manifest.Options)
+	for _, conv := range c.manifest.Conversions {
+		if conv.Source == srcExt && slices.Contains(conv.Targets, targetExt) {
+			specs = append(specs, conv.Options...)
+		}
+	}
+	return specs
+}
+
+// Convert runs the plugin on src.
+func (c *Converter) Convert(src, target string, opts converter.Options) error {
+	return c.ConvertContext(context.Background(), src, target, opts)
+}
+
+// ConvertContext runs the plugin on src, killing it when ctx is cancelled and
+// forwarding the progress it prints.
+func (c *Converter) ConvertContext(ctx context.Context, src, target string, opts converter.Options) error {
+	srcExt, targetExt := converter.NormalizeExt(filepath.Ext(src)), converter.NormalizeExt(filepath.Ext(target))
+	args := []string{"convert", "--from", srcExt, "--to", targetExt}
+	for _, spec := range c.OptionSpecs(srcExt, targetExt) {
+		if v, ok := formatOption(opts[spec.Name]); ok {
+			args = append(args, "--option", spec.Name+"="+v)
+		}
+	}
+	// The runner may start the plugin in another directory
+	args = append(args, absPath(src), absPath(target))
+
+	pr, pw := io.Pipe()
+	parsed := make(chan struct{})
+	go func() {
+		defer close(parsed)
+		parseProgress(pr, func(fraction float64) {
+			converter.ReportProgress(ctx, fraction)
+		})
+		// Keep the plugin from blocking should parsing stop early
+		io.Copy(io.Discard, pr)
+	}()
+
+	stderr, err := converter.RunnerFrom(ctx).Run(ctx, converter.Command{
+		Tool:   c.path,
+		Args:   args,
+		Stdout: pw,
+		Env:    []string{"GOLTER_THREADS=" + strconv.Itoa(converter.Threads(ctx))},
+	})
+	pw.Close()
+	<-parsed
+	if err != nil {
+		return converter.NewToolError(c.Name(), err, stderr)
+	}
+	return nil
+}
+
+// parseProgress reads "progress=<fraction>" lines until r is exhausted.
+func parseProgress(r io.Reader, report func(float64)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || key != "progress" {
+			continue
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			report(f)
+		}
+	}
+}
+
+// formatOption renders an option value as plugin command-line text. Unset
+// and empty values are left to the plugin's defaults.
+func formatOption(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	case []string:
+		return strings.Join(v, " "), len(v) > 0
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// Describe loads the manifest of the plugin at path, from <path>.json when
+// present and otherwise by running it with --describe.
+func Describe(ctx context.Context, path string) (*Converter, error) {
+	path = absPath(path)
+	data, err := os.ReadFile(manifestPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+		defer cancel()
+		var stdout bytes.Buffer
+		_, err = converter.RunnerFrom(ctx).Run(ctx, converter.Command{Tool: path, Args: []string{"--describe"}, Stdout: &stdout})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe plugin: %w", err)
+		}
+		data = stdout.Bytes()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin manifest: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &Converter{path: path, manifest: m}, nil
+}
+
+// manifestPath returns where the sidecar manifest of the plugin at path lives.
+func manifestPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+// Load describes every plugin executable in dir. A missing dir has no
+// plugins. Plugins that cannot be described are skipped and reported in the
+// joined error, which does not prevent the others from loading.
+func Load(ctx context.Context, dir string) ([]*Converter, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var plugins []*Converter
+	var errs []error
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !isExecutable(path, e) {
+			continue
+		}
+		p, err := Describe(ctx, path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", e.Name(), err))
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, errors.Join(errs...)
+}
+
+// Register loads the plugins in dir into mgr. See Load for how errors are reported.
+func Register(ctx context.Context, mgr *converter.Manager, dir string) error {
+	plugins, err := Load(ctx, dir)
+	for _, p := range plugins {
+		mgr.Register(p)
+	}
+	return err
+}
+
+// isExecutable reports whether the directory entry is a plugin program.
+// Hidden files and sidecar manifests are never plugins.
+func isExecutable(path string, e os.DirEntry) bool {
+	name := e.Name()
+	if strings.HasPrefix(name, ".") || strings.EqualFold(filepath.Ext(name), ".json") {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode()&0111 != 0
+}
+
+// absPath makes path absolute, falling back to path itself.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
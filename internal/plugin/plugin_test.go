@@ -0,0 +1,256 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// upperScript describes itself, reports progress and writes its upper-cased
+// input followed by the options and thread budget it received.
+const upperScript = `#!/bin/sh
+if [ "$1" = "--describe" ]; then
+  echo '{"name":"Upper","conversions":[{"source":"txt","targets":[".UP"]}],"options":[{"name":"suffix","type":"string","description":"Appended text"}]}'
+  exit 0
+fi
+shift
+opts=""
+while [ "$1" != "${1#--}" ]; do
+  if [ "$1" = "--option" ]; then opts="$opts $2"; fi
+  shift 2
+done
+if grep -q fail "$1"; then echo "cannot convert" >&2; exit 3; fi
+echo "progress=0.5"
+tr a-z A-Z < "$1" > "$2"
+echo "$opts threads=$GOLTER_THREADS" >> "$2"
+echo "progress=1"
+`
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "upper", upperScript)
+	writePlugin(t, dir, "broken", "#!/bin/sh\necho not json\n")
+	// Not executable, so not a plugin
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("docs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := Load(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "plugin broken") {
+		t.Errorf("expected the broken plugin to be reported, got %v", err)
+	}
+	if len(plugins) != 1 {
+		t.Fatalf("expected one plugin, got %d", len(plugins))
+	}
+	p := plugins[0]
+	if p.Name() != "Upper" || !p.CanConvert(".txt", ".up") || p.CanConvert(".txt", ".pdf") {
+		t.Errorf("unexpected plugin description: %+v", p.manifest)
+	}
+	if specs := converter.OptionSpecsFor(p, ".txt", ".up"); len(specs) != 1 || specs[0].Name != "suffix" {
+		t.Errorf("expected the suffix option, got %+v", specs)
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	plugins, err := Load(context.Background(), filepath.Join(t.TempDir(), "none"))
+	if err != nil || len(plugins) != 0 {
+		t.Errorf("expected no plugins and no error, got %v, %v", plugins, err)
+	}
+}
+
+func TestDescribe_ManifestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "scan", "#!/bin/sh\nexit 1\n")
+	manifest := `{"name":"Scans","priority":10,"conversions":[{"source":".scan","targets":[".png"],"options":[{"name":"dpi","type":"int","description":"Resolution","min":72,"max":1200}]}]}`
+	if err := os.WriteFile(filepath.Join(dir, "scan.json"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Describe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	if p.Name() != "Scans" || !p.CanConvert(".scan", ".png") {
+		t.Errorf("unexpected plugin: %+v", p.manifest)
+	}
+	if got := converter.Priority(p, ".scan", ".png"); got != 10 {
+		t.Errorf("Priority = %d, want 10", got)
+	}
+	if specs := p.OptionSpecs(".scan", ".png"); len(specs) != 1 || specs[0].Max != 1200 {
+		t.Errorf("expected the dpi option, got %+v", specs)
+	}
+	if got := converter.CostOf(p, ".scan", ".png"); got != converter.CostHeavy {
+		t.Errorf("CostOf = %q, want heavy without a declared cost", got)
+	}
+	p.manifest.Cost = converter.CostLight
+	if got := converter.CostOf(p, ".scan", ".png"); got != converter.CostLight {
+		t.Errorf("CostOf = %q, want the declared light cost", got)
+	}
+}
+
+func TestDescribe_InvalidManifest(t *testing.T) {
+	tests := map[string]string{
+		"no name":        `{"conversions":[{"source":".a","targets":[".b"]}]}`,
+		"no conversions": `{"name":"Empty"}`,
+		"no targets":     `{"name":"Half","conversions":[{"source":".a"}]}`,
+		"unknown cost":   `{"name":"Odd","cost":"huge","conversions":[{"source":".a","targets":[".b"]}]}`,
+	}
+	for name, manifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writePlugin(t, dir, "p", "#!/bin/sh\necho '"+manifest+"'\n")
+			if _, err := Describe(context.Background(), path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConverter_Convert(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Describe(context.Background(), writePlugin(t, dir, "upper", upperScript))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "out.up")
+
+	var mu sync.Mutex
+	var progress []float64
+	ctx := converter.WithProgress(converter.WithThreads(context.Background(), 2), func(f float64) {
+		mu.Lock()
+		progress = append(progress, f)
+		mu.Unlock()
+	})
+	opts := converter.Options{"suffix": "!", "quality": "High"}
+	if err := converter.ConvertContext(ctx, p, src, target, opts); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "HELLO\n suffix=! threads=2\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if len(progress) != 2 || progress[0] != 0.5 || progress[1] != 1 {
+		t.Errorf("unexpected progress %v", progress)
+	}
+}
+
+func TestConverter_ConvertFailure(t *testing.T) {
+	dir := t.TempDir()
+	p, err := Describe(context.Background(), writePlugin(t, dir, "upper", upperScript))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("fail"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Convert(src, filepath.Join(dir, "out.up"), nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot convert") {
+		t.Errorf("expected the plugin's stderr in the error, got %v", err)
+	}
+}
+
+func TestConverter_ConvertLongOutput(t *testing.T) {
+	dir := t.TempDir()
+	// A line too long to scan, followed by more than a pipe holds
+	script := strings.Replace(upperScript, `echo "progress=0.5"`, `head -c 300000 /dev/zero | tr '\0' x; echo; head -c 300000 /dev/zero | tr '\0' y`, 1)
+	p, err := Describe(context.Background(), writePlugin(t, dir, "upper", script))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Convert(src, filepath.Join(dir, "out.up"), nil) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("conversion failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("conversion blocked on the plugin's output")
+	}
+}
+
+func TestConverter_Runner(t *testing.T) {
+	const manifest = `{"name":"Upper","conversions":[{"source":".txt","targets":[".up"]}]}`
+	runner := &converter.RecordingRunner{Respond: func(cmd converter.Command) ([]byte, error) {
+		if cmd.Args[0] == "--describe" {
+			io.WriteString(cmd.Stdout, manifest)
+			return nil, nil
+		}
+		target := cmd.Args[len(cmd.Args)-1]
+		return nil, os.WriteFile(target, []byte("HELLO"), 0644)
+	}}
+	ctx := converter.WithThreads(converter.WithRunner(context.Background(), runner), 3)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "upper")
+	p, err := Describe(ctx, path)
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	src := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := converter.ConvertContext(ctx, p, src, filepath.Join(dir, "out.up"), nil); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+
+	cmds := runner.Commands()
+	if len(cmds) != 2 || cmds[0].Tool != path || cmds[1].Tool != path {
+		t.Fatalf("expected the plugin to be described and run through the runner, got %+v", cmds)
+	}
+	if cmds[1].Args[0] != "convert" || !slices.Equal(cmds[1].Env, []string{"GOLTER_THREADS=3"}) {
+		t.Errorf("unexpected convert command %+v", cmds[1])
+	}
+}
+
+func TestRegister(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "upper", upperScript)
+
+	mgr := converter.NewDefaultManager()
+	if err := Register(context.Background(), mgr, dir); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	c, err := mgr.FindConverter(".txt", ".up")
+	if err != nil || c.Name() != "Upper" {
+		t.Errorf("expected the plugin to handle .txt to .up, got %v, %v", c, err)
+	}
+}
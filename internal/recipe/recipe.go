@@ -0,0 +1,269 @@
+package recipe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Recipe is a declarative list of conversions loaded from a YAML job file.
+//
+//	jobs:
+//	  - name: docs
+//	    inputs: ["docs/*.md"]
+//	    targets: [".pdf", ".epub"]
+//	    quality: balanced
+//	    out_dir: build/docs
+//	    options:
+//	      pandocArgs: ["--toc"]
+type Recipe struct {
+	Jobs []Task `yaml:"jobs"`
+
+	// Defaults fill in settings a task leaves unset.
+	Defaults Defaults `yaml:"-"`
+
+	// dir is the directory of the job file; relative paths resolve against it.
+	dir string
+}
+
+// Task converts a set of inputs to one or more target formats.
+type Task struct {
+	Name    string                 `yaml:"name"`
+	Inputs  []string               `yaml:"inputs"`
+	Targets []string               `yaml:"targets"`
+	Quality string                 `yaml:"quality"`
+	OutDir  string                 `yaml:"out_dir"`
+	Jobs    int                    `yaml:"jobs"`
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// Defaults are applied to tasks that don't set their own quality, concurrency
+// or options, typically from the user's config file. Conflict, Cache, Force
+// and Verify apply to every task.
+type Defaults struct {
+	Quality  string
+	Jobs     int
+	Limits   batch.Limits
+	Options  converter.Options
+	Conflict batch.ConflictPolicy
+	Cache    *cache.Cache
+	Force    bool
+	Verify   bool
+}
+
+// IsRecipeFile reports whether path looks like a job file by its extension.
+func IsRecipeFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Load reads and parses a job file.
+func Load(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job file: %w", err)
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.dir = filepath.Dir(path)
+	return r, nil
+}
+
+// Parse parses a job file's contents. Relative paths resolve against the working directory.
+func Parse(data []byte) (*Recipe, error) {
+	var r Recipe
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse job file: %w", err)
+	}
+	if len(r.Jobs) == 0 {
+		return nil, errors.New("job file defines no jobs")
+	}
+	return &r, nil
+}
+
+// Plan expands every task into batch jobs, one per target format.
+func (r *Recipe) Plan() ([]batch.Job, error) {
+	var jobs []batch.Job
+	var errs []error
+
+	for i, task := range r.Jobs {
+		name := task.Name
+		if name == "" {
+			name = fmt.Sprintf("job %d", i+1)
+		}
+
+		if len(task.Inputs) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no inputs", name))
+			continue
+		}
+		if len(task.Targets) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no targets", name))
+			continue
+		}
+
+		quality := r.Defaults.Quality
+		if quality == "" {
+			quality = "High"
+		}
+		if task.Quality != "" {
+			q, err := batch.ParseQuality(task.Quality)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			quality = q
+		}
+
+		files, err := batch.ExpandInputs(r.resolveAll(task.Inputs))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		outDir := ""
+		if task.OutDir != "" {
+			outDir = r.resolve(task.OutDir)
+		}
+
+		jobCount := task.Jobs
+		if jobCount == 0 {
+			jobCount = r.Defaults.Jobs
+		}
+
+		for _, target := range task.Targets {
+			jobs = append(jobs, batch.Job{
+				Files:     files,
+				TargetExt: converter.NormalizeExt(target),
+				Quality:   quality,
+				OutDir:    outDir,
+				Jobs:      jobCount,
+				Limits:    r.Defaults.Limits,
+				Options:   mergeOptions(r.Defaults.Options, normalizeOptions(task.Options)),
+				Conflict:  r.Defaults.Conflict,
+				Cache:     r.Defaults.Cache,
+				Force:     r.Defaults.Force,
+				Verify:    r.Defaults.Verify,
+			})
+		}
+	}
+
+	return jobs, errors.Join(errs...)
+}
+
+// Validate plans the recipe and checks every file/target pair against the
+// manager's conversion matrix, so nothing runs when any step is impossible.
+func (r *Recipe) Validate(mgr *converter.Manager) ([]batch.Job, error) {
+	jobs, err := r.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, job := range jobs {
+		for _, file := range job.Files {
+			if _, err := os.Stat(file); err != nil {
+				errs = append(errs, fmt.Errorf("input %s: %w", file, err))
+				continue
+			}
+			if _, _, err := mgr.FindConverterForFile(file, job.TargetExt); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return jobs, nil
+}
+
+// Run validates the recipe and then runs its jobs in order, returning a single
+// report covering every conversion. Cancelling ctx stops after the running step.
+func (r *Recipe) Run(ctx context.Context, mgr *converter.Manager, onResult func(batch.Result)) (batch.Report, error) {
+	jobs, err := r.Validate(mgr)
+	if err != nil {
+		return batch.Report{}, err
+	}
+
+	start := time.Now()
+	var combined batch.Report
+	for _, job := range jobs {
+		if job.OutDir != "" {
+			if err := os.MkdirAll(job.OutDir, 0755); err != nil {
+				return combined, fmt.Errorf("failed to create output directory: %w", err)
+			}
+		}
+		report := batch.Run(ctx, mgr, job, onResult)
+		combined.Results = append(combined.Results, report.Results...)
+		if report.Cancelled {
+			combined.Cancelled = true
+			break
+		}
+	}
+	combined.Duration = time.Since(start)
+	return combined, nil
+}
+
+func (r *Recipe) resolve(path string) string {
+	if filepath.IsAbs(path) || r.dir == "" {
+		return path
+	}
+	return filepath.Join(r.dir, path)
+}
+
+func (r *Recipe) resolveAll(paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = r.resolve(p)
+	}
+	return out
+}
+
+// normalizeOptions converts YAML lists into the []string values converters expect.
+func normalizeOptions(opts map[string]interface{}) converter.Options {
+	if len(opts) == 0 {
+		return nil
+	}
+	out := make(converter.Options, len(opts))
+	for k, v := range opts {
+		if list, ok := v.([]interface{}); ok {
+			strs := make([]string, 0, len(list))
+			for _, item := range list {
+				strs = append(strs, fmt.Sprint(item))
+			}
+			out[k] = strs
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// mergeOptions returns defaults overridden by opts.
+func mergeOptions(defaults, opts converter.Options) converter.Options {
+	if len(defaults) == 0 {
+		return opts
+	}
+	out := make(converter.Options, len(defaults)+len(opts))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range opts {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package recipe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(`
+jobs:
+  - name: data
+    inputs: ["data/*.json"]
+    targets: [yaml, .xml]
+    quality: compact
+    out_dir: build
+    options:
+      pandocArgs: ["--toc", "--standalone"]
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(r.Jobs) != 1 || r.Jobs[0].Name != "data" || r.Jobs[0].OutDir != "build" {
+		t.Errorf("unexpected recipe: %+v", r)
+	}
+
+	if _, err := Parse([]byte(`jobs: []`)); err == nil {
+		t.Error("Parse should reject a file without jobs")
+	}
+	if _, err := Parse([]byte("jobs:\n  - inputs: [a]\n    tragets: [.pdf]\n")); err == nil {
+		t.Error("Parse should reject unknown fields")
+	}
+}
+
+func TestRecipe_Plan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "data", "a.json"), `{"a":1}`)
+	writeFile(t, filepath.Join(dir, "data", "b.json"), `{"b":2}`)
+	jobPath := filepath.Join(dir, "golter.yaml")
+	writeFile(t, jobPath, `
+jobs:
+  - inputs: ["data/*.json"]
+    targets: [yaml, .xml]
+    quality: compact
+    out_dir: build
+    options:
+      pandocArgs: ["--toc"]
+`)
+
+	r, err := Load(jobPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	jobs, err := r.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected one batch per target, got %d", len(jobs))
+	}
+
+	wantFiles := []string{filepath.Join(dir, "data", "a.json"), filepath.Join(dir, "data", "b.json")}
+	for _, job := range jobs {
+		if !reflect.DeepEqual(job.Files, wantFiles) {
+			t.Errorf("Files = %v, want %v", job.Files, wantFiles)
+		}
+		if job.Quality != "Compact" || job.OutDir != filepath.Join(dir, "build") {
+			t.Errorf("unexpected job settings: %+v", job)
+		}
+		if args, ok := job.Options["pandocArgs"].([]string); !ok || !reflect.DeepEqual(args, []string{"--toc"}) {
+			t.Errorf("pandocArgs = %#v, want []string{\"--toc\"}", job.Options["pandocArgs"])
+		}
+	}
+	if jobs[0].TargetExt != ".yaml" || jobs[1].TargetExt != ".xml" {
+		t.Errorf("unexpected targets %s, %s", jobs[0].TargetExt, jobs[1].TargetExt)
+	}
+}
+
+func TestRecipe_Validate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), `{"a":1}`)
+	jobPath := filepath.Join(dir, "golter.yaml")
+	writeFile(t, jobPath, `
+jobs:
+  - name: impossible
+    inputs: ["a.json"]
+    targets: [.mp4]
+  - name: bad quality
+    inputs: ["a.json"]
+    targets: [.yaml]
+    quality: ultra
+`)
+
+	r, err := Load(jobPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	_, err = r.Validate(converter.NewDefaultManager())
+	if err == nil {
+		t.Fatal("Validate should fail")
+	}
+	if !strings.Contains(err.Error(), "bad quality") {
+		t.Errorf("expected quality error, got %v", err)
+	}
+}
+
+func TestRecipe_Run(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), `{"a":1}`)
+	jobPath := filepath.Join(dir, "golter.yaml")
+	writeFile(t, jobPath, `
+jobs:
+  - inputs: ["*.json"]
+    targets: [.yaml, .xml]
+    out_dir: out
+`)
+
+	r, err := Load(jobPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	report, err := r.Run(context.Background(), converter.NewDefaultManager(), nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Results) != 2 || report.Failed() != 0 {
+		t.Fatalf("unexpected results: %+v", report.Results)
+	}
+	for _, name := range []string{"a.yaml", "a.xml"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); err != nil {
+			t.Errorf("expected output %s: %v", name, err)
+		}
+	}
+}
+
+func TestRecipe_ValidateStopsBeforeRunning(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), `{"a":1}`)
+	jobPath := filepath.Join(dir, "golter.yaml")
+	writeFile(t, jobPath, `
+jobs:
+  - inputs: ["a.json"]
+    targets: [.yaml]
+  - inputs: ["a.json"]
+    targets: [.mp3]
+`)
+
+	r, err := Load(jobPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := r.Run(context.Background(), converter.NewDefaultManager(), nil); err == nil {
+		t.Fatal("Run should fail validation")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.yaml")); !os.IsNotExist(err) {
+		t.Error("no conversion should run when validation fails")
+	}
+}
@@ -0,0 +1,444 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// Defaults for Config fields left at zero.
+const (
+	DefaultMaxUpload = 100 << 20 // 100 MB
+	DefaultWorkers   = 4
+	DefaultQueueSize = 64
+	DefaultResultTTL = time.Hour
+)
+
+// Job statuses reported by the API.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Config controls limits of the HTTP server.
+type Config struct {
+	// MaxUpload is the largest accepted upload in bytes.
+	MaxUpload int64
+	// Workers is the number of conversions run concurrently. The CPUs are
+	// shared between them, so each external tool is given an equal part.
+	Workers int
+	// QueueSize is how many jobs may wait for a worker before uploads are rejected.
+	QueueSize int
+	// ResultTTL is how long finished jobs and their files are kept.
+	ResultTTL time.Duration
+	// Quality is the preset used when an upload does not ask for one. Empty
+	// means converter.QualityHigh.
+	Quality string
+	// Options are passed to every conversion alongside the requested quality.
+	Options converter.Options
+	// Runner runs the external tools conversions need. Nil runs them from PATH.
+	Runner converter.Runner
+}
+
+// Server exposes a converter.Manager over HTTP.
+type Server struct {
+	mgr   *converter.Manager
+	cfg   Config
+	dir   string
+	queue chan *job
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	// ctx is cancelled by Close to stop running conversions.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+type job struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Source     string     `json:"source"`
+	Target     string     `json:"target"`
+	Quality    string     `json:"quality"`
+	Converter  string     `json:"converter,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ErrorClass string     `json:"error_class,omitempty"`
+	Hint       string     `json:"hint,omitempty"`
+	Warning    string     `json:"warning,omitempty"`
+	InputSize  int64      `json:"input_bytes"`
+	OutputSize int64      `json:"output_bytes,omitempty"`
+	Created    time.Time  `json:"created"`
+	Finished   *time.Time `json:"finished,omitempty"`
+
+	dir        string
+	inputPath  string
+	outputPath string
+	cancel     context.CancelFunc
+}
+
+// New creates a Server and starts its worker pool. Call Close to stop the
+// workers and remove temporary files.
+func New(mgr *converter.Manager, cfg Config) (*Server, error) {
+	if cfg.MaxUpload <= 0 {
+		cfg.MaxUpload = DefaultMaxUpload
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.ResultTTL <= 0 {
+		cfg.ResultTTL = DefaultResultTTL
+	}
+
+	dir, err := os.MkdirTemp("", "golter_server")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	s := &Server{
+		mgr:   mgr,
+		cfg:   cfg,
+		dir:   dir,
+		queue: make(chan *job, cfg.QueueSize),
+		done:  make(chan struct{}),
+		jobs:  make(map[string]*job),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if cfg.Runner != nil {
+		s.ctx = converter.WithRunner(s.ctx, cfg.Runner)
+	}
+	s.ctx = converter.WithThreads(s.ctx, max(1, runtime.NumCPU()/cfg.Workers))
+
+	for i := 0; i < cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	s.wg.Add(1)
+	go s.janitor()
+
+	return s, nil
+}
+
+// Close stops the workers, cancelling running conversions, and removes all temporary files.
+func (s *Server) Close() error {
+	s.cancel()
+	close(s.done)
+	s.wg.Wait()
+	return os.RemoveAll(s.dir)
+}
+
+// Handler returns the HTTP routes of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/formats", s.handleFormats)
+	mux.HandleFunc("POST /api/jobs", s.handleCreateJob)
+	mux.HandleFunc("GET /api/jobs/{id}", s.handleGetJob)
+	mux.HandleFunc("GET /api/jobs/{id}/result", s.handleGetResult)
+	mux.HandleFunc("DELETE /api/jobs/{id}", s.handleDeleteJob)
+	return mux
+}
+
+func (s *Server) handleFormats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.mgr.Conversions())
+}
+
+// handleCreateJob accepts a multipart upload with a "file" part and "target"
+// and optional "quality" fields, and queues the conversion.
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUpload)
+
+	target := converter.NormalizeExt(r.FormValue("target"))
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds %d bytes", s.cfg.MaxUpload))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "missing file upload")
+		return
+	}
+	defer file.Close()
+
+	if target == "" {
+		writeError(w, http.StatusBadRequest, "missing target format")
+		return
+	}
+
+	quality := s.cfg.Quality
+	if quality == "" {
+		quality = converter.QualityHigh
+	}
+	if q := r.FormValue("quality"); q != "" {
+		quality, err = batch.ParseQuality(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	name := filepath.Base(header.Filename)
+	det := converter.Detection{Ext: converter.NormalizeExt(filepath.Ext(name))}
+	if det.Detected, err = converter.DetectReaderAt(file, header.Size); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	srcExt := det.Format()
+	conv, err := s.mgr.FindConverter(srcExt, target)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	j := &job{
+		ID:        id,
+		Status:    StatusQueued,
+		Source:    name,
+		Target:    target,
+		Quality:   quality,
+		Converter: conv.Name(),
+		Warning:   det.Warning(),
+		Created:   time.Now(),
+		dir:       filepath.Join(s.dir, id),
+	}
+	if err := s.saveUpload(j, file, srcExt); err != nil {
+		os.RemoveAll(j.dir)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = j
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- j:
+	default:
+		s.removeJob(id)
+		writeError(w, http.StatusServiceUnavailable, "conversion queue is full")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, s.snapshot(j))
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.lookup(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.snapshot(j))
+}
+
+func (s *Server) handleGetResult(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.lookup(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	snap := s.snapshot(j)
+	if snap.Status != StatusDone {
+		writeError(w, http.StatusConflict, "job is "+snap.Status)
+		return
+	}
+
+	name := strings.TrimSuffix(snap.Source, filepath.Ext(snap.Source)) + snap.Target
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, j.outputPath)
+}
+
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.lookup(id); !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	s.removeJob(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) saveUpload(j *job, file io.Reader, srcExt string) error {
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create job dir: %w", err)
+	}
+	j.inputPath = filepath.Join(j.dir, "input"+srcExt)
+	j.outputPath = filepath.Join(j.dir, "output"+j.Target)
+
+	out, err := os.Create(j.inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to store upload: %w", err)
+	}
+	defer out.Close()
+
+	n, err := io.Copy(out, file)
+	if err != nil {
+		return fmt.Errorf("failed to store upload: %w", err)
+	}
+	j.InputSize = n
+	return nil
+}
+
+func (s *Server) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case j := <-s.queue:
+			s.run(j)
+		}
+	}
+}
+
+func (s *Server) run(j *job) {
+	s.mu.Lock()
+	if _, ok := s.jobs[j.ID]; !ok {
+		// Deleted while queued
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	j.Status = StatusRunning
+	j.cancel = cancel
+	s.mu.Unlock()
+
+	conv, err := s.mgr.FindConverter(filepath.Ext(j.inputPath), j.Target)
+	if err == nil {
+		err = converter.ConvertContext(ctx, conv, j.inputPath, j.outputPath, s.options(j.Quality))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.cancel = nil
+	finished := time.Now()
+	j.Finished = &finished
+	if err != nil {
+		j.Status = StatusFailed
+		j.Error = err.Error()
+		j.ErrorClass = batch.ErrorClass(err)
+		j.Hint = converter.Hint(err)
+		return
+	}
+	j.Status = StatusDone
+	if info, statErr := os.Stat(j.outputPath); statErr == nil {
+		j.OutputSize = info.Size()
+	}
+}
+
+// janitor removes finished jobs older than the result TTL.
+func (s *Server) janitor() {
+	defer s.wg.Done()
+	interval := s.cfg.ResultTTL / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.expire(now)
+		}
+	}
+}
+
+func (s *Server) expire(now time.Time) {
+	s.mu.Lock()
+	var expired []string
+	for id, j := range s.jobs {
+		if j.Finished != nil && now.Sub(*j.Finished) > s.cfg.ResultTTL {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range expired {
+		s.removeJob(id)
+	}
+}
+
+func (s *Server) options(quality string) converter.Options {
+	opts := make(converter.Options, len(s.cfg.Options)+1)
+	for k, v := range s.cfg.Options {
+		opts[k] = v
+	}
+	opts["quality"] = quality
+	return opts
+}
+
+func (s *Server) lookup(id string) (*job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+// snapshot copies a job under the lock so it can be encoded safely.
+func (s *Server) snapshot(j *job) job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *j
+}
+
+func (s *Server) removeJob(id string) {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	delete(s.jobs, id)
+	if ok && j.cancel != nil {
+		j.cancel()
+	}
+	s.mu.Unlock()
+	if ok {
+		os.RemoveAll(j.dir)
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
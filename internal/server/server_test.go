@@ -0,0 +1,255 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// upperConverter upper-cases .txt files into .out files, or fails when the
+// content contains "bad".
+type upperConverter struct{}
+
+func (c *upperConverter) Name() string { return "Upper" }
+
+func (c *upperConverter) CanConvert(srcExt, targetExt string) bool {
+	return srcExt == ".txt" && targetExt == ".out"
+}
+
+func (c *upperConverter) SupportedSourceExtensions() []string { return []string{".txt"} }
+
+func (c *upperConverter) SupportedTargetFormats(srcExt string) []string {
+	return []string{".out"}
+}
+
+func (c *upperConverter) Convert(src, target string, opts converter.Options) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(data, []byte("bad")) {
+		return errors.New("boom")
+	}
+	return os.WriteFile(target, bytes.ToUpper(data), 0644)
+}
+
+func newTestServer(t *testing.T, cfg Config) (*Server, *httptest.Server) {
+	t.Helper()
+	mgr := converter.NewManager()
+	mgr.Register(&upperConverter{})
+
+	s, err := New(mgr, cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return s, ts
+}
+
+func upload(t *testing.T, url, filename, content, target string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if target != "" {
+		mw.WriteField("target", target)
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(fw, content)
+	mw.Close()
+
+	resp, err := http.Post(url+"/api/jobs", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	return resp
+}
+
+func decodeJob(t *testing.T, resp *http.Response) job {
+	t.Helper()
+	defer resp.Body.Close()
+	var j job
+	if err := json.NewDecoder(resp.Body).Decode(&j); err != nil {
+		t.Fatalf("failed to decode job: %v", err)
+	}
+	return j
+}
+
+func waitForJob(t *testing.T, url, id string) job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(url + "/api/jobs/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j := decodeJob(t, resp)
+		if j.Status == StatusDone || j.Status == StatusFailed {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return job{}
+}
+
+func TestServer_Formats(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+
+	resp, err := http.Get(ts.URL + "/api/formats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got []converter.Conversion
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Source != ".txt" || got[0].Target != ".out" {
+		t.Errorf("unexpected formats: %+v", got)
+	}
+}
+
+func TestServer_ConvertAndDownload(t *testing.T) {
+	s, ts := newTestServer(t, Config{})
+
+	resp := upload(t, ts.URL, "notes.txt", "hello", "out")
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	created := decodeJob(t, resp)
+
+	done := waitForJob(t, ts.URL, created.ID)
+	if done.Status != StatusDone {
+		t.Fatalf("expected done, got %s (%s)", done.Status, done.Error)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/jobs/" + created.ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "HELLO" {
+		t.Errorf("result = %q, want HELLO", data)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "notes.out") {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/jobs/"+created.ID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", resp.StatusCode)
+	}
+
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 0 {
+		t.Errorf("expected job files removed, found %d entries", len(entries))
+	}
+}
+
+func TestServer_DefaultQuality(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+	if j := decodeJob(t, upload(t, ts.URL, "a.txt", "hello", "out")); j.Quality != converter.QualityHigh {
+		t.Errorf("expected %s without a configured quality, got %q", converter.QualityHigh, j.Quality)
+	}
+
+	_, ts = newTestServer(t, Config{Quality: converter.QualityCompact})
+	if j := decodeJob(t, upload(t, ts.URL, "a.txt", "hello", "out")); j.Quality != converter.QualityCompact {
+		t.Errorf("expected the configured quality, got %q", j.Quality)
+	}
+}
+
+func TestServer_FailedJob(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+
+	created := decodeJob(t, upload(t, ts.URL, "a.txt", "bad", ".out"))
+	done := waitForJob(t, ts.URL, created.ID)
+	if done.Status != StatusFailed || done.Error == "" || done.ErrorClass != batch.ErrorClassConversion {
+		t.Fatalf("expected failed job with error, got %+v", done)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/jobs/" + created.ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 for failed result, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_RejectsBadRequests(t *testing.T) {
+	_, ts := newTestServer(t, Config{MaxUpload: 1024})
+
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		target   string
+		want     int
+	}{
+		{"missing target", "a.txt", "x", "", http.StatusBadRequest},
+		{"unsupported", "a.png", "x", ".out", http.StatusUnprocessableEntity},
+		{"too large", "a.txt", strings.Repeat("x", 4096), ".out", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := upload(t, ts.URL, tt.filename, tt.content, tt.target)
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/api/jobs/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_ExpireRemovesFinishedJobs(t *testing.T) {
+	s, ts := newTestServer(t, Config{ResultTTL: time.Hour})
+
+	created := decodeJob(t, upload(t, ts.URL, "a.txt", "hi", ".out"))
+	waitForJob(t, ts.URL, created.ID)
+
+	s.expire(time.Now().Add(2 * time.Hour))
+
+	if _, ok := s.lookup(created.ID); ok {
+		t.Error("expected expired job to be removed")
+	}
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 0 {
+		t.Errorf("expected job files removed, found %d entries", len(entries))
+	}
+}
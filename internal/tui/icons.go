@@ -36,6 +36,7 @@ const (
 	iconSpinner     = "◐"
 	iconLoading     = "⏳"
 	iconDone        = "✓"
+	iconSkipped     = "⏭️ "
 
 	// Action icons
 	iconConvert  = "🔄"
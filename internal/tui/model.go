@@ -1,14 +1,22 @@
 package tui
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/config"
 	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+	"github.com/sametcn99/golter/internal/plugin"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Model represents the main application model
@@ -17,9 +25,12 @@ type Model struct {
 	previousState   State
 	selector        Selector
 	selectedFiles   []string
+	sourceExt       string
 	targetFormats   []string
+	hiddenTargets   string
+	targetVia       map[string]string
 	actionOptions   []string
-	qualityOptions  []string
+	optionsForm     optionsForm
 	cursor          int
 	spinner         spinner.Model
 	progress        progress.Model
@@ -31,6 +42,7 @@ type Model struct {
 	err             error
 	quitting        bool
 	manager         *converter.Manager
+	config          config.Config
 	targetFormat    string
 	width           int
 	height          int
@@ -38,17 +50,30 @@ type Model struct {
 	latestVersion   string
 	updateUrl       string
 	updateAvailable bool
+	lastReport      batch.Report
+	reportStatus    string
+	cancel          context.CancelFunc
+	cancelling      bool
+	updates         chan tea.Msg
+	fileProgress    map[string]float64
+	conflict        *conflictMsg
+	cache           *cache.Cache
+	resumable       *journal.Journal
+	plannedJob      batch.Job
+	plan            *batch.Plan
+	planSeq         int
 }
 
 // NewModel creates a new Model with initial configuration
-func NewModel(initialPath string) Model {
-	mgr := converter.NewManager()
-	mgr.Register(&converter.ImageConverter{})
-	mgr.Register(&converter.VideoConverter{})
-	mgr.Register(&converter.DocumentConverter{})
-	mgr.Register(&converter.DocDataConverter{})
-	mgr.Register(&converter.AudioConverter{})
+func NewModel(initialPath string, cfg config.Config) Model {
+	mgr := converter.NewDefaultManager()
+	// Broken plugins are left out; `golter formats` reports why
+	plugin.Register(converter.WithRunner(context.Background(), cfg.Runner()), mgr, cfg.PluginDirectory())
+	cfg.ApplyPins(mgr)
 
+	if initialPath == "" {
+		initialPath = cfg.StartDir
+	}
 	if initialPath == "" {
 		// Default to user's home directory (cross-platform)
 		homeDir, err := os.UserHomeDir()
@@ -66,7 +91,19 @@ func NewModel(initialPath string) Model {
 		initialPath = filepath.Dir(initialPath)
 	}
 
-	s := NewSelector(initialPath, mgr.SupportedExtensions())
+	s := NewSelector(initialPath, mgr.SupportedExtensions(), cfg.ShowHidden)
+
+	var c *cache.Cache
+	if cfg.Cache {
+		// An unreadable cache just means every file is converted
+		c, _ = cache.Open()
+	}
+
+	// Offer to resume the newest interrupted batch, if there is one
+	var resumable *journal.Journal
+	if dir, err := journal.Dir(); err == nil {
+		resumable, _ = journal.Find(dir, "")
+	}
 
 	// Configure spinner with custom style
 	sp := spinner.New()
@@ -81,21 +118,224 @@ func NewModel(initialPath string) Model {
 	p.Width = 40
 
 	return Model{
-		state:    StateSelecting,
-		selector: s,
-		spinner:  sp,
-		progress: p,
-		manager:  mgr,
+		state:     StateSelecting,
+		selector:  s,
+		spinner:   sp,
+		progress:  p,
+		manager:   mgr,
+		config:    cfg,
+		cache:     c,
+		resumable: resumable,
 		actionOptions: []string{
 			iconConvert + "  Convert Format",
 			iconCompress + "  Compress Files",
 		},
-		qualityOptions: []string{
-			"✨ High Quality   (Best visual quality, larger files)",
-			"⚖️  Balanced      (Good quality, moderate size)",
-			"📦 Compact        (Smaller files, reduced quality)",
-		},
 		width:  80,
 		height: 24,
 	}
 }
+
+// newBatchContext returns the context for a new batch; Esc cancels it.
+func (m *Model) newBatchContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.cancelling = false
+	return converter.WithRunner(ctx, m.config.Runner())
+}
+
+// startBatch converts job in the background, streaming per-file progress
+// back to the model until the batch finishes.
+func (m *Model) startBatch(job batch.Job) tea.Cmd {
+	ctx := m.newBatchContext()
+	m.updates = make(chan tea.Msg, progressBuffer)
+	m.fileProgress = make(map[string]float64)
+	m.conflict = nil
+	return tea.Batch(
+		m.spinner.Tick,
+		convertFilesWithProgress(ctx, job, m.manager, m.updates),
+		waitForUpdate(m.updates),
+	)
+}
+
+// overallProgress returns the completed fraction of the running batch,
+// counting partially converted files.
+func (m Model) overallProgress() float64 {
+	if m.progressTotal == 0 {
+		return 0
+	}
+	done := float64(m.progressCurrent)
+	for _, f := range m.fileProgress {
+		done += f
+	}
+	if done > float64(m.progressTotal) {
+		done = float64(m.progressTotal)
+	}
+	return done / float64(m.progressTotal)
+}
+
+// newJob builds a batch job for files using the configured defaults,
+// overridden by opts.
+func (m Model) newJob(files []string, targetExt string, opts converter.Options) batch.Job {
+	merged := m.config.Options()
+	if merged == nil {
+		merged = converter.Options{}
+	}
+	for k, v := range opts {
+		merged[k] = v
+	}
+	quality := converter.QualityOf(merged)
+	if quality == "" {
+		quality = m.config.QualityLevel()
+	}
+	return batch.Job{
+		Files:     files,
+		TargetExt: targetExt,
+		Quality:   quality,
+		OutDir:    m.config.OutputDir,
+		Jobs:      m.config.Workers,
+		Limits:    m.config.Limits(),
+		Options:   merged,
+		Conflict:  m.config.ConflictPolicy(true),
+		Cache:     m.cache,
+		Verify:    m.config.Verify,
+	}
+}
+
+// answerConflict replies to the pending conflict prompt.
+func (m *Model) answerConflict(policy batch.ConflictPolicy, all bool) {
+	if m.conflict == nil {
+		return
+	}
+	m.conflict.reply <- conflictAnswer{policy: policy, all: all}
+	m.conflict = nil
+	m.currentStatus = "Resuming..."
+}
+
+// openOptions shows the options form for the chosen conversion, or starts
+// it right away when the converter has nothing to configure.
+func (m *Model) openOptions() tea.Cmd {
+	target := m.targetFormat
+	if target == "" {
+		target = m.sourceExt
+	}
+	var specs []converter.OptionSpec
+	if c, err := m.manager.FindConverter(m.sourceExt, target); err == nil {
+		specs = converter.OptionSpecsFor(c, m.sourceExt, target)
+	}
+	if len(specs) == 0 {
+		m.optionsForm = optionsForm{}
+		return m.reviewPlan(nil)
+	}
+
+	defaults := converter.Options{"quality": m.config.QualityLevel()}
+	for k, v := range m.config.Options() {
+		defaults[k] = v
+	}
+	m.optionsForm = newOptionsForm(specs, defaults)
+	m.state = StateSelectingOptions
+	m.cursor = 0
+	return nil
+}
+
+// reviewPlan works out what converting the selected files with opts on top
+// of the configured defaults would do, and shows it before anything is
+// converted.
+func (m *Model) reviewPlan(opts converter.Options) tea.Cmd {
+	m.plannedJob = m.newJob(m.selectedFiles, m.targetFormat, opts)
+	m.plan = nil
+	m.planSeq++
+	m.state = StateReviewingPlan
+	m.cursor = 0
+	return tea.Batch(m.spinner.Tick, planJobCmd(m.manager, m.plannedJob, m.planSeq))
+}
+
+// planBack returns from the plan review to where the conversion was set up.
+func (m *Model) planBack() {
+	m.plan = nil
+	m.cursor = 0
+	switch {
+	case len(m.optionsForm.specs) > 0:
+		m.state = StateSelectingOptions
+	case m.targetFormat != "":
+		m.state = StateSelectingFormat
+	default:
+		m.state = StateSelectingAction
+	}
+}
+
+// planRows is how many files of the plan fit in the window at once.
+func (m Model) planRows() int {
+	return max(3, m.height-16)
+}
+
+// startConversion converts the reviewed job.
+func (m *Model) startConversion() tea.Cmd {
+	job := m.plannedJob
+	m.plan = nil
+	m.state = StateConverting
+	m.progressCurrent = 0
+	m.progressTotal = len(job.Files)
+	m.startTime = time.Now()
+	if m.targetFormat == "" {
+		m.currentStatus = "Starting compression..."
+	} else {
+		m.currentStatus = "Starting conversion..."
+	}
+	job.Journal = newJournal(job)
+	return m.startBatch(job)
+}
+
+// newJournal records job on disk so it can be resumed if golter is
+// interrupted. Without a journal the batch still runs, it just cannot be
+// resumed.
+func newJournal(job batch.Job) *journal.Journal {
+	dir, err := journal.Dir()
+	if err != nil {
+		return nil
+	}
+	jr, _ := journal.Create(dir, job.Spec())
+	return jr
+}
+
+// resumeBatch converts what is left of the interrupted batch, after
+// removing the partial outputs it left behind.
+func (m *Model) resumeBatch() tea.Cmd {
+	jr := m.resumable
+	m.resumable = nil
+	if err := jr.Claim(); err != nil {
+		m.state = StateDone
+		m.err = fmt.Errorf("Batch %s could not resume:\n%v", jr.ID, err)
+		return nil
+	}
+	jr.CleanPartial()
+
+	job := batch.ResumeJob(jr)
+	job.Limits = m.config.Limits()
+	if jr.Spec.Cache {
+		job.Cache = m.cache
+		if job.Cache == nil {
+			job.Cache, _ = cache.Open()
+		}
+	}
+	m.selectedFiles = job.Files
+	m.targetFormat = job.TargetExt
+	m.state = StateConverting
+	m.progressCurrent = 0
+	m.progressTotal = len(job.Files)
+	m.startTime = time.Now()
+	m.currentStatus = "Resuming interrupted batch..."
+	return m.startBatch(job)
+}
+
+// discardResumable forgets the interrupted batch and its partial outputs.
+func (m *Model) discardResumable() {
+	jr := m.resumable
+	m.resumable = nil
+	// A batch another golter has since resumed is left to it
+	if jr.Claim() != nil {
+		return
+	}
+	jr.CleanPartial()
+	// A journal that cannot be removed is offered again next time
+	jr.Remove()
+}
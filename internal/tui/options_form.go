@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sametcn99/golter/internal/converter"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// optionsForm edits the options a converter declares. Each option is a row;
+// the last row starts the conversion. Values are kept as text, with "" meaning
+// the converter's default.
+type optionsForm struct {
+	specs   []converter.OptionSpec
+	values  []string
+	cursor  int
+	editing bool
+	input   textinput.Model
+	err     string
+}
+
+// newOptionsForm builds a form for specs, prefilled from defaults and then
+// from the specs' own defaults.
+func newOptionsForm(specs []converter.OptionSpec, defaults converter.Options) optionsForm {
+	values := make([]string, len(specs))
+	for i, spec := range specs {
+		switch v := defaults[spec.Name].(type) {
+		case string:
+			values[i] = v
+		case []string:
+			values[i] = strings.Join(v, " ")
+		case nil:
+			if spec.Default != nil {
+				values[i] = fmt.Sprint(spec.Default)
+			}
+		default:
+			values[i] = fmt.Sprint(v)
+		}
+	}
+
+	input := textinput.New()
+	input.Prompt = ""
+	input.CharLimit = 256
+
+	return optionsForm{specs: specs, values: values, input: input}
+}
+
+// onStart reports whether the cursor is on the start row.
+func (f optionsForm) onStart() bool {
+	return f.cursor == len(f.specs)
+}
+
+// Update handles a key press and reports whether the user asked to start.
+func (f optionsForm) Update(msg tea.KeyMsg) (optionsForm, bool) {
+	if f.editing {
+		switch msg.String() {
+		case "enter":
+			f.values[f.cursor] = strings.TrimSpace(f.input.Value())
+			f.editing = false
+			f.input.Blur()
+			f.err = ""
+			if err := f.validate(f.cursor); err != nil {
+				f.err = err.Error()
+			}
+		case "esc":
+			f.editing = false
+			f.input.Blur()
+		default:
+			f.input, _ = f.input.Update(msg)
+		}
+		return f, false
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case "down", "j", "tab":
+		if f.cursor < len(f.specs) {
+			f.cursor++
+		}
+	case "left", "h":
+		if !f.onStart() {
+			f.step(-1)
+		}
+	case "right", "l":
+		if !f.onStart() {
+			f.step(1)
+		}
+	case "enter":
+		if f.onStart() {
+			if _, err := f.Options(); err != nil {
+				f.err = err.Error()
+				return f, false
+			}
+			return f, true
+		}
+		switch f.specs[f.cursor].Type {
+		case converter.OptionEnum, converter.OptionBool:
+			f.step(1)
+		default:
+			f.editing = true
+			f.input.SetValue(f.values[f.cursor])
+			f.input.CursorEnd()
+			f.input.Focus()
+		}
+	}
+	return f, false
+}
+
+// step moves the current option to its previous or next value.
+func (f *optionsForm) step(delta int) {
+	spec := f.specs[f.cursor]
+	value := f.values[f.cursor]
+	switch spec.Type {
+	case converter.OptionEnum:
+		f.values[f.cursor] = cycle(f.enumValues(spec), value, delta)
+	case converter.OptionBool:
+		f.values[f.cursor] = cycle([]string{"", "true", "false"}, value, delta)
+	case converter.OptionInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			// Start from the middle of the range the first time
+			n = (spec.Min + spec.Max) / 2
+		} else {
+			n += delta
+		}
+		if spec.Max > spec.Min {
+			n = max(spec.Min, min(spec.Max, n))
+		}
+		f.values[f.cursor] = strconv.Itoa(n)
+	}
+	f.err = ""
+}
+
+// enumValues lists the values an enum row cycles through; "" (the
+// converter's default) is offered unless the option always has a value.
+func (f optionsForm) enumValues(spec converter.OptionSpec) []string {
+	if spec.Name == "quality" {
+		return spec.Choices
+	}
+	return append([]string{""}, spec.Choices...)
+}
+
+func cycle(values []string, current string, delta int) string {
+	i := slices.IndexFunc(values, func(v string) bool { return strings.EqualFold(v, current) })
+	if i < 0 {
+		return values[0]
+	}
+	return values[(i+delta+len(values))%len(values)]
+}
+
+// validate checks the value in row i against its spec.
+func (f optionsForm) validate(i int) error {
+	return converter.ValidateOptions(f.specs[i:i+1], converter.Options{f.specs[i].Name: f.values[i]})
+}
+
+// Options returns the set values, validated against the specs.
+func (f optionsForm) Options() (converter.Options, error) {
+	opts := converter.Options{}
+	for i, spec := range f.specs {
+		if f.values[i] != "" {
+			opts[spec.Name] = f.values[i]
+		}
+	}
+	if err := converter.ValidateOptions(f.specs, opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// View renders the form rows, the start button and any validation error.
+func (f optionsForm) View() string {
+	var s strings.Builder
+	width := 0
+	for _, spec := range f.specs {
+		width = max(width, len(spec.Name))
+	}
+
+	for i, spec := range f.specs {
+		value := f.values[i]
+		switch {
+		case f.editing && i == f.cursor:
+			value = f.input.View()
+		case value == "":
+			value = mutedStyle.Render("auto")
+		case spec.Type == converter.OptionEnum || spec.Type == converter.OptionBool || spec.Type == converter.OptionInt:
+			value = "‹ " + value + " ›"
+		}
+		row := fmt.Sprintf("%-*s  %s", width, spec.Name, value)
+		if i == f.cursor {
+			s.WriteString(selectedMenuItemStyle.Render(row) + "\n")
+			s.WriteString(mutedStyle.Render("    "+describeOption(spec)) + "\n")
+		} else {
+			s.WriteString(menuItemStyle.Render(row) + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+	start := iconConvert + "  Start"
+	if f.onStart() {
+		s.WriteString(selectedMenuItemStyle.Render(start) + "\n")
+	} else {
+		s.WriteString(menuItemStyle.Render(start) + "\n")
+	}
+
+	if f.err != "" {
+		s.WriteString("\n" + errorStyle.Render("  "+f.err) + "\n")
+	}
+	return s.String()
+}
+
+// describeOption returns the description of spec with its accepted values.
+func describeOption(spec converter.OptionSpec) string {
+	desc := spec.Description
+	switch spec.Type {
+	case converter.OptionInt:
+		if spec.Max > spec.Min {
+			desc += fmt.Sprintf(" (%d-%d)", spec.Min, spec.Max)
+		}
+	case converter.OptionArgs:
+		desc += " (space separated)"
+	}
+	return desc
+}
@@ -20,11 +20,12 @@ type Selector struct {
 	selected         map[string]bool // path -> true
 	allowedExts      map[string]bool
 	selectedFileType FileType // Track the type of first selected file
+	showHidden       bool
 	width            int
 	height           int
 }
 
-func NewSelector(startPath string, allowedExts []string) Selector {
+func NewSelector(startPath string, allowedExts []string, showHidden bool) Selector {
 	delegate := list.NewDefaultDelegate()
 	delegate.ShowDescription = false
 	delegate.SetSpacing(0)
@@ -72,6 +73,7 @@ func NewSelector(startPath string, allowedExts []string) Selector {
 		selected:         make(map[string]bool),
 		allowedExts:      exts,
 		selectedFileType: FileTypeUnknown,
+		showHidden:       showHidden,
 		width:            80,
 		height:           24,
 	}
@@ -1,8 +1,9 @@
 package tui
 
 import (
-	"path/filepath"
 	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
 )
 
 func (s *Selector) SelectedFiles() []string {
@@ -21,6 +22,20 @@ func (s *Selector) ClearSelection() {
 	s.loadFiles()
 }
 
+// HighlightedFile returns the file under the cursor, if any.
+func (s *Selector) HighlightedFile() (string, bool) {
+	i, ok := s.list.SelectedItem().(item)
+	if !ok || i.isDir {
+		return "", false
+	}
+	return i.path, true
+}
+
+// IsFiltering reports whether the user is typing a filter.
+func (s *Selector) IsFiltering() bool {
+	return s.list.FilterState() == list.Filtering
+}
+
 // GetSelectedFileType returns the current selected file type
 func (s *Selector) GetSelectedFileType() FileType {
 	return s.selectedFileType
@@ -31,8 +46,7 @@ func (s *Selector) TotalSelectableFiles() int {
 	count := 0
 	for _, listItem := range s.list.Items() {
 		if i, ok := listItem.(item); ok && !i.isDir && i.info != nil {
-			ext := filepath.Ext(i.path)
-			fileType := getFileType(ext)
+			fileType := getFileType(i.format)
 			if s.selectedFileType == FileTypeUnknown || fileType == s.selectedFileType {
 				count++
 			}
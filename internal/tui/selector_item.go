@@ -3,7 +3,6 @@ package tui
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -12,6 +11,8 @@ type item struct {
 	isDir    bool
 	info     os.FileInfo
 	selected bool
+	format   string // source format, detected from content
+	mismatch bool   // the file's extension contradicts its content
 }
 
 func (i item) Title() string {
@@ -33,8 +34,7 @@ func (i item) Title() string {
 	if i.isDir {
 		icon = iconFolder
 	} else {
-		ext := strings.ToLower(filepath.Ext(i.info.Name()))
-		icon = getFileIcon(ext)
+		icon = getFileIcon(i.format)
 	}
 
 	name := i.info.Name()
@@ -44,6 +44,9 @@ func (i item) Title() string {
 
 	// Add file size for files
 	size := FormatSize(i.info.Size())
+	if i.mismatch {
+		return fmt.Sprintf("%s %s %s (%s) %s %s content", prefix, icon, name, size, iconWarning, strings.ToUpper(strings.TrimPrefix(i.format, ".")))
+	}
 	return fmt.Sprintf("%s %s %s (%s)", prefix, icon, name, size)
 }
 
@@ -7,6 +7,8 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/sametcn99/golter/internal/converter"
+
 	"github.com/charmbracelet/bubbles/list"
 )
 
@@ -46,27 +48,35 @@ func (s *Selector) loadFiles() {
 			continue
 		}
 
-		// Skip hidden files and directories
-		if strings.HasPrefix(e.Name(), ".") {
+		// Skip hidden files and directories unless configured to show them
+		if !s.showHidden && strings.HasPrefix(e.Name(), ".") {
 			continue
 		}
 
+		path := filepath.Join(s.currentDir, e.Name())
+		format := ""
+		mismatch := false
 		if e.IsDir() {
 			dirCount++
 		} else {
-			ext := strings.ToLower(filepath.Ext(e.Name()))
-			if !s.allowedExts[ext] {
+			// Files are typed by their content, so misnamed and
+			// extension-less files are offered what they can convert to
+			det, _ := converter.DetectFile(path)
+			format = det.Format()
+			if !s.allowedExts[format] {
 				continue
 			}
+			mismatch = det.Mismatch()
 			fileCount++
 		}
 
-		path := filepath.Join(s.currentDir, e.Name())
 		items = append(items, item{
 			path:     path,
 			isDir:    e.IsDir(),
 			info:     info,
 			selected: s.selected[path],
+			format:   format,
+			mismatch: mismatch,
 		})
 	}
 
@@ -42,8 +42,7 @@ func (s *Selector) Update(msg tea.Msg) (Selector, tea.Cmd) {
 			i, ok := s.list.SelectedItem().(item)
 			if ok && !i.isDir {
 				path := i.path
-				ext := filepath.Ext(path)
-				fileType := getFileType(ext)
+				fileType := getFileType(i.format)
 
 				if s.selected[path] {
 					// Deselecting
@@ -77,8 +76,7 @@ func (s *Selector) Update(msg tea.Msg) (Selector, tea.Cmd) {
 			selectedCount := 0
 			for idx, listItem := range items {
 				if i, ok := listItem.(item); ok && !i.isDir && i.info != nil {
-					ext := filepath.Ext(i.path)
-					fileType := getFileType(ext)
+					fileType := getFileType(i.format)
 
 					// If no type selected yet, use the first file's type
 					if s.selectedFileType == FileTypeUnknown && !s.selected[i.path] {
@@ -3,6 +3,8 @@ package tui
 import (
 	"path/filepath"
 	"strings"
+
+	"github.com/sametcn99/golter/internal/converter"
 )
 
 // FileType represents the type of file (image, video, audio, document)
@@ -103,3 +105,13 @@ func truncatePath(path string, maxWidth int) string {
 
 	return "..." + string(filepath.Separator) + result
 }
+
+// detectFormat returns the format of the file at path as sniffed from its
+// content, falling back to its extension when the file cannot be read.
+func detectFormat(path string) string {
+	d, err := converter.DetectFile(path)
+	if err != nil {
+		return strings.ToLower(filepath.Ext(path))
+	}
+	return d.Format()
+}
@@ -1,14 +1,39 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/config"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+func TestMain(m *testing.M) {
+	// Keep the developer's interrupted batches out of the tests
+	dir, err := os.MkdirTemp("", "golter_tui_test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(journal.EnvDir, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestNewModel(t *testing.T) {
-	m := NewModel(".")
+	m := NewModel(".", config.Default())
 	if m.state != StateSelecting {
 		t.Errorf("Initial state should be StateSelecting, got %v", m.state)
 	}
@@ -21,12 +46,12 @@ func TestNewModel(t *testing.T) {
 }
 
 func TestModel_Init(t *testing.T) {
-	m := NewModel(".")
+	m := NewModel(".", config.Default())
 	_ = m.Init()
 }
 
 func TestModel_Update(t *testing.T) {
-	m := NewModel(".")
+	m := NewModel(".", config.Default())
 
 	// Test window resize
 	msg := tea.WindowSizeMsg{Width: 100, Height: 50}
@@ -58,3 +83,584 @@ func TestFormatDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestNewModel_UsesConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.StartDir = dir
+	cfg.Quality = "compact"
+	cfg.Workers = 2
+	cfg.ShowHidden = true
+
+	m := NewModel("", cfg)
+	if m.selector.currentDir != dir {
+		t.Errorf("expected start dir %s, got %s", dir, m.selector.currentDir)
+	}
+	if !m.selector.showHidden {
+		t.Error("expected hidden files to be shown")
+	}
+	job := m.newJob([]string{"a.png"}, ".webp", nil)
+	if job.Quality != "Compact" {
+		t.Errorf("expected Compact as default quality, got %q", job.Quality)
+	}
+	if job.Jobs != 2 {
+		t.Errorf("expected 2 workers, got %d", job.Jobs)
+	}
+}
+
+func TestModel_EscCancelsConversion(t *testing.T) {
+	m := NewModel(".", config.Default())
+	ctx := m.newBatchContext()
+	m.state = StateConverting
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(Model)
+	if ctx.Err() != nil || m.cancelling {
+		t.Fatal("expected backspace not to cancel the batch")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if ctx.Err() == nil {
+		t.Fatal("expected Esc to cancel the batch context")
+	}
+	if m.state != StateConverting || !m.cancelling {
+		t.Errorf("expected to keep converting until the batch returns, got state %v", m.state)
+	}
+
+	updated, _ = m.Update(batchResult{report: batch.Report{
+		Cancelled: true,
+		Results:   []batch.Result{{Path: "a.png", OutputPath: "a.webp"}},
+	}})
+	m = updated.(Model)
+	if m.state != StateDone {
+		t.Errorf("expected StateDone, got %v", m.state)
+	}
+	if m.err != nil || !strings.Contains(m.output, "Cancelled after converting 1 file(s)") {
+		t.Errorf("unexpected result output %q (err %v)", m.output, m.err)
+	}
+}
+
+func TestModel_GroupsFailures(t *testing.T) {
+	m := NewModel(".", config.Default())
+	m.state = StateConverting
+
+	missing := &converter.MissingToolError{Tool: "ffmpeg", Install: "ffmpeg (https://ffmpeg.org)"}
+	updated, _ := m.Update(batchResult{report: batch.Report{Results: []batch.Result{
+		{Path: "a.png", OutputPath: "a.webp"},
+		{Path: "b.mp4", Err: missing},
+		{Path: "c.mp4", Err: missing},
+		{Path: "d.json", Err: fmt.Errorf("failed to parse json: %w", converter.ErrCorruptInput)},
+	}}})
+	m = updated.(Model)
+
+	out := m.output
+	for _, want := range []string{"Missing dependency (2)", "Unreadable source (1)", "golter doctor", "b.mp4", "d.json"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in results:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "golter doctor") != 1 {
+		t.Errorf("expected the shared hint once:\n%s", out)
+	}
+	if strings.Index(out, "Missing dependency") > strings.Index(out, "Unreadable source") {
+		t.Errorf("expected groups in a stable order:\n%s", out)
+	}
+}
+
+func TestModel_ProgressUpdates(t *testing.T) {
+	m := NewModel(".", config.Default())
+	m.state = StateConverting
+	m.progressTotal = 2
+	m.startBatch(batch.Job{})
+
+	updated, _ := m.Update(progressMsg{file: "a.mp4", fraction: 0.5})
+	m = updated.(Model)
+	if got := m.overallProgress(); got != 0.25 {
+		t.Errorf("overall progress = %v, want 0.25", got)
+	}
+	if !strings.Contains(m.View(), "a.mp4  50%") {
+		t.Errorf("expected per-file percentage in view, got:\n%s", m.View())
+	}
+
+	updated, _ = m.Update(fileDoneMsg{result: batch.Result{Path: "a.mp4"}})
+	m = updated.(Model)
+	if m.progressCurrent != 1 || len(m.fileProgress) != 0 {
+		t.Errorf("expected finished file to be counted, got current=%d running=%v", m.progressCurrent, m.fileProgress)
+	}
+	if got := m.overallProgress(); got != 0.5 {
+		t.Errorf("overall progress = %v, want 0.5", got)
+	}
+	m.cancel()
+}
+
+func TestSelector_AdmitsExtensionlessFilesByContent(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"download": "%PDF-1.4\n",
+		"notes":    "just some text\n",
+		"data.txt": "not supported\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewSelector(dir, []string{".pdf"}, false)
+	var got []item
+	for _, li := range s.list.Items() {
+		if i, ok := li.(item); ok && !i.isDir && i.info != nil {
+			got = append(got, i)
+		}
+	}
+	if len(got) != 1 || filepath.Base(got[0].path) != "download" {
+		t.Fatalf("expected only the extension-less PDF to be listed, got %+v", got)
+	}
+	if got[0].format != ".pdf" || getFileType(got[0].format) != FileTypeDocument {
+		t.Errorf("expected the file to be treated as a .pdf document, got %q", got[0].format)
+	}
+}
+
+func TestSelector_TypesMisnamedFilesByContent(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"report.xls": "<!DOCTYPE html><html></html>",
+		"sheet.xls":  "not really a sheet",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewSelector(dir, []string{".html", ".xls"}, false)
+	got := make(map[string]item)
+	for _, li := range s.list.Items() {
+		if i, ok := li.(item); ok && !i.isDir && i.info != nil {
+			got[filepath.Base(i.path)] = i
+		}
+	}
+	if i := got["report.xls"]; i.format != ".html" || !i.mismatch || !strings.Contains(i.Title(), "HTML content") {
+		t.Errorf("expected report.xls to be typed as HTML and flagged, got %+v (%q)", i, i.Title())
+	}
+	if i := got["sheet.xls"]; i.format != ".xls" || i.mismatch {
+		t.Errorf("expected sheet.xls to keep its extension, got %+v", i)
+	}
+}
+
+func TestModel_OptionsForm(t *testing.T) {
+	cfg := config.Default()
+	cfg.Quality = "compact"
+	m := NewModel(t.TempDir(), cfg)
+	m.selectedFiles = []string{"a.png"}
+	m.sourceExt = ".png"
+	m.targetFormat = ".webp"
+	m.openOptions()
+
+	if m.state != StateSelectingOptions {
+		t.Fatalf("expected the options form, got state %v", m.state)
+	}
+	if len(m.optionsForm.specs) == 0 || m.optionsForm.specs[0].Name != "quality" {
+		t.Fatalf("expected the image converter's options, got %+v", m.optionsForm.specs)
+	}
+	if m.optionsForm.values[0] != "Compact" {
+		t.Errorf("expected the configured quality to be preselected, got %q", m.optionsForm.values[0])
+	}
+
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(Model)
+		}
+	}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	typeText := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// Cycle the quality preset, then type an out-of-range encoder quality
+	press(right, down, enter, typeText("150"), enter)
+	if m.optionsForm.values[0] != "High" {
+		t.Errorf("expected right to cycle Compact to High, got %q", m.optionsForm.values[0])
+	}
+	if m.optionsForm.err == "" {
+		t.Error("expected a validation error for imageQuality 150")
+	}
+
+	// Typing q while editing must not open the quit prompt
+	press(enter, tea.KeyMsg{Type: tea.KeyCtrlU}, typeText("q"))
+	if m.state != StateSelectingOptions || !m.optionsForm.editing {
+		t.Fatalf("expected to keep editing, got state %v", m.state)
+	}
+	press(tea.KeyMsg{Type: tea.KeyBackspace}, typeText("60"), enter, down, enter)
+	if m.state != StateReviewingPlan {
+		t.Fatalf("expected the plan to be reviewed, got state %v (form error %q)", m.state, m.optionsForm.err)
+	}
+
+	job := m.plannedJob
+	if job.Quality != "High" || job.Options["imageQuality"] != "60" {
+		t.Errorf("unexpected job options: quality %q, options %v", job.Quality, job.Options)
+	}
+}
+
+func TestModel_ConflictPrompt(t *testing.T) {
+	m := NewModel(".", config.Default())
+	m.state = StateConverting
+	m.startBatch(batch.Job{})
+	defer m.cancel()
+
+	reply := make(chan conflictAnswer, 1)
+	updated, _ := m.Update(conflictMsg{path: "photo.png", reply: reply})
+	m = updated.(Model)
+	if !strings.Contains(m.View(), "Output already exists") {
+		t.Errorf("expected a conflict prompt, got:\n%s", m.View())
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	m = updated.(Model)
+	if m.conflict != nil {
+		t.Error("expected the prompt to be dismissed")
+	}
+	if answer := <-reply; answer.policy != batch.ConflictRename || !answer.all {
+		t.Errorf("unexpected answer %+v", answer)
+	}
+}
+
+func TestAskConflict_RemembersAnswerForAll(t *testing.T) {
+	updates := make(chan tea.Msg, 1)
+	ask := askConflict(context.Background(), updates)
+
+	go func() {
+		msg := (<-updates).(conflictMsg)
+		msg.reply <- conflictAnswer{policy: batch.ConflictSkip, all: true}
+	}()
+	if got := ask("a.png"); got != batch.ConflictSkip {
+		t.Fatalf("first answer = %q, want skip", got)
+	}
+	// Answered for all, so no further prompt is sent
+	if got := ask("b.png"); got != batch.ConflictSkip {
+		t.Errorf("second answer = %q, want skip", got)
+	}
+	if len(updates) != 0 {
+		t.Error("expected no second prompt")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := askConflict(ctx, make(chan tea.Msg))("c.png"); got != batch.ConflictSkip {
+		t.Errorf("cancelled answer = %q, want skip", got)
+	}
+}
+
+func TestModel_HidesUnavailableFormats(t *testing.T) {
+	m := NewModel(t.TempDir(), config.Default())
+	caps := &converter.Capabilities{Tools: []converter.Tool{
+		{Name: "ffmpeg", Available: true, Encoders: []string{"libx264", "aac"}},
+	}}
+	updated, _ := m.Update(toolsProbedMsg{caps: caps})
+	m = updated.(Model)
+
+	m.selectedFiles = []string{"clip.mp4"}
+	m.sourceExt = ".mp4"
+	m.state = StateSelectingAction
+	m.cursor = 0
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+
+	if m.state != StateSelectingFormat {
+		t.Fatalf("expected the format list, got state %v (err %v)", m.state, m.err)
+	}
+	if slices.Contains(m.targetFormats, ".mkv") || !slices.Contains(m.targetFormats, ".mov") {
+		t.Errorf("expected .mkv hidden and .mov offered, got %v", m.targetFormats)
+	}
+	if !strings.Contains(m.View(), ".mkv (libx265 encoder)") {
+		t.Errorf("expected a note about hidden formats, got:\n%s", m.View())
+	}
+}
+
+func TestModel_FormatMenuIsStable(t *testing.T) {
+	m := NewModel(t.TempDir(), config.Default())
+	m.selectedFiles = []string{"data.csv"}
+	m.sourceExt = ".csv"
+	m.state = StateSelectingAction
+	m.cursor = 0
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+
+	// Direct targets first, then those reached through another format
+	want := []string{".json", ".xlsx", ".toml", ".xls", ".xml", ".yaml", ".yml"}
+	if !slices.Equal(m.targetFormats, want) {
+		t.Errorf("targetFormats = %v, want %v", m.targetFormats, want)
+	}
+	if m.targetVia[".yaml"] != ".json" || m.targetVia[".json"] != "" {
+		t.Errorf("unexpected route hints %v", m.targetVia)
+	}
+	if !strings.Contains(m.View(), "via .json") {
+		t.Errorf("expected routed formats to be marked, got:\n%s", m.View())
+	}
+}
+
+// interruptedBatch records a batch of a.json and b.json to YAML that stopped
+// after a.json.
+func interruptedBatch(t *testing.T) (dir string, jr *journal.Journal) {
+	t.Helper()
+	t.Setenv(journal.EnvDir, t.TempDir())
+	dir = t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	for _, p := range []string{a, b, filepath.Join(dir, "a.yaml")} {
+		if err := os.WriteFile(p, []byte(`{"ok":true}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jr, err := journal.Create(os.Getenv(journal.EnvDir), journal.Spec{Files: []string{a, b}, TargetExt: ".yaml", Quality: "Balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jr.Done(a, filepath.Join(dir, "a.yaml"))
+	jr.Start(b, filepath.Join(dir, "b.yaml"))
+	jr.Close()
+	return dir, jr
+}
+
+func TestModel_ResumesInterruptedBatch(t *testing.T) {
+	dir, jr := interruptedBatch(t)
+	partial := filepath.Join(dir, ".b.golter-1.yaml")
+	if err := os.WriteFile(partial, []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(dir, config.Default())
+	if m.resumable == nil || m.resumable.ID != jr.ID {
+		t.Fatal("expected the interrupted batch to be offered")
+	}
+	if !strings.Contains(m.View(), "1 of 2 files left") {
+		t.Errorf("expected the resume notice, got:\n%s", m.View())
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = updated.(Model)
+	defer m.cancel()
+	if m.state != StateConverting || m.progressTotal != 1 || m.resumable != nil {
+		t.Errorf("expected to convert the one remaining file, got state %v with %d files", m.state, m.progressTotal)
+	}
+	if want := filepath.Join(dir, "b.json"); !slices.Equal(m.selectedFiles, []string{want}) {
+		t.Errorf("selected files = %v, want [%s]", m.selectedFiles, want)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Error("expected the partial output to be removed before resuming")
+	}
+}
+
+func TestModel_DiscardsInterruptedBatch(t *testing.T) {
+	dir, jr := interruptedBatch(t)
+	m := NewModel(dir, config.Default())
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.resumable != nil {
+		t.Error("expected the batch to be discarded")
+	}
+	if _, err := os.Stat(jr.File()); !os.IsNotExist(err) {
+		t.Error("expected the journal to be removed")
+	}
+}
+
+func TestModel_LeavesBatchResumedElsewhere(t *testing.T) {
+	dir, jr := interruptedBatch(t)
+	partial := filepath.Join(dir, ".b.golter-1.yaml")
+	if err := os.WriteFile(partial, []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel(dir, config.Default())
+
+	// Another golter resumes the batch while this one offers it
+	other, err := journal.Open(jr.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Claim(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.resumable != nil {
+		t.Error("expected the batch to no longer be offered")
+	}
+	if _, err := os.Stat(jr.File()); err != nil {
+		t.Error("expected the running batch's journal to be kept")
+	}
+	if _, err := os.Stat(partial); err != nil {
+		t.Error("expected the running batch's partial output to be kept")
+	}
+	if NewModel(dir, config.Default()).resumable != nil {
+		t.Error("expected a running batch not to be offered")
+	}
+}
+
+func TestConvertFilesWithProgress_Journal(t *testing.T) {
+	dir, _ := interruptedBatch(t)
+	src := filepath.Join(dir, "b.json")
+	mgr := converter.NewDefaultManager()
+
+	for _, cancelled := range []bool{false, true} {
+		job := batch.Job{Files: []string{src}, TargetExt: ".yaml", Conflict: batch.ConflictOverwrite}
+		job.Journal = newJournal(job)
+		if job.Journal == nil {
+			t.Fatal("expected a journal for the batch")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		if cancelled {
+			cancel()
+		}
+		msg := convertFilesWithProgress(ctx, job, mgr, make(chan tea.Msg, progressBuffer))().(batchResult)
+		cancel()
+
+		_, err := os.Stat(job.Journal.File())
+		if cancelled && (msg.journal == nil || err != nil) {
+			t.Errorf("expected a cancelled batch to keep its journal, stat err = %v", err)
+		}
+		if !cancelled && (msg.journal != nil || !os.IsNotExist(err)) {
+			t.Errorf("expected a finished batch to remove its journal, stat err = %v", err)
+		}
+	}
+}
+
+func TestModel_ReviewsPlan(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+	for _, p := range []string{a, b, filepath.Join(dir, "a.yaml")} {
+		if err := os.WriteFile(p, []byte(`{"ok":true}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewModel(dir, config.Default())
+	m.selectedFiles = []string{a, b}
+	m.sourceExt = ".json"
+	m.targetFormat = ".yaml"
+	m.targetFormats = []string{".yaml"}
+	m.reviewPlan(nil)
+	if m.state != StateReviewingPlan || !strings.Contains(m.View(), "Checking the files") {
+		t.Fatalf("expected the plan to be worked out, got state %v", m.state)
+	}
+
+	// Enter waits for the plan
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.state != StateReviewingPlan {
+		t.Fatalf("expected to keep waiting for the plan, got state %v", m.state)
+	}
+
+	// A plan for a job the user backed out of is ignored
+	stale := planReadyMsg{plan: batch.NewPlan(m.manager, m.plannedJob, nil), seq: m.planSeq - 1}
+	updated, _ = m.Update(stale)
+	m = updated.(Model)
+	if m.plan != nil {
+		t.Fatal("expected the stale plan to be ignored")
+	}
+	updated, _ = m.Update(planReadyMsg{plan: batch.NewPlan(m.manager, m.plannedJob, nil), seq: m.planSeq})
+	m = updated.(Model)
+	view := m.View()
+	for _, want := range []string{"a.yaml", "output exists, will ask", "b.yaml", "2 to convert, 1 with existing outputs"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the plan, got:\n%s", want, view)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.yaml")); !os.IsNotExist(err) {
+		t.Error("reviewing the plan must not convert anything")
+	}
+
+	// Esc goes back to the format, which had no options form
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.state != StateSelectingFormat || m.plan != nil {
+		t.Fatalf("expected to return to the format, got state %v", m.state)
+	}
+
+	m.reviewPlan(nil)
+	updated, _ = m.Update(planReadyMsg{plan: batch.NewPlan(m.manager, m.plannedJob, nil), seq: m.planSeq})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.state != StateConverting || m.progressTotal != 2 {
+		t.Fatalf("expected the conversion to start, got state %v", m.state)
+	}
+	m.cancel()
+}
+
+func TestRunRecipeCmd_Cache(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"ok":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobFile := filepath.Join(dir, "job.yaml")
+	if err := os.WriteFile(jobFile, []byte("jobs:\n  - inputs: [a.json]\n    targets: [.yaml]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cachePath := filepath.Join(dir, "cache.json")
+	c, err := cache.OpenFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewDefaultManager()
+	run := func() batch.Result {
+		t.Helper()
+		msg := runRecipeCmd(context.Background(), jobFile, mgr, config.Default(), c)()
+		res, ok := msg.(batchResult)
+		if !ok || len(res.report.Results) != 1 || res.report.Results[0].Err != nil {
+			t.Fatalf("unexpected result %+v", msg)
+		}
+		return res.report.Results[0]
+	}
+
+	if run().Cached {
+		t.Fatal("the first run has nothing cached")
+	}
+	if !run().Cached {
+		t.Error("expected the job file to reuse the cached output")
+	}
+	saved, err := cache.OpenFile(cachePath)
+	if err != nil || saved.Len() != 1 {
+		t.Errorf("expected the cache to be saved, got %v", err)
+	}
+}
+
+// brokenJSON converts text to JSON that does not parse.
+type brokenJSON struct{}
+
+func (c *brokenJSON) Name() string { return "Broken" }
+func (c *brokenJSON) CanConvert(srcExt, targetExt string) bool {
+	return srcExt == ".txt" && targetExt == ".json"
+}
+func (c *brokenJSON) SupportedSourceExtensions() []string        { return []string{".txt"} }
+func (c *brokenJSON) SupportedTargetFormats(src string) []string { return []string{".json"} }
+func (c *brokenJSON) Convert(src, target string, opts converter.Options) error {
+	return os.WriteFile(target, []byte("{not json"), 0644)
+}
+
+func TestRunRecipeCmd_Verify(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobFile := filepath.Join(dir, "job.yaml")
+	if err := os.WriteFile(jobFile, []byte("jobs:\n  - inputs: [a.txt]\n    targets: [.json]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := converter.NewManager()
+	mgr.Register(&brokenJSON{})
+	cfg := config.Default()
+	cfg.Verify = true
+
+	msg := runRecipeCmd(context.Background(), jobFile, mgr, cfg, nil)()
+	res, ok := msg.(batchResult)
+	if !ok || len(res.report.Results) != 1 {
+		t.Fatalf("unexpected result %+v", msg)
+	}
+	if err := res.report.Results[0].Err; !errors.Is(err, converter.ErrVerification) {
+		t.Errorf("expected the configured verification to fail the output, got %v", err)
+	}
+}
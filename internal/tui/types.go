@@ -1,7 +1,9 @@
 package tui
 
 import (
-	"time"
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/journal"
 )
 
 // State represents the current state of the application
@@ -11,7 +13,8 @@ const (
 	StateSelecting State = iota
 	StateSelectingAction
 	StateSelectingFormat
-	StateSelectingQuality
+	StateSelectingOptions
+	StateReviewingPlan
 	StateConverting
 	StateDone
 	StateQuitting
@@ -26,8 +29,10 @@ func (s State) String() string {
 		return "Action Selection"
 	case StateSelectingFormat:
 		return "Format Selection"
-	case StateSelectingQuality:
-		return "Quality Selection"
+	case StateSelectingOptions:
+		return "Options"
+	case StateReviewingPlan:
+		return "Plan Review"
 	case StateConverting:
 		return "Converting"
 	case StateDone:
@@ -39,23 +44,57 @@ func (s State) String() string {
 	}
 }
 
-type conversionResult struct {
-	path       string
-	outputPath string
-	err        error
-	duration   time.Duration
+type batchResult struct {
+	report batch.Report
+	// journal is kept when the batch was cancelled, so it can be resumed.
+	journal *journal.Journal
 }
 
-type batchResult struct {
-	results  []conversionResult
-	duration time.Duration
+type recipeFailedMsg struct {
+	path string
+	err  error
+}
+
+type reportSavedMsg struct {
+	path string
+	err  error
 }
 
+// planReadyMsg carries the plan of the job being reviewed. seq tells it
+// apart from plans of jobs the user has since backed out of.
+type planReadyMsg struct {
+	plan batch.Plan
+	seq  int
+}
+
+// progressMsg carries the completed fraction of a file that is being converted.
 type progressMsg struct {
-	current int
-	total   int
-	file    string
-	status  string
+	file     string
+	fraction float64
+}
+
+// fileDoneMsg is sent when a file in the running batch finishes.
+type fileDoneMsg struct {
+	result batch.Result
+}
+
+// conflictMsg asks the user what to do with an output that already exists.
+// The answer is sent on reply.
+type conflictMsg struct {
+	path  string
+	reply chan<- conflictAnswer
+}
+
+// conflictAnswer is the user's choice for a conflict; all applies it to
+// every remaining conflict in the batch.
+type conflictAnswer struct {
+	policy batch.ConflictPolicy
+	all    bool
+}
+
+// toolsProbedMsg carries which external tools and encoders are installed.
+type toolsProbedMsg struct {
+	caps *converter.Capabilities
 }
 
 type checkUpdateMsg struct {
@@ -1,14 +1,18 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/config"
 	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/recipe"
 	"github.com/sametcn99/golter/internal/version"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -16,12 +20,25 @@ import (
 )
 
 func (m Model) Init() tea.Cmd {
+	if !m.config.CheckUpdates {
+		return tea.Batch(m.spinner.Tick, probeToolsCmd(m.config.Runner()))
+	}
 	return tea.Batch(
 		m.spinner.Tick,
+		probeToolsCmd(m.config.Runner()),
 		checkUpdatesCmd,
 	)
 }
 
+// probeToolsCmd checks which external tools r finds, so the format list can
+// leave out conversions that would fail.
+func probeToolsCmd(r converter.Runner) tea.Cmd {
+	return func() tea.Msg {
+		ctx := converter.WithRunner(context.Background(), r)
+		return toolsProbedMsg{caps: converter.ProbeTools(ctx, converter.KnownTools...)}
+	}
+}
+
 func checkUpdatesCmd() tea.Msg {
 	latest, url, available, err := version.CheckForUpdates()
 	return checkUpdateMsg{latest, url, available, err}
@@ -54,6 +71,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Keys go to the text field while an option value is being typed
+		if m.state == StateSelectingOptions && m.optionsForm.editing && msg.String() != "ctrl+c" {
+			break
+		}
+
+		// A pending conflict prompt takes the answer keys; Esc still cancels
+		if m.state == StateConverting && m.conflict != nil {
+			switch msg.String() {
+			case "o", "O":
+				m.answerConflict(batch.ConflictOverwrite, msg.String() == "O")
+				return m, nil
+			case "s", "S":
+				m.answerConflict(batch.ConflictSkip, msg.String() == "S")
+				return m, nil
+			case "r", "R":
+				m.answerConflict(batch.ConflictRename, msg.String() == "R")
+				return m, nil
+			case "esc":
+				// The batch's Ask gives up once the context is cancelled
+				m.conflict = nil
+			}
+		}
+
 		// Universal back handling
 		if msg.String() == "esc" || msg.String() == "backspace" {
 			switch m.state {
@@ -65,10 +105,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = StateSelectingAction
 				m.cursor = 0
 				return m, nil
-			case StateSelectingQuality:
-				m.state = StateSelectingAction
+			case StateSelectingOptions:
+				if m.targetFormat != "" {
+					m.state = StateSelectingFormat
+				} else {
+					m.state = StateSelectingAction
+				}
 				m.cursor = 0
 				return m, nil
+			case StateReviewingPlan:
+				m.planBack()
+				return m, nil
+			case StateConverting:
+				// A stray backspace must not throw away a running batch
+				if msg.String() != "esc" {
+					return m, nil
+				}
+				if m.cancel != nil && !m.cancelling {
+					m.cancel()
+					m.cancelling = true
+					m.currentStatus = "Cancelling, waiting for running conversions to stop..."
+				}
+				return m, nil
 			case StateDone:
 				m.state = StateSelecting
 				m.selectedFiles = nil
@@ -77,6 +135,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.output = ""
 				m.progressCurrent = 0
 				m.progressTotal = 0
+				m.lastReport = batch.Report{}
+				m.reportStatus = ""
 				return m, nil
 			}
 		}
@@ -91,52 +151,133 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		} else {
 			if msg.String() == "ctrl+c" {
+				// Stop external tools before exiting
+				if m.cancel != nil {
+					m.cancel()
+				}
 				m.quitting = true
 				return m, tea.Quit
 			}
 		}
 
-	case progressMsg:
-		m.progressCurrent = msg.current
-		m.progressTotal = msg.total
-		m.currentFile = msg.file
-		m.currentStatus = msg.status
+	case planReadyMsg:
+		if m.state == StateReviewingPlan && msg.seq == m.planSeq {
+			m.plan = &msg.plan
+		}
 		return m, nil
 
+	case progressMsg:
+		// Updates can trail the batch result; keep draining but ignore them.
+		if m.state == StateConverting && m.fileProgress != nil {
+			m.fileProgress[msg.file] = msg.fraction
+		}
+		return m, waitForUpdate(m.updates)
+
+	case conflictMsg:
+		if m.state != StateConverting || m.cancelling {
+			msg.reply <- conflictAnswer{policy: batch.ConflictSkip}
+			return m, waitForUpdate(m.updates)
+		}
+		m.conflict = &msg
+		m.currentStatus = "Waiting for your answer..."
+		return m, waitForUpdate(m.updates)
+
+	case fileDoneMsg:
+		if m.state == StateConverting && m.fileProgress != nil {
+			delete(m.fileProgress, msg.result.Path)
+			m.progressCurrent++
+			if !m.cancelling {
+				m.currentStatus = fmt.Sprintf("Converted %s", filepath.Base(msg.result.Path))
+			}
+		}
+		return m, waitForUpdate(m.updates)
+
 	case batchResult:
 		m.state = StateDone
+		m.lastReport = msg.report
+		if msg.journal != nil {
+			m.resumable = msg.journal
+		}
+		m.reportStatus = ""
+		if m.cancel != nil {
+			m.cancel()
+			m.cancel = nil
+		}
+		m.cancelling = false
+		m.fileProgress = nil
+		m.conflict = nil
 		// Aggregate results
-		successCount := 0
-		var errs []string
+		successCount, skippedCount, cachedCount := 0, 0, 0
+		var failed []batch.Result
 		var successFiles []string
 		var totalSaved int64
 
-		for _, res := range msg.results {
-			if res.err != nil {
-				errs = append(errs, fmt.Sprintf("  %s %s: %v", iconError, filepath.Base(res.path), res.err))
+		for _, res := range msg.report.Results {
+			if res.Err != nil {
+				// Failures are listed by groupFailures with their warnings
+				failed = append(failed, res)
+				continue
+			}
+			if res.Cached {
+				cachedCount++
+				successFiles = append(successFiles, fmt.Sprintf("  %s %s %s %s (unchanged)",
+					iconSuccess,
+					filepath.Base(res.Path),
+					iconArrowRight,
+					filepath.Base(res.OutputPath),
+				))
+			} else if res.Skipped {
+				skippedCount++
+				successFiles = append(successFiles, fmt.Sprintf("  %s%s: %s already exists",
+					iconSkipped,
+					filepath.Base(res.Path),
+					filepath.Base(res.OutputPath),
+				))
 			} else {
 				successCount++
 				durationStr := ""
-				if res.duration > 0 {
-					durationStr = fmt.Sprintf(" (%s)", formatDuration(res.duration))
+				if res.Duration > 0 {
+					durationStr = fmt.Sprintf(" (%s)", formatDuration(res.Duration))
 				}
 				successFiles = append(successFiles, fmt.Sprintf("  %s %s %s %s%s",
 					iconSuccess,
-					filepath.Base(res.path),
+					filepath.Base(res.Path),
 					iconArrowRight,
-					filepath.Base(res.outputPath),
+					filepath.Base(res.OutputPath),
 					durationStr,
 				))
 			}
+			if res.Warning != "" {
+				successFiles = append(successFiles, fmt.Sprintf("    %s%s", iconWarning, res.Warning))
+			}
 		}
+		errs := groupFailures(failed)
 
-		totalDuration := formatDuration(msg.duration)
+		totalDuration := formatDuration(msg.report.Duration)
+		// Mention skipped files next to the timing in every summary
+		if skippedCount > 0 {
+			totalDuration += fmt.Sprintf(", %d skipped", skippedCount)
+		}
+		if cachedCount > 0 {
+			totalDuration += fmt.Sprintf(", %d unchanged", cachedCount)
+		}
 
-		if len(errs) > 0 && successCount > 0 {
+		if msg.report.Cancelled {
+			m.output = fmt.Sprintf("Cancelled after converting %d file(s) in %s",
+				successCount,
+				totalDuration,
+			)
+			if len(successFiles) > 0 {
+				m.output += "\n\n" + strings.Join(successFiles, "\n")
+			}
+			if len(errs) > 0 {
+				m.output += "\n\nNot converted:\n" + strings.Join(errs, "\n")
+			}
+		} else if len(errs) > 0 && successCount > 0 {
 			// Partial success
 			m.output = fmt.Sprintf("Converted %d/%d files in %s\n\n%s\n\nErrors:\n%s",
 				successCount,
-				len(msg.results),
+				len(msg.report.Results),
 				totalDuration,
 				strings.Join(successFiles, "\n"),
 				strings.Join(errs, "\n"),
@@ -159,11 +300,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case recipeFailedMsg:
+		m.state = StateDone
+		m.err = fmt.Errorf("Job file %s could not run:\n%v", filepath.Base(msg.path), msg.err)
+		return m, nil
+
+	case reportSavedMsg:
+		if msg.err != nil {
+			m.reportStatus = fmt.Sprintf("%s %v", iconError, msg.err)
+		} else {
+			m.reportStatus = fmt.Sprintf("%s Report saved to %s", iconSuccess, msg.path)
+		}
+		return m, nil
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case toolsProbedMsg:
+		m.manager.SetCapabilities(msg.caps)
+		return m, nil
+
 	case checkUpdateMsg:
 		if msg.err == nil && msg.available {
 			m.latestVersion = msg.latest
@@ -184,11 +342,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			files := m.selector.SelectedFiles()
 			if len(files) > 0 {
 				m.selectedFiles = files
+				m.sourceExt = detectFormat(files[0])
 				m.state = StateSelectingAction
 				m.cursor = 0
 				return m, nil
 			}
 		}
+
+		// Ctrl+R resumes the interrupted batch, x discards it
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.resumable != nil && !m.selector.IsFiltering() {
+			switch keyMsg.String() {
+			case "ctrl+r":
+				return m, m.resumeBatch()
+			case "x":
+				m.discardResumable()
+				return m, nil
+			}
+		}
+
+		// Check for "r" key to run the highlighted job file
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "r" && !m.selector.IsFiltering() {
+			if path, ok := m.selector.HighlightedFile(); ok && recipe.IsRecipeFile(path) {
+				m.state = StateConverting
+				m.progressCurrent = 0
+				m.progressTotal = 0
+				m.startTime = time.Now()
+				m.currentStatus = "Running job file " + filepath.Base(path) + "..."
+				ctx := m.newBatchContext()
+				return m, tea.Batch(
+					m.spinner.Tick,
+					runRecipeCmd(ctx, path, m.manager, m.config, m.cache),
+				)
+			}
+		}
 		return m, cmd
 
 	case StateSelectingAction:
@@ -206,11 +392,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selectedAction := m.actionOptions[m.cursor]
 
 				if strings.Contains(selectedAction, "Convert Format") {
-					ext := filepath.Ext(m.selectedFiles[0])
+					ext := m.sourceExt
 					m.targetFormats = m.manager.GetSupportedTargetFormats(ext)
+					m.hiddenTargets = describeHiddenTargets(m.manager.UnavailableTargets(ext))
+					m.targetVia = routeHints(m.manager, ext, m.targetFormats)
 
 					if len(m.targetFormats) == 0 {
-						m.err = fmt.Errorf("no supported target formats for %s", ext)
+						if m.hiddenTargets != "" {
+							m.err = fmt.Errorf("no available target formats for %s\n%s", ext, m.hiddenTargets)
+						} else {
+							m.err = fmt.Errorf("no supported target formats for %s", ext)
+						}
 						m.state = StateDone
 						return m, nil
 					}
@@ -220,8 +412,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					// Compress Files - keep original format
 					m.targetFormat = ""
-					m.state = StateSelectingQuality
-					m.cursor = 0
+					return m, m.openOptions()
 				}
 				return m, nil
 			}
@@ -240,40 +431,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "enter":
 				m.targetFormat = m.targetFormats[m.cursor]
-				m.state = StateConverting
-				m.progressCurrent = 0
-				m.progressTotal = len(m.selectedFiles)
-				m.startTime = time.Now()
-				m.currentStatus = "Starting conversion..."
-				return m, tea.Batch(
-					m.spinner.Tick,
-					convertFilesWithProgress(m.selectedFiles, m.targetFormat, "High", m.manager),
-				)
+				return m, m.openOptions()
 			}
 		}
 
-	case StateSelectingQuality:
+	case StateSelectingOptions:
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			var start bool
+			m.optionsForm, start = m.optionsForm.Update(keyMsg)
+			if start {
+				opts, _ := m.optionsForm.Options()
+				return m, m.reviewPlan(opts)
+			}
+		}
+
+	case StateReviewingPlan:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.plan != nil {
 			switch keyMsg.String() {
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
 				}
 			case "down", "j":
-				if m.cursor < len(m.qualityOptions)-1 {
+				if m.cursor < len(m.plan.Items)-m.planRows() {
 					m.cursor++
 				}
 			case "enter":
-				quality := m.qualityOptions[m.cursor]
-				m.state = StateConverting
-				m.progressCurrent = 0
-				m.progressTotal = len(m.selectedFiles)
-				m.startTime = time.Now()
-				m.currentStatus = "Starting compression..."
-				return m, tea.Batch(
-					m.spinner.Tick,
-					convertFilesWithProgress(m.selectedFiles, m.targetFormat, quality, m.manager),
-				)
+				return m, m.startConversion()
 			}
 		}
 
@@ -281,91 +465,153 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+
+	case StateDone:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "s" && len(m.lastReport.Results) > 0 {
+			return m, saveReportCmd(m.lastReport, m.selector.currentDir)
+		}
 	}
 
 	return m, nil
 }
 
-func convertFilesWithProgress(files []string, targetExt string, quality string, mgr *converter.Manager) tea.Cmd {
+// planJobCmd plans job in the background, as planning reads every file.
+func planJobCmd(mgr *converter.Manager, job batch.Job, seq int) tea.Cmd {
 	return func() tea.Msg {
-		startTime := time.Now()
-		var results []conversionResult
-		var mu sync.Mutex
-		var completed int32
-
-		opts := converter.Options{
-			"quality": quality,
-		}
-
-		// Determine optimal concurrency based on file count
-		concurrency := 4
-		if len(files) < concurrency {
-			concurrency = len(files)
-		}
-
-		// Process files concurrently with semaphore
-		var wg sync.WaitGroup
-		semaphore := make(chan struct{}, concurrency)
-
-		for _, path := range files {
-			wg.Add(1)
-			go func(path string) {
-				defer wg.Done()
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
-
-				fileStart := time.Now()
+		return planReadyMsg{plan: batch.NewPlan(mgr, job, nil), seq: seq}
+	}
+}
 
-				ext := filepath.Ext(path)
-				effectiveTargetExt := targetExt
-				if effectiveTargetExt == "" {
-					effectiveTargetExt = ext
-				}
+// progressBuffer is how many progress updates may queue up before new ones
+// are dropped; finished-file updates are never dropped.
+const progressBuffer = 64
 
-				conv, err := mgr.FindConverter(ext, effectiveTargetExt)
-				if err != nil {
-					mu.Lock()
-					results = append(results, conversionResult{
-						path:     path,
-						err:      err,
-						duration: time.Since(fileStart),
+// convertFilesWithProgress runs the batch, sending progressMsg and fileDoneMsg
+// on updates while it runs. updates is closed before the batchResult is returned.
+func convertFilesWithProgress(ctx context.Context, job batch.Job, mgr *converter.Manager, updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		defer close(updates)
+		if job.OutDir != "" {
+			if err := os.MkdirAll(job.OutDir, 0755); err != nil {
+				report := batch.Report{}
+				for _, file := range job.Files {
+					report.Results = append(report.Results, batch.Result{
+						Path: file,
+						Err:  fmt.Errorf("failed to create output directory: %w", err),
 					})
-					atomic.AddInt32(&completed, 1)
-					mu.Unlock()
-					return
-				}
-
-				var outputPath string
-				if targetExt == "" {
-					// Compression mode
-					outputPath = strings.TrimSuffix(path, ext) + "_compressed" + ext
-				} else {
-					// Conversion mode
-					outputPath = strings.TrimSuffix(path, ext) + targetExt
-					if outputPath == path {
-						outputPath = strings.TrimSuffix(path, ext) + "_converted" + targetExt
-					}
 				}
+				return batchResult{report: report}
+			}
+		}
+		job.OnProgress = func(path string, fraction float64) {
+			// Never block a converter on a slow UI
+			select {
+			case updates <- progressMsg{file: path, fraction: fraction}:
+			default:
+			}
+		}
+		if job.Conflict == batch.ConflictAsk {
+			job.Ask = askConflict(ctx, updates)
+		}
+		report := batch.Run(ctx, mgr, job, func(res batch.Result) {
+			select {
+			case updates <- fileDoneMsg{result: res}:
+			case <-ctx.Done():
+			}
+		})
+		if job.Cache != nil {
+			// A cache that cannot be saved only costs a reconversion next time
+			job.Cache.Save()
+		}
+		if job.Journal == nil {
+			return batchResult{report: report}
+		}
+		// Only a cancelled batch has anything left to resume
+		if !report.Cancelled {
+			job.Journal.Remove()
+			return batchResult{report: report}
+		}
+		job.Journal.Close()
+		return batchResult{report: report, journal: job.Journal}
+	}
+}
 
-				err = conv.Convert(path, outputPath, opts)
-				duration := time.Since(fileStart)
+// askConflict returns a batch.Job.Ask that prompts through updates and
+// remembers answers the user applied to all conflicts. A cancelled batch
+// skips the file.
+func askConflict(ctx context.Context, updates chan<- tea.Msg) func(string) batch.ConflictPolicy {
+	var remembered batch.ConflictPolicy
+	return func(path string) batch.ConflictPolicy {
+		if remembered != "" {
+			return remembered
+		}
+		reply := make(chan conflictAnswer, 1)
+		select {
+		case updates <- conflictMsg{path: path, reply: reply}:
+		case <-ctx.Done():
+			return batch.ConflictSkip
+		}
+		select {
+		case answer := <-reply:
+			if answer.all {
+				remembered = answer.policy
+			}
+			return answer.policy
+		case <-ctx.Done():
+			return batch.ConflictSkip
+		}
+	}
+}
 
-				mu.Lock()
-				results = append(results, conversionResult{
-					path:       path,
-					outputPath: outputPath,
-					err:        err,
-					duration:   duration,
-				})
-				atomic.AddInt32(&completed, 1)
-				mu.Unlock()
-			}(path)
+// waitForUpdate delivers the next message from a running batch, or nothing
+// once the batch has finished.
+func waitForUpdate(updates <-chan tea.Msg) tea.Cmd {
+	if updates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
 		}
+		return msg
+	}
+}
 
-		wg.Wait()
-		return batchResult{
-			results:  results,
-			duration: time.Since(startTime),
+// runRecipeCmd validates and runs a YAML job file, skipping files c has
+// unchanged outputs for when c is not nil.
+func runRecipeCmd(ctx context.Context, path string, mgr *converter.Manager, cfg config.Config, c *cache.Cache) tea.Cmd {
+	return func() tea.Msg {
+		r, err := recipe.Load(path)
+		if err != nil {
+			return recipeFailedMsg{path: path, err: err}
+		}
+		// Job files run without progress updates, so there is no way to ask
+		r.Defaults = recipe.Defaults{
+			Quality:  cfg.QualityLevel(),
+			Jobs:     cfg.Workers,
+			Limits:   cfg.Limits(),
+			Options:  cfg.Options(),
+			Conflict: cfg.ConflictPolicy(false),
+			Cache:    c,
+			Verify:   cfg.Verify,
+		}
+		report, err := r.Run(ctx, mgr, nil)
+		if c != nil {
+			// A cache that cannot be saved only costs a reconversion next time
+			c.Save()
+		}
+		if err != nil {
+			return recipeFailedMsg{path: path, err: err}
 		}
+		return batchResult{report: report}
+	}
+}
+
+// saveReportCmd writes the last batch report as JSON into dir.
+func saveReportCmd(report batch.Report, dir string) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(dir, batch.DefaultReportName(time.Now()))
+		return reportSavedMsg{path: path, err: batch.SaveReport(path, "json", report)}
 	}
 }
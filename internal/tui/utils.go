@@ -3,10 +3,15 @@ package tui
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/converter"
 )
 
 // statFile is a helper to get file info
@@ -145,3 +150,88 @@ func GetFileCategory(ext string) string {
 		return "other"
 	}
 }
+
+// describeHiddenTargets summarizes targets left out because tools or encoders
+// are missing, e.g. "Unavailable: .mkv (libx265 encoder); run golter doctor".
+func describeHiddenTargets(hidden map[string][]string) string {
+	if len(hidden) == 0 {
+		return ""
+	}
+	targets := make([]string, 0, len(hidden))
+	for t := range hidden {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	parts := make([]string, 0, len(targets))
+	for _, t := range targets {
+		parts = append(parts, fmt.Sprintf("%s (%s)", t, strings.Join(hidden[t], ", ")))
+	}
+	return "Unavailable: " + strings.Join(parts, ", ") + "; run golter doctor"
+}
+
+// routeHints returns the intermediate formats of each target that no single
+// converter handles, e.g. ".pdf" for ".docx" to ".epub".
+func routeHints(mgr *converter.Manager, srcExt string, targets []string) map[string]string {
+	hints := make(map[string]string)
+	for _, t := range targets {
+		steps, err := mgr.FindRoute(srcExt, t)
+		if err != nil || len(steps) < 2 {
+			continue
+		}
+		via := make([]string, 0, len(steps)-1)
+		for _, s := range steps[1:] {
+			via = append(via, s.Source)
+		}
+		hints[t] = strings.Join(via, ", ")
+	}
+	return hints
+}
+
+// failureTitles names each batch error class in the results screen, in the
+// order groups are listed.
+var failureTitles = []struct{ class, title string }{
+	{batch.ErrorClassMissingTool, "Missing dependency"},
+	{batch.ErrorClassUnsupported, "Unsupported conversion"},
+	{batch.ErrorClassInvalidOption, "Invalid option"},
+	{batch.ErrorClassCorruptInput, "Unreadable source"},
+	{batch.ErrorClassNotFound, "Source not found"},
+	{batch.ErrorClassEncode, "Could not write output"},
+	{batch.ErrorClassToolFailed, "External tool failed"},
+	{batch.ErrorClassVerification, "Failed verification"},
+	{batch.ErrorClassConversion, "Conversion failed"},
+	{batch.ErrorClassCancelled, "Cancelled"},
+}
+
+// groupFailures lists failed results grouped by error class, each group
+// headed by its title, file count and the distinct fixes converter.Hint
+// suggests for it.
+func groupFailures(failed []batch.Result) []string {
+	groups := make(map[string][]batch.Result)
+	for _, res := range failed {
+		class := batch.ErrorClass(res.Err)
+		groups[class] = append(groups[class], res)
+	}
+
+	var lines []string
+	for _, g := range failureTitles {
+		results := groups[g.class]
+		if len(results) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %s (%d)", g.title, len(results)))
+		seen := make(map[string]bool)
+		for _, res := range results {
+			if hint := converter.Hint(res.Err); hint != "" && !seen[hint] {
+				seen[hint] = true
+				lines = append(lines, fmt.Sprintf("    %s %s", iconArrowRight, hint))
+			}
+		}
+		for _, res := range results {
+			lines = append(lines, fmt.Sprintf("  %s %s: %v", iconError, filepath.Base(res.Path), res.Err))
+			if res.Warning != "" {
+				lines = append(lines, fmt.Sprintf("    %s%s", iconWarning, res.Warning))
+			}
+		}
+	}
+	return lines
+}
@@ -3,9 +3,11 @@ package tui
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/sametcn99/golter/internal/batch"
 	"github.com/sametcn99/golter/internal/version"
 )
 
@@ -36,8 +38,11 @@ func (m Model) View() string {
 	case StateSelectingFormat:
 		m.renderFormatState(&s)
 
-	case StateSelectingQuality:
-		m.renderQualityState(&s)
+	case StateSelectingOptions:
+		m.renderOptionsState(&s)
+
+	case StateReviewingPlan:
+		m.renderPlanState(&s)
 
 	case StateConverting:
 		m.renderConvertingState(&s)
@@ -60,6 +65,11 @@ func (m Model) View() string {
 }
 
 func (m *Model) renderSelectingState(s *strings.Builder) {
+	if m.resumable != nil {
+		done, total := m.resumable.Progress()
+		s.WriteString(infoStyle.Render(fmt.Sprintf("  %s An interrupted batch has %d of %d files left", iconWarning, total-done, total)) + "\n")
+		s.WriteString(mutedStyle.Render("  Press Ctrl+R to resume it or x to discard it") + "\n\n")
+	}
 	s.WriteString(stateTitleStyle.Render("Select files to convert") + "\n")
 	s.WriteString(m.selector.View())
 	s.WriteString("\n")
@@ -74,7 +84,7 @@ func (m *Model) renderSelectingState(s *strings.Builder) {
 
 func (m *Model) renderActionState(s *strings.Builder) {
 	// Get file type info
-	ext := filepath.Ext(m.selectedFiles[0])
+	ext := m.sourceExt
 	supportedTargets := m.manager.GetSupportedTargetFormats(ext)
 
 	// Rebuild action options dynamically
@@ -107,7 +117,7 @@ func (m *Model) renderActionState(s *strings.Builder) {
 
 func (m *Model) renderFormatState(s *strings.Builder) {
 	fileCount := len(m.selectedFiles)
-	srcExt := filepath.Ext(m.selectedFiles[0])
+	srcExt := m.sourceExt
 
 	s.WriteString(stateTitleStyle.Render(fmt.Sprintf("Select target format (from %s)", srcExt)) + "\n")
 	s.WriteString(mutedStyle.Render(fmt.Sprintf("  Converting %d file(s)", fileCount)) + "\n\n")
@@ -115,6 +125,9 @@ func (m *Model) renderFormatState(s *strings.Builder) {
 	for i, format := range m.targetFormats {
 		icon := getFormatIcon(format)
 		formatDisplay := fmt.Sprintf("%s  %s", icon, format)
+		if via := m.targetVia[format]; via != "" {
+			formatDisplay += "  " + mutedStyle.Render("via "+via)
+		}
 
 		if m.cursor == i {
 			s.WriteString(selectedMenuItemStyle.Render(formatDisplay) + "\n")
@@ -122,19 +135,109 @@ func (m *Model) renderFormatState(s *strings.Builder) {
 			s.WriteString(menuItemStyle.Render(formatDisplay) + "\n")
 		}
 	}
+
+	if m.hiddenTargets != "" {
+		s.WriteString("\n" + mutedStyle.Render("  "+m.hiddenTargets) + "\n")
+	}
 }
 
-func (m *Model) renderQualityState(s *strings.Builder) {
-	s.WriteString(stateTitleStyle.Render("Select compression quality") + "\n")
-	s.WriteString(mutedStyle.Render(fmt.Sprintf("  Compressing %d file(s)", len(m.selectedFiles))) + "\n\n")
+func (m *Model) renderOptionsState(s *strings.Builder) {
+	if m.targetFormat == "" {
+		s.WriteString(stateTitleStyle.Render("Compression options") + "\n")
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  Compressing %d file(s)", len(m.selectedFiles))) + "\n\n")
+	} else {
+		s.WriteString(stateTitleStyle.Render(fmt.Sprintf("Conversion options (%s to %s)", m.sourceExt, m.targetFormat)) + "\n")
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  Converting %d file(s)", len(m.selectedFiles))) + "\n\n")
+	}
+	s.WriteString(m.optionsForm.View())
+}
 
-	for i, q := range m.qualityOptions {
-		if m.cursor == i {
-			s.WriteString(selectedMenuItemStyle.Render(q) + "\n")
-		} else {
-			s.WriteString(menuItemStyle.Render(q) + "\n")
-		}
+func (m *Model) renderPlanState(s *strings.Builder) {
+	s.WriteString(stateTitleStyle.Render("Review the plan") + "\n")
+	if m.targetFormat == "" {
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  Compressing %d file(s)", len(m.selectedFiles))) + "\n\n")
+	} else {
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  Converting %d file(s) to %s", len(m.selectedFiles), m.targetFormat)) + "\n\n")
+	}
+	if m.plan == nil {
+		s.WriteString(fmt.Sprintf("  %s Checking the files...\n", m.spinner.View()))
+		return
+	}
+
+	// The cursor is the first row shown
+	start := m.cursor
+	end := min(len(m.plan.Items), start+m.planRows())
+	if start > 0 {
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  %s %d more above", iconChevronRight, start)) + "\n")
+	}
+	for _, it := range m.plan.Items[start:end] {
+		s.WriteString(renderPlanItem(it) + "\n")
+	}
+	if rest := len(m.plan.Items) - end; rest > 0 {
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  %s %d more below", iconChevronDown, rest)) + "\n")
+	}
+
+	input, estimated := m.plan.Sizes()
+	summary := fmt.Sprintf("%d to convert", m.plan.Converting())
+	if n := m.plan.Conflicts(); n > 0 {
+		summary += fmt.Sprintf(", %d with existing outputs", n)
+	}
+	if n := m.plan.Failing(); n > 0 {
+		summary += fmt.Sprintf(", %d will fail", n)
+	}
+	summary += fmt.Sprintf("  %s %s %s ~%s", iconArrowRight, FormatSize(input), iconArrowRight, FormatSize(estimated))
+	s.WriteString("\n" + infoStyle.Render("  "+summary) + "\n")
+}
+
+// renderPlanItem renders one file of the plan with what stands out about it.
+func renderPlanItem(it batch.PlanItem) string {
+	name := filepath.Base(it.Path)
+	if it.Err != nil {
+		return errorStyle.Render(fmt.Sprintf("  %s %s: %v", iconError, name, it.Err))
+	}
+
+	size := FormatSize(it.InputSize)
+	switch {
+	case it.Skipped:
+	case it.EstimatedSize > 0:
+		size += fmt.Sprintf(" %s ~%s", iconArrowRight, FormatSize(it.EstimatedSize))
+	default:
+		size += fmt.Sprintf(" %s ?", iconArrowRight)
+	}
+
+	var notes []string
+	switch it.Conflict {
+	case batch.ConflictOverwrite:
+		notes = append(notes, "overwrites existing output")
+	case batch.ConflictRename:
+		notes = append(notes, "output exists, renamed")
+	case batch.ConflictSkip:
+		notes = append(notes, "output exists, skipped")
+	case batch.ConflictAsk:
+		notes = append(notes, "output exists, will ask")
+	}
+	if it.Cached {
+		notes = append(notes, "unchanged, reused")
+	}
+	if len(it.Tools) > 0 {
+		notes = append(notes, "runs "+strings.Join(it.Tools, ", "))
+	}
+
+	icon := iconSuccess
+	if it.Skipped {
+		icon = iconSkipped
 	}
+	line := fmt.Sprintf("  %s %s %s %s  %s  %s", icon, name, iconArrowRight, filepath.Base(it.OutputPath), mutedStyle.Render(it.Converter), size)
+	if len(notes) > 0 {
+		line += "  " + mutedStyle.Render(strings.Join(notes, ", "))
+	}
+	if len(it.Missing) > 0 {
+		line += "\n" + errorStyle.Render(fmt.Sprintf("    %s Missing %s", iconWarning, strings.Join(it.Missing, ", ")))
+	}
+	if it.Warning != "" {
+		line += "\n" + mutedStyle.Render(fmt.Sprintf("    %s%s", iconWarning, it.Warning))
+	}
+	return line
 }
 
 func (m *Model) renderConvertingState(s *strings.Builder) {
@@ -143,24 +246,46 @@ func (m *Model) renderConvertingState(s *strings.Builder) {
 	// Spinner and status
 	s.WriteString(fmt.Sprintf("  %s %s\n\n", m.spinner.View(), m.currentStatus))
 
+	if m.conflict != nil {
+		confirmBox := confirmStyle.Render(
+			infoStyle.Render(iconWarning+" Output already exists") + "\n\n" +
+				m.conflict.path + "\n\n" +
+				"[o] Overwrite  [s] Skip  [r] Rename\n" +
+				mutedStyle.Render("Shift+key applies to all remaining conflicts, Esc cancels"),
+		)
+		s.WriteString(confirmBox + "\n\n")
+	}
+
 	// Progress bar
+	overall := m.overallProgress()
 	if m.progressTotal > 0 {
-		progressPercent := float64(m.progressCurrent) / float64(m.progressTotal)
-		s.WriteString("  " + m.progress.ViewAs(progressPercent) + "\n")
-		s.WriteString(progressTextStyle.Render(fmt.Sprintf("  %d / %d files processed", m.progressCurrent, m.progressTotal)) + "\n\n")
+		s.WriteString("  " + m.progress.ViewAs(overall) + "\n")
+		s.WriteString(progressTextStyle.Render(fmt.Sprintf("  %d / %d files processed (%d%%)", m.progressCurrent, m.progressTotal, int(overall*100))) + "\n\n")
 	}
 
-	// Current file being processed
-	if m.currentFile != "" {
-		s.WriteString(currentFileStyle.Render(fmt.Sprintf("  Processing: %s", filepath.Base(m.currentFile))) + "\n")
+	// Files being processed, with their own progress
+	running := make([]string, 0, len(m.fileProgress))
+	for file := range m.fileProgress {
+		running = append(running, file)
+	}
+	sort.Strings(running)
+	for _, file := range running {
+		s.WriteString(currentFileStyle.Render(fmt.Sprintf("  Processing: %s %3d%%", filepath.Base(file), int(m.fileProgress[file]*100))) + "\n")
 	}
 
-	// Elapsed time
+	// Elapsed time and estimate
 	elapsed := time.Since(m.startTime)
-	s.WriteString(mutedStyle.Render(fmt.Sprintf("\n  Elapsed: %s", formatDuration(elapsed))) + "\n")
+	timing := fmt.Sprintf("\n  Elapsed: %s", formatDuration(elapsed))
+	if overall > 0 && overall < 1 {
+		remaining := time.Duration(float64(elapsed) / overall * (1 - overall))
+		timing += fmt.Sprintf("  ETA: %s", formatDuration(remaining))
+	}
+	s.WriteString(mutedStyle.Render(timing) + "\n")
 
 	// Cancel hint
-	s.WriteString("\n" + mutedStyle.Render("  Press Ctrl+C to cancel") + "\n")
+	if !m.cancelling {
+		s.WriteString("\n" + mutedStyle.Render("  Press Esc to cancel, Ctrl+C to quit") + "\n")
+	}
 }
 
 func (m *Model) renderDoneState(s *strings.Builder) {
@@ -179,6 +304,10 @@ func (m *Model) renderDoneState(s *strings.Builder) {
 		)
 		s.WriteString(successBox + "\n")
 	}
+
+	if m.reportStatus != "" {
+		s.WriteString(mutedStyle.Render("  "+m.reportStatus) + "\n")
+	}
 }
 
 func (m *Model) renderQuittingState(s *strings.Builder) {
@@ -202,10 +331,36 @@ func (m Model) renderFooter() string {
 			RenderHelpKey("d", "Deselect"),
 			RenderHelpKey("Enter", "Open folder"),
 			RenderHelpKey("c", "Confirm"),
+			RenderHelpKey("r", "Run job file"),
 			RenderHelpKey("/", "Filter"),
 			RenderHelpKey("q", "Quit"),
 		}
-	case StateSelectingAction, StateSelectingFormat, StateSelectingQuality:
+		if m.resumable != nil {
+			shortcuts = append(shortcuts, RenderHelpKey("Ctrl+R", "Resume batch"), RenderHelpKey("x", "Discard batch"))
+		}
+	case StateSelectingOptions:
+		if m.optionsForm.editing {
+			shortcuts = []string{
+				RenderHelpKey("Enter", "Set value"),
+				RenderHelpKey("Esc", "Discard"),
+			}
+		} else {
+			shortcuts = []string{
+				RenderHelpKey("↑↓/jk", "Navigate"),
+				RenderHelpKey("←→/hl", "Change"),
+				RenderHelpKey("Enter", "Edit/Start"),
+				RenderHelpKey("Esc", "Back"),
+				RenderHelpKey("q", "Quit"),
+			}
+		}
+	case StateReviewingPlan:
+		shortcuts = []string{
+			RenderHelpKey("↑↓/jk", "Scroll"),
+			RenderHelpKey("Enter", "Start"),
+			RenderHelpKey("Esc", "Back"),
+			RenderHelpKey("q", "Quit"),
+		}
+	case StateSelectingAction, StateSelectingFormat:
 		shortcuts = []string{
 			RenderHelpKey("↑↓/jk", "Navigate"),
 			RenderHelpKey("Enter", "Select"),
@@ -215,6 +370,7 @@ func (m Model) renderFooter() string {
 	case StateDone:
 		shortcuts = []string{
 			RenderHelpKey("Esc", "New conversion"),
+			RenderHelpKey("s", "Save report"),
 			RenderHelpKey("q", "Quit"),
 		}
 	}
@@ -0,0 +1,260 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+// StateFileName is the default name of the file recording handled files.
+const StateFileName = ".golter-watch.json"
+
+// DefaultInterval is how often the watched directory is scanned.
+const DefaultInterval = 2 * time.Second
+
+// stamp identifies a version of a file by size and modification time.
+type stamp struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// sniffed is the format of a file's content at one version of the file.
+type sniffed struct {
+	stamp  stamp
+	format string
+}
+
+// state is persisted between runs so restarts don't reprocess handled files.
+type state struct {
+	Handled map[string]stamp `json:"handled"`
+	Outputs map[string]bool  `json:"outputs"`
+}
+
+// Watcher converts files as they appear in a directory.
+type Watcher struct {
+	Dir       string
+	TargetExt string
+	Quality   string
+	OutDir    string
+	// Jobs and Limits bound concurrent conversions (see batch.Job).
+	Jobs     int
+	Limits   batch.Limits
+	Interval time.Duration
+	// Options are passed to every conversion.
+	Options converter.Options
+	// StatePath is where handled files are recorded. Defaults to Dir/.golter-watch.json.
+	StatePath string
+	Manager   *converter.Manager
+	// OnResult is called for every converted file.
+	OnResult func(batch.Result)
+	// Cache, when not nil, skips sources whose content was converted
+	// before, such as files that were touched or copied back in. It is
+	// saved after every poll that converts something.
+	Cache *cache.Cache
+	// Verify reads every output back, failing files whose output is
+	// unreadable (see batch.Job.Verify).
+	Verify bool
+
+	state   state
+	pending map[string]stamp
+	// formats remembers what the files seen on the last poll contain, so
+	// unchanged files are not read again.
+	formats map[string]sniffed
+}
+
+// New creates a Watcher for dir converting new files to targetExt.
+func New(mgr *converter.Manager, dir, targetExt string) *Watcher {
+	return &Watcher{
+		Dir:       dir,
+		TargetExt: targetExt,
+		Quality:   "High",
+		Interval:  DefaultInterval,
+		StatePath: filepath.Join(dir, StateFileName),
+		Manager:   mgr,
+	}
+}
+
+// Run polls the directory until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	if err := w.load(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans the directory once and converts files that have stopped changing
+// since the previous scan. Files still being written are picked up on a later poll.
+// Conversions interrupted by ctx are not recorded, so they run again next time.
+func (w *Watcher) Poll(ctx context.Context) (batch.Report, error) {
+	if w.state.Handled == nil {
+		if err := w.load(); err != nil {
+			return batch.Report{}, err
+		}
+	}
+
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		return batch.Report{}, fmt.Errorf("failed to read watch directory: %w", err)
+	}
+
+	seen := make(map[string]stamp)
+	formats := make(map[string]sniffed)
+	// Which formats convert to the target is looked up once per poll
+	supported := make(map[string]bool)
+	var ready []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(w.Dir, e.Name())
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		current := stamp{Size: info.Size(), ModTime: info.ModTime()}
+		if handled, ok := w.state.Handled[path]; ok && handled.equal(current) {
+			continue
+		}
+		f, ok := w.formats[path]
+		if !ok || !f.stamp.equal(current) {
+			f = sniffed{stamp: current, format: detectFormat(path)}
+		}
+		formats[path] = f
+		if !w.isCandidate(path, f.format, supported) {
+			continue
+		}
+
+		seen[path] = current
+		if prev, ok := w.pending[path]; ok && prev.equal(current) {
+			ready = append(ready, path)
+		}
+	}
+	w.pending = seen
+	w.formats = formats
+
+	if len(ready) == 0 {
+		return batch.Report{}, nil
+	}
+	sort.Strings(ready)
+
+	report := batch.Run(ctx, w.Manager, batch.Job{
+		Files:     ready,
+		TargetExt: w.TargetExt,
+		Quality:   w.Quality,
+		OutDir:    w.OutDir,
+		Jobs:      w.Jobs,
+		Limits:    w.Limits,
+		Options:   w.Options,
+		// A changed source replaces the output it produced earlier
+		Conflict: batch.ConflictOverwrite,
+		Cache:    w.Cache,
+		Verify:   w.Verify,
+	}, w.OnResult)
+
+	for _, res := range report.Results {
+		if batch.ErrorClass(res.Err) == batch.ErrorClassCancelled {
+			continue
+		}
+		w.state.Handled[res.Path] = seen[res.Path]
+		delete(w.pending, res.Path)
+		if res.OutputPath != "" {
+			w.state.Outputs[res.OutputPath] = true
+		}
+	}
+	if w.Cache != nil {
+		if err := w.Cache.Save(); err != nil {
+			return report, err
+		}
+	}
+	return report, w.save()
+}
+
+// isCandidate reports whether path, whose content is in format, is a source
+// the watcher should convert. supported memoizes which formats have a
+// converter to the target.
+func (w *Watcher) isCandidate(path, format string, supported map[string]bool) bool {
+	if w.state.Outputs[path] || path == w.StatePath {
+		return false
+	}
+	if format == "" || format == w.TargetExt || converter.NormalizeExt(filepath.Ext(path)) == w.TargetExt {
+		return false
+	}
+	ok, known := supported[format]
+	if !known {
+		_, err := w.Manager.FindConverter(format, w.TargetExt)
+		ok = err == nil
+		supported[format] = ok
+	}
+	return ok
+}
+
+// detectFormat returns the format of the file at path as sniffed from its
+// content, falling back to its extension.
+func detectFormat(path string) string {
+	d, _ := converter.DetectFile(path)
+	return d.Format()
+}
+
+func (w *Watcher) load() error {
+	w.state = state{
+		Handled: make(map[string]stamp),
+		Outputs: make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(w.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, &w.state); err != nil {
+		return fmt.Errorf("failed to parse watch state %s: %w", w.StatePath, err)
+	}
+	if w.state.Handled == nil {
+		w.state.Handled = make(map[string]stamp)
+	}
+	if w.state.Outputs == nil {
+		w.state.Outputs = make(map[string]bool)
+	}
+	return nil
+}
+
+func (w *Watcher) save() error {
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch state: %w", err)
+	}
+	// Written atomically so a crash never leaves a torn state file
+	if err := converter.WriteFileAtomic(w.StatePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+func (s stamp) equal(other stamp) bool {
+	return s.Size == other.Size && s.ModTime.Equal(other.ModTime)
+}
@@ -0,0 +1,156 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sametcn99/golter/internal/batch"
+	"github.com/sametcn99/golter/internal/cache"
+	"github.com/sametcn99/golter/internal/converter"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	dir := t.TempDir()
+	w := New(converter.NewDefaultManager(), dir, ".yaml")
+
+	src := filepath.Join(dir, "config.json")
+	writeFile(t, src, `{"a":1}`)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "ignored")
+
+	// First poll only records the file; it may still be written to.
+	report, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(report.Results) != 0 {
+		t.Fatalf("expected no conversions on first sight, got %d", len(report.Results))
+	}
+
+	// Second poll sees the same size and mtime and converts it.
+	report, err = w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Err != nil {
+		t.Fatalf("expected one successful conversion, got %+v", report.Results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.yaml")); err != nil {
+		t.Errorf("expected output file: %v", err)
+	}
+
+	// Handled files are not converted again.
+	for i := 0; i < 2; i++ {
+		report, err = w.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(report.Results) != 0 {
+			t.Errorf("expected handled file to be skipped, got %+v", report.Results)
+		}
+	}
+}
+
+func TestWatcher_StatePersists(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "data.json"), `[1,2]`)
+
+	w := New(converter.NewDefaultManager(), dir, ".yaml")
+	for i := 0; i < 2; i++ {
+		if _, err := w.Poll(context.Background()); err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+	}
+	if _, err := os.Stat(w.StatePath); err != nil {
+		t.Fatalf("expected state file: %v", err)
+	}
+
+	// A restarted watcher must not reprocess the handled file.
+	restarted := New(converter.NewDefaultManager(), dir, ".yaml")
+	for i := 0; i < 2; i++ {
+		report, err := restarted.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(report.Results) != 0 {
+			t.Errorf("restarted watcher reprocessed %+v", report.Results)
+		}
+	}
+}
+
+func TestWatcher_CacheSkipsTouchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.json")
+	writeFile(t, src, `[1,2]`)
+	c, err := cache.OpenFile(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(converter.NewDefaultManager(), dir, ".yaml")
+	w.Cache = c
+	poll := func() batch.Report {
+		t.Helper()
+		var report batch.Report
+		for i := 0; i < 2; i++ {
+			r, err := w.Poll(context.Background())
+			if err != nil {
+				t.Fatalf("Poll failed: %v", err)
+			}
+			report.Results = append(report.Results, r.Results...)
+		}
+		return report
+	}
+	if report := poll(); len(report.Results) != 1 || report.Cached() != 0 {
+		t.Fatalf("expected one conversion, got %+v", report.Results)
+	}
+	if _, err := os.Stat(c.File()); err != nil {
+		t.Errorf("expected the cache to be saved: %v", err)
+	}
+
+	// Rewriting the same content changes the mtime but not what is converted
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(src, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if report := poll(); len(report.Results) != 1 || report.Cached() != 1 {
+		t.Errorf("expected the touched file to reuse its output, got %+v", report.Results)
+	}
+}
+
+func TestWatcher_DetectsContent(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	// A PNG saved under a name no converter reads
+	writeFile(t, filepath.Join(dir, "photo.dat"), buf.String())
+
+	w := New(converter.NewDefaultManager(), dir, ".jpg")
+	var report batch.Report
+	for i := 0; i < 2; i++ {
+		var err error
+		if report, err = w.Poll(context.Background()); err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+	}
+	if len(report.Results) != 1 || report.Results[0].Err != nil {
+		t.Fatalf("expected the PNG to be converted by its content, got %+v", report.Results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "photo.jpg")); err != nil {
+		t.Errorf("expected output file: %v", err)
+	}
+}
@@ -4,19 +4,32 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/sametcn99/golter/internal/cli"
+	"github.com/sametcn99/golter/internal/config"
 	"github.com/sametcn99/golter/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	// Run a non-interactive command when one is given
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// Get initial path from args or use current directory
 	initialPath := ""
 	if len(os.Args) > 1 {
 		initialPath = os.Args[1]
 	}
 
-	model := tui.NewModel(initialPath)
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	model := tui.NewModel(initialPath, cfg)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
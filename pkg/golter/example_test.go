@@ -0,0 +1,17 @@
+package golter_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sametcn99/golter/pkg/golter"
+)
+
+func ExampleConvert() {
+	in := strings.NewReader(`{"name": "golter"}`)
+	if err := golter.Convert(in, os.Stdout, ".json", ".yaml", nil); err != nil {
+		fmt.Println(err)
+	}
+	// Output: name: golter
+}
@@ -0,0 +1,228 @@
+// Package golter exposes golter's converters as a Go library.
+//
+// New returns a Manager with every built-in converter registered. Conversions
+// can be run on files or on streams:
+//
+//	m := golter.New()
+//	err := m.ConvertStream(r, w, ".yaml", ".json", nil)
+//
+// Image, data and Markdown/HTML conversions stream in memory; conversions that
+// need ffmpeg, Pandoc or Calibre are spooled through temporary files.
+package golter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/sametcn99/golter/internal/converter"
+	"github.com/sametcn99/golter/internal/plugin"
+)
+
+// Core types, shared with the golter CLI and TUI.
+type (
+	// Manager finds the converter for a source/target pair.
+	Manager = converter.Manager
+	// Converter converts files between formats.
+	Converter = converter.Converter
+	// StreamConverter is implemented by converters that can work on
+	// io.Reader/io.Writer without touching disk.
+	StreamConverter = converter.StreamConverter
+	// ContextConverter is implemented by converters that can be cancelled mid-way.
+	ContextConverter = converter.ContextConverter
+	// ToolRequirer is implemented by converters that shell out to external tools.
+	ToolRequirer = converter.ToolRequirer
+	// Options holds converter-specific settings such as "quality".
+	Options = converter.Options
+	// Conversion describes one supported source/target pair.
+	Conversion = converter.Conversion
+	// ProgressFunc receives the completed fraction of a conversion.
+	ProgressFunc = converter.ProgressFunc
+	// Detection compares a file's extension with the format found in its content.
+	Detection = converter.Detection
+	// OptionSpec describes one option a converter accepts.
+	OptionSpec = converter.OptionSpec
+	// OptionDescriber is implemented by converters that declare their options.
+	OptionDescriber = converter.OptionDescriber
+	// EncoderRequirer is implemented by converters that need specific ffmpeg encoders.
+	EncoderRequirer = converter.EncoderRequirer
+	// Prioritizer is implemented by converters that rank themselves against
+	// others handling the same pair; see Manager.Pin to choose one outright.
+	Prioritizer = converter.Prioritizer
+	// Cost classifies a conversion as light in-process work or a heavy
+	// external program.
+	Cost = converter.Cost
+	// Coster is implemented by converters that declare their cost.
+	Coster = converter.Coster
+	// Estimator is implemented by converters that can predict their output size.
+	Estimator = converter.Estimator
+	// Capabilities records which external tools and ffmpeg encoders are installed.
+	Capabilities = converter.Capabilities
+	// Tool is the result of probing one external program.
+	Tool = converter.Tool
+	// MissingToolError reports an external program that is not installed.
+	MissingToolError = converter.MissingToolError
+	// ToolError reports an external program that failed, with its exit code
+	// and the tail of its output.
+	ToolError = converter.ToolError
+	// Runner runs the external tools converters depend on; see WithRunner.
+	Runner = converter.Runner
+	// Command is one run of an external tool.
+	Command = converter.Command
+	// ExecRunner runs tools as processes, with configurable binary paths,
+	// environment, working directory and timeout.
+	ExecRunner = converter.ExecRunner
+	// RecordingRunner records commands instead of running them, for tests.
+	RecordingRunner = converter.RecordingRunner
+)
+
+// Built-in converters, for registering on a custom Manager.
+type (
+	ImageConverter    = converter.ImageConverter
+	VideoConverter    = converter.VideoConverter
+	AudioConverter    = converter.AudioConverter
+	DocumentConverter = converter.DocumentConverter
+	DocDataConverter  = converter.DocDataConverter
+)
+
+// External converter plugins; see RegisterPlugins.
+type (
+	// Plugin is a converter backed by an external executable.
+	Plugin = plugin.Converter
+	// PluginManifest is what a plugin reports about itself.
+	PluginManifest = plugin.Manifest
+)
+
+// Values for the "quality" option.
+const (
+	QualityHigh     = converter.QualityHigh
+	QualityBalanced = converter.QualityBalanced
+	QualityCompact  = converter.QualityCompact
+)
+
+var (
+	// ErrNoConverter is returned when no converter supports a source/target pair.
+	ErrNoConverter = converter.ErrNoConverter
+	// ErrInvalidOption is returned when an option does not match the converter's schema.
+	ErrInvalidOption = converter.ErrInvalidOption
+	// ErrVerification is returned when an output does not read back as its format.
+	ErrVerification = converter.ErrVerification
+	// ErrMissingTool is returned when a conversion needs a program that is not installed.
+	ErrMissingTool = converter.ErrMissingTool
+	// ErrCorruptInput is returned when the source cannot be read as its format.
+	ErrCorruptInput = converter.ErrCorruptInput
+	// ErrEncode is returned when the output could not be produced.
+	ErrEncode = converter.ErrEncode
+	// ErrToolFailed is returned when an external program exits with an error.
+	ErrToolFailed = converter.ErrToolFailed
+	// ErrCancelled is returned, with the context's error, when a conversion is cancelled.
+	ErrCancelled = converter.ErrCancelled
+)
+
+// Hint suggests how to fix a conversion error, or returns "".
+func Hint(err error) string {
+	return converter.Hint(err)
+}
+
+// New returns a Manager with all built-in converters registered.
+func New() *Manager {
+	return converter.NewDefaultManager()
+}
+
+// RegisterPlugins registers the converter plugins found in dir on m. Plugins
+// that cannot be described are skipped and reported in the returned error.
+func RegisterPlugins(ctx context.Context, m *Manager, dir string) error {
+	return plugin.Register(ctx, m, dir)
+}
+
+// ProbeTools reports which of the external tools golter uses are installed,
+// with their versions and ffmpeg's encoders. Pass the result to
+// Manager.SetCapabilities to hide conversions that cannot run.
+func ProbeTools(ctx context.Context) *Capabilities {
+	return converter.ProbeTools(ctx, converter.KnownTools...)
+}
+
+// NewManager returns a Manager with no converters registered.
+func NewManager() *Manager {
+	return converter.NewManager()
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultManager *Manager
+)
+
+func defaultMgr() *Manager {
+	defaultOnce.Do(func() {
+		defaultManager = New()
+	})
+	return defaultManager
+}
+
+// Convert reads srcExt data from r and writes it to w converted to targetExt,
+// using the built-in converters.
+func Convert(r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	return ConvertContext(context.Background(), r, w, srcExt, targetExt, opts)
+}
+
+// ConvertContext is Convert with a context: conversions through external
+// tools use the Runner set with WithRunner and stop when ctx is done.
+func ConvertContext(ctx context.Context, r io.Reader, w io.Writer, srcExt, targetExt string, opts Options) error {
+	return defaultMgr().ConvertStreamContext(ctx, r, w, srcExt, targetExt, opts)
+}
+
+// DetectFile sniffs the format of the file at path from its content.
+func DetectFile(path string) (Detection, error) {
+	return converter.DetectFile(path)
+}
+
+// ConvertFile converts the file at src to target. The source format is
+// detected from the file content and the target format from its extension.
+func ConvertFile(src, target string, opts Options) error {
+	return ConvertFileContext(context.Background(), src, target, opts)
+}
+
+// ConvertFileContext is ConvertFile with cancellation; external tools are
+// killed when ctx is cancelled. The output is written to a temporary file
+// next to target and moved over it only once the conversion succeeds, so a
+// failed or cancelled call leaves target as it was.
+func ConvertFileContext(ctx context.Context, src, target string, opts Options) error {
+	c, d, err := defaultMgr().FindConverterForFile(src, filepath.Ext(target))
+	if err != nil {
+		return err
+	}
+	staged, cleanup, err := converter.StageSource(src, d.Format())
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := converter.ConvertContext(ctx, c, staged, target, opts); err != nil {
+		return fmt.Errorf("failed to convert %s: %w", src, err)
+	}
+	return nil
+}
+
+// WithProgress returns a context that makes ConvertFileContext report
+// progress to fn. Converters that cannot measure progress do not call it.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return converter.WithProgress(ctx, fn)
+}
+
+// WithRunner returns a context whose conversions and tool probes run
+// external tools through r, e.g. an ExecRunner pointing at a static ffmpeg.
+func WithRunner(ctx context.Context, r Runner) context.Context {
+	return converter.WithRunner(ctx, r)
+}
+
+// WithThreads returns a context that limits conversions to n threads, e.g.
+// the -threads given to ffmpeg. Without it they may use every CPU.
+func WithThreads(ctx context.Context, n int) context.Context {
+	return converter.WithThreads(ctx, n)
+}
+
+// Conversions lists every conversion supported by the built-in converters.
+func Conversions() []Conversion {
+	return defaultMgr().Conversions()
+}
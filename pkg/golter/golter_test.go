@@ -0,0 +1,110 @@
+package golter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert_Stream(t *testing.T) {
+	var out bytes.Buffer
+	if err := Convert(strings.NewReader("name: golter\n"), &out, ".yaml", ".json", nil); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"name": "golter"`) {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+}
+
+func TestConvert_Unsupported(t *testing.T) {
+	err := Convert(strings.NewReader("x"), &bytes.Buffer{}, ".json", ".mp4", nil)
+	if !errors.Is(err, ErrNoConverter) {
+		t.Errorf("expected ErrNoConverter, got %v", err)
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.json")
+	target := filepath.Join(dir, "data.yaml")
+	if err := os.WriteFile(src, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConvertFile(src, target, Options{"quality": QualityHigh}); err != nil {
+		t.Fatalf("ConvertFile failed: %v", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "a: 1") {
+		t.Errorf("unexpected output: %s", data)
+	}
+}
+
+func TestConvertFileContext_KeepsTargetOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.json")
+	target := filepath.Join(dir, "data.yaml")
+	if err := os.WriteFile(src, []byte(`{"a": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConvertFile(src, target, nil); err == nil {
+		t.Fatal("expected truncated JSON to fail")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ConvertFileContext(ctx, src, target, nil); err == nil {
+		t.Fatal("expected a cancelled conversion to fail")
+	}
+
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Errorf("expected the existing target to be kept, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected no temporary output to be left behind, got %v", entries)
+	}
+}
+
+func TestNewManager_CustomRegistration(t *testing.T) {
+	m := NewManager()
+	if _, err := m.FindConverter(".json", ".yaml"); err == nil {
+		t.Fatal("expected an empty manager to find no converter")
+	}
+	m.Register(&DocDataConverter{})
+	if _, err := m.FindConverter(".json", ".yaml"); err != nil {
+		t.Errorf("expected registered converter to be found: %v", err)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	if len(Conversions()) == 0 {
+		t.Error("expected built-in conversions")
+	}
+}
+
+func TestConvertFileContext_Progress(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.yaml")
+	if err := os.WriteFile(src, []byte("name: golter\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last float64
+	ctx := WithProgress(context.Background(), func(f float64) { last = f })
+	if err := ConvertFileContext(ctx, src, filepath.Join(dir, "data.json"), nil); err != nil {
+		t.Fatalf("ConvertFileContext failed: %v", err)
+	}
+	if last != 1 {
+		t.Errorf("expected progress to reach 1, got %v", last)
+	}
+}